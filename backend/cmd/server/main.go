@@ -9,15 +9,18 @@ import (
 	"sync"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/charmbracelet/log"
 	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
 
 	"github.com/halceonio/kubelens/backend/internal/auth"
 	"github.com/halceonio/kubelens/backend/internal/config"
 	"github.com/halceonio/kubelens/backend/internal/k8s"
 	"github.com/halceonio/kubelens/backend/internal/server"
 	"github.com/halceonio/kubelens/backend/internal/storage"
+	"github.com/halceonio/kubelens/backend/internal/tracing"
 )
 
 func main() {
@@ -35,16 +38,26 @@ func main() {
 		logger.Fatal("config error", "err", err)
 	}
 	logger.Info("loaded config", "path", path)
+	logEffectiveConfig(logger, cfg)
+	logConfigValidation(logger, cfg)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	verifier, err := auth.NewVerifier(ctx, cfg.Auth)
+	verifier, err := auth.NewVerifier(ctx, cfg.Auth, cfg.TLS)
 	if err != nil {
 		logger.Fatal("auth setup error", "err", err)
 	}
 	dynamicVerifier := auth.NewDynamicVerifier(verifier)
 
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Server.Otel)
+	if err != nil {
+		logger.Fatal("tracing setup error", "err", err)
+	}
+	if cfg.Server.Otel.Endpoint != "" {
+		logger.Info("tracing enabled", "endpoint", cfg.Server.Otel.Endpoint)
+	}
+
 	k8sClient, metaClient, err := k8s.NewClients(cfg.Kubernetes)
 	if err != nil {
 		logger.Fatal("k8s client error", "err", err)
@@ -58,15 +71,19 @@ func main() {
 
 	srv := server.New(cfg, dynamicVerifier, k8sClient, metaClient, sessionStore)
 
-	go watchConfig(ctx, logger, path, func(updated *config.Config) {
-		newVerifier, err := auth.NewVerifier(ctx, updated.Auth)
-		if err != nil {
-			logger.Error("config reload: auth verifier update failed", "err", err)
-		} else {
-			dynamicVerifier.Update(newVerifier)
-		}
-		srv.UpdateConfig(updated)
-	})
+	if path != "" {
+		reload := newConfigReloader(logger, path, func(updated *config.Config) {
+			newVerifier, err := auth.NewVerifier(ctx, updated.Auth, updated.TLS)
+			if err != nil {
+				logger.Error("config reload: auth verifier update failed", "err", err)
+			} else {
+				dynamicVerifier.Update(newVerifier)
+			}
+			srv.UpdateConfig(updated)
+		})
+		go watchConfig(ctx, logger, path, reload)
+		go watchReloadSignal(ctx, reload)
+	}
 
 	go func() {
 		logger.Info("server listening", "address", cfg.Server.Address)
@@ -80,38 +97,49 @@ func main() {
 	<-sigCh
 	logger.Info("shutdown signal received")
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	shutdownTimeout := time.Duration(srv.Config().Server.ShutdownTimeoutSeconds) * time.Second
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer shutdownCancel()
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		logger.Error("shutdown error", "err", err)
 	}
-}
-
-func watchConfig(ctx context.Context, logger *log.Logger, path string, onReload func(cfg *config.Config)) {
-	if path == "" {
-		return
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("tracing shutdown error", "err", err)
 	}
+}
 
-	watcher, err := fsnotify.NewWatcher()
+// logEffectiveConfig logs cfg after defaults are applied, with secrets
+// redacted, so a deployment can confirm which values it actually runs with.
+func logEffectiveConfig(logger *log.Logger, cfg *config.Config) {
+	out, err := yaml.Marshal(cfg.Redacted())
 	if err != nil {
-		logger.Error("config watcher error", "err", err)
+		logger.Warn("could not render effective config", "err", err)
 		return
 	}
-	defer watcher.Close()
+	logger.Info("effective config", "config", string(out))
+}
 
-	dir := filepath.Dir(path)
-	if err := watcher.Add(dir); err != nil {
-		logger.Error("config watcher error", "err", err)
-		return
+// logConfigValidation logs what config.Validate finds in a config that
+// loaded, so problems such as a shared log and cache Redis show up at startup
+// and on reload rather than only through /api/v1/config/validate.
+func logConfigValidation(logger *log.Logger, cfg *config.Config) {
+	result := config.Validate(cfg)
+	for _, msg := range result.Errors {
+		logger.Error("config validation", "err", msg)
 	}
-	if err := watcher.Add(path); err != nil {
-		logger.Error("config watcher error", "err", err)
+	for _, msg := range result.Warnings {
+		logger.Warn("config validation", "warning", msg)
 	}
+}
 
+// newConfigReloader returns a debounced trigger that reloads path and hands
+// the result to onReload. The file watcher and SIGHUP share it so both paths
+// validate and apply a config identically.
+func newConfigReloader(logger *log.Logger, path string, onReload func(cfg *config.Config)) func(reason string) {
 	var mu sync.Mutex
 	var timer *time.Timer
 
-	scheduleReload := func() {
+	return func(reason string) {
 		mu.Lock()
 		defer mu.Unlock()
 		if timer != nil {
@@ -120,13 +148,46 @@ func watchConfig(ctx context.Context, logger *log.Logger, path string, onReload
 		timer = time.AfterFunc(500*time.Millisecond, func() {
 			updated, err := config.LoadFromPath(path)
 			if err != nil {
-				logger.Error("config reload error", "err", err)
+				logger.Error("config reload error", "err", err, "trigger", reason)
 				return
 			}
-			logger.Info("config reloaded", "path", path)
+			logger.Info("config reloaded", "path", path, "trigger", reason)
+			logEffectiveConfig(logger, updated)
+			logConfigValidation(logger, updated)
 			onReload(updated)
 		})
 	}
+}
+
+// configRewatchInterval is how often watchConfig retries adding a watch on
+// the config file after it disappeared mid-swap.
+const configRewatchInterval = time.Second
+
+// watchConfig watches the config file and its directory. ConfigMap volumes
+// update by flipping a ..data symlink, which replaces the inode behind the
+// file, so the file watch is re-added after rename/remove events and every
+// directory event re-resolves the symlink to catch swaps the file watch missed.
+func watchConfig(ctx context.Context, logger *log.Logger, path string, reload func(reason string)) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logger.Error("config watcher error", "err", err)
+		return
+	}
+	defer watcher.Close()
+
+	dir := filepath.Dir(path)
+	if err := watcher.Add(dir); err != nil {
+		logger.Error("config watcher error", "err", err)
+		return
+	}
+	fileWatched := watcher.Add(path) == nil
+	if !fileWatched {
+		logger.Warn("config watcher: config file not watchable yet, retrying", "path", path)
+	}
+	resolved := resolveConfigPath(path)
+
+	rewatch := time.NewTicker(configRewatchInterval)
+	defer rewatch.Stop()
 
 	for {
 		select {
@@ -136,8 +197,25 @@ func watchConfig(ctx context.Context, logger *log.Logger, path string, onReload
 			if !ok {
 				return
 			}
-			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) != 0 {
-				scheduleReload()
+			if filepath.Clean(event.Name) == filepath.Clean(path) && event.Op&(fsnotify.Rename|fsnotify.Remove) != 0 {
+				_ = watcher.Remove(path)
+				fileWatched = watcher.Add(path) == nil
+			}
+			current := resolveConfigPath(path)
+			swapped := current != resolved
+			resolved = current
+			if swapped || event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) != 0 {
+				reload("fsnotify")
+			}
+		case <-rewatch.C:
+			if fileWatched {
+				continue
+			}
+			if err := watcher.Add(path); err == nil {
+				fileWatched = true
+				resolved = resolveConfigPath(path)
+				logger.Info("config watcher: config file is back", "path", path)
+				reload("fsnotify")
 			}
 		case err := <-watcher.Errors:
 			if err != nil {
@@ -146,3 +224,32 @@ func watchConfig(ctx context.Context, logger *log.Logger, path string, onReload
 		}
 	}
 }
+
+// resolveConfigPath follows symlinks to the file currently backing path and
+// returns "" while it does not exist.
+func resolveConfigPath(path string) string {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return ""
+	}
+	if _, err := os.Stat(resolved); err != nil {
+		return ""
+	}
+	return resolved
+}
+
+// watchReloadSignal reloads the config on SIGHUP, covering atomic swaps such
+// as symlink flips that the file watcher can miss.
+func watchReloadSignal(ctx context.Context, reload func(reason string)) {
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	defer signal.Stop(hupCh)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hupCh:
+			reload("sighup")
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/halceonio/kubelens/backend/internal/auth"
+)
+
+type rateLimitInspectResponse struct {
+	Subject string                    `json:"subject"`
+	Buckets []rateLimitBucketResponse `json:"buckets"`
+}
+
+type logStreamsResponse struct {
+	Instance     string          `json:"instance"`
+	RedisEnabled bool            `json:"redis_enabled"`
+	Streams      []logStreamInfo `json:"streams"`
+}
+
+type rateLimitResetResponse struct {
+	Subject string `json:"subject"`
+	Removed int    `json:"removed"`
+}
+
+func (h *KubeHandler) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok || user == nil || !user.Admin {
+		writeError(w, http.StatusForbidden, "admin access required")
+		return false
+	}
+	return true
+}
+
+// requireRecentAuth enforces auth.max_auth_age_seconds for sensitive
+// actions. A stale or missing auth_time gets a 401 carrying the RFC 9470
+// insufficient_user_authentication challenge, which tells the UI to send the
+// user through an interactive login rather than reuse the SSO session.
+func (h *KubeHandler) requireRecentAuth(w http.ResponseWriter, r *http.Request) bool {
+	maxAge := h.cfg.Auth.MaxAuthAgeSeconds
+	if maxAge <= 0 {
+		return true
+	}
+	user, ok := auth.UserFromContext(r.Context())
+	if ok && user != nil && !user.AuthTime.IsZero() && time.Since(user.AuthTime) <= time.Duration(maxAge)*time.Second {
+		return true
+	}
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_user_authentication", error_description="re-authentication required", max_age=%d`, maxAge))
+	h.audit(r, "reauth_required", "", "", map[string]any{"result": "denied"})
+	writeError(w, http.StatusUnauthorized, "re-authentication required")
+	return false
+}
+
+// requireLogs answers 403 when logs.enabled is false, in which case the log
+// hub and app stream pool were never started.
+func (h *KubeHandler) requireLogs(w http.ResponseWriter) bool {
+	if h.logHub == nil {
+		writeError(w, http.StatusForbidden, "log access is disabled")
+		return false
+	}
+	return true
+}
+
+func (h *KubeHandler) handleAdminRateLimits(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodDelete) {
+		return
+	}
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	subject := strings.TrimSpace(r.URL.Query().Get("subject"))
+	if subject == "" {
+		writeError(w, http.StatusBadRequest, "subject is required")
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		removed := h.logLimiter.reset(subject)
+		h.audit(r, "ratelimit_reset", "", subject, map[string]any{"removed": removed})
+		writeJSON(w, r, rateLimitResetResponse{Subject: subject, Removed: removed})
+		return
+	}
+
+	h.auditRead(r, "ratelimit_inspect", "", subject, nil)
+	writeJSON(w, r, rateLimitInspectResponse{Subject: subject, Buckets: h.logLimiter.inspect(subject)})
+}
+
+// handleAdminLogStreams lists this instance's log workers and which instance
+// holds each stream's Redis lock, to trace stalled streams to a replica.
+func (h *KubeHandler) handleAdminLogStreams(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	if !h.requireAdmin(w, r) || !h.requireLogs(w) {
+		return
+	}
+	h.auditRead(r, "logstreams_inspect", "", "", nil)
+	writeJSON(w, r, logStreamsResponse{
+		Instance:     h.logHub.instanceID,
+		RedisEnabled: h.logHub.redisEnabled,
+		Streams:      h.logHub.Streams(),
+	})
+}
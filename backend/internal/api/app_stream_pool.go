@@ -5,23 +5,25 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+
+	"github.com/halceonio/kubelens/backend/internal/config"
 )
 
 const (
-	appStreamSubscriberBuffer = 256
-	appStreamLogBuffer        = 512
-	appStreamHeartbeatPeriod  = 15 * time.Second
-	appStreamStatsPeriod      = 5 * time.Second
+	appStreamHeartbeatPeriod = 15 * time.Second
+	appStreamStatsPeriod     = 5 * time.Second
 )
 
 type appStreamPool struct {
 	mu      sync.Mutex
 	streams map[string]*appStream
+	stopped bool
 	handler *KubeHandler
 }
 
@@ -31,24 +33,79 @@ type appStream struct {
 	name         string
 	container    string
 	tail         int64
+	reorder      *logReorderBuffer
 	handler      *KubeHandler
 	ctx          context.Context
 	cancel       context.CancelFunc
 	logCh        chan logEntry
+	podEvents    chan struct{}
 	activePods   map[string]context.CancelFunc
+	podCursors   map[string]podCursor
 	podStates    map[string]podState
 	lastPodHash  string
+	noPods       bool
+	omittedPods  int
+	queuedPods   map[string]bool
+	maxPods      int
+	subBuffer    int
 	mu           sync.Mutex
 	subscribers  map[string]*appSubscriber
+	orderedSubs  int
 	startOnce    sync.Once
 	stopOnce     sync.Once
+	wg           sync.WaitGroup
 	resyncPeriod time.Duration
 }
 
+// appSubscriber is one client of a shared app stream. Lines are localized to
+// its location as they are sent, and ordered subscribers are fed through the
+// stream's reorder buffer, so clients that differ only in zone or ordering
+// share the stream.
 type appSubscriber struct {
-	id      string
-	ch      chan sseEvent
-	dropped atomic.Int64
+	id       string
+	ch       chan sseEvent
+	done     chan struct{}
+	location *time.Location
+	ordered  bool
+	dropped  atomic.Int64
+}
+
+// podCursor is the last line an app stream received from one pod. When the
+// pod's log stream restarts, for example after a reload or an idle stop, the
+// app stream resumes after it instead of re-reading the tail, so the
+// aggregated view has neither a gap nor repeated lines.
+type podCursor struct {
+	id        string
+	timestamp string
+	message   string
+}
+
+func newPodCursor(entry logEntry) podCursor {
+	return podCursor{id: entry.ID, timestamp: entry.Timestamp, message: entry.Message}
+}
+
+func (c podCursor) resume() logResume {
+	resume := logResume{sinceID: c.id}
+	if t, err := time.Parse(time.RFC3339Nano, c.timestamp); err == nil {
+		resume.sinceTime = &t
+	}
+	return resume
+}
+
+// skipSeen drops the replayed lines up to and including the cursor. A
+// timestamp resume includes lines at the cursor's own timestamp, and lines
+// from Kubernetes fallbacks carry no IDs, so those match on timestamp and
+// message instead.
+func (c podCursor) skipSeen(entries []logEntry) []logEntry {
+	for i, entry := range entries {
+		if c.id != "" && entry.ID == c.id {
+			return entries[i+1:]
+		}
+		if entry.Timestamp == c.timestamp && entry.Message == c.message {
+			return entries[i+1:]
+		}
+	}
+	return entries
 }
 
 type podState struct {
@@ -80,13 +137,20 @@ func newAppStreamPool(handler *KubeHandler) *appStreamPool {
 	}
 }
 
-func (p *appStreamPool) subscribe(ctx context.Context, namespace, name string, opts *corev1.PodLogOptions) (*appSubscriber, func(), error) {
+func (p *appStreamPool) subscribe(ctx context.Context, namespace, name string, opts *corev1.PodLogOptions, loc *time.Location, ordered bool) (*appSubscriber, func(), error) {
 	if opts == nil {
 		return nil, nil, errors.New("log options missing")
 	}
+	if loc == nil {
+		loc = time.UTC
+	}
 	key := fmt.Sprintf("%s/%s?container=%s&tail=%d", namespace, name, opts.Container, valueOrDefault(opts.TailLines, 0))
 
 	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return nil, nil, errLogHubStopped
+	}
 	stream, ok := p.streams[key]
 	if !ok {
 		stream = newAppStream(p.handler, key, namespace, name, opts)
@@ -94,7 +158,7 @@ func (p *appStreamPool) subscribe(ctx context.Context, namespace, name string, o
 	}
 	p.mu.Unlock()
 
-	sub, unsubscribe := stream.subscribe(ctx)
+	sub, unsubscribe := stream.subscribe(ctx, loc, ordered)
 	return sub, func() {
 		unsubscribe()
 		if stream.isIdle() {
@@ -108,6 +172,27 @@ func (p *appStreamPool) subscribe(ctx context.Context, namespace, name string, o
 	}, nil
 }
 
+// stop ends every app stream and waits for its run loop and pod consumers to
+// exit, releasing informer watches and pod subscriptions. It runs before the
+// log hub stops, whose shutdown would otherwise wait on them until timeout.
+func (p *appStreamPool) stop() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.stopped = true
+	streams := p.streams
+	p.streams = map[string]*appStream{}
+	p.mu.Unlock()
+
+	for _, stream := range streams {
+		stream.stop()
+	}
+	for _, stream := range streams {
+		stream.wg.Wait()
+	}
+}
+
 func newAppStream(handler *KubeHandler, key, namespace, name string, opts *corev1.PodLogOptions) *appStream {
 	ctx, cancel := context.WithCancel(context.Background())
 	resync := time.Duration(handler.cfg.Logs.AppStreamResync) * time.Second
@@ -123,26 +208,51 @@ func newAppStream(handler *KubeHandler, key, namespace, name string, opts *corev
 		handler:      handler,
 		ctx:          ctx,
 		cancel:       cancel,
-		logCh:        make(chan logEntry, appStreamLogBuffer),
+		logCh:        make(chan logEntry, appStreamBuffer(handler.cfg.Logs.AppLogBuffer, config.DefaultAppLogBuffer)),
+		podEvents:    make(chan struct{}, 1),
 		activePods:   make(map[string]context.CancelFunc),
+		podCursors:   make(map[string]podCursor),
 		podStates:    make(map[string]podState),
 		subscribers:  make(map[string]*appSubscriber),
 		resyncPeriod: resync,
+		maxPods:      handler.cfg.Logs.MaxPodsPerAppStream,
+		subBuffer:    appStreamBuffer(handler.cfg.Logs.AppSubscriberBuffer, config.DefaultAppSubscriberBuffer),
+		reorder:      newLogReorderBuffer(time.Duration(handler.cfg.Logs.ReorderWindowMillis)*time.Millisecond, handler.cfg.Logs.ReorderMaxLines),
 	}
 	return stream
 }
 
-func (s *appStream) subscribe(ctx context.Context) (*appSubscriber, func()) {
+// appStreamBuffer applies logs.app_*_buffer, falling back to the default when
+// unset and never going below config.MinAppStreamBuffer, which validation
+// reports as an error.
+func appStreamBuffer(size, fallback int) int {
+	if size <= 0 {
+		return fallback
+	}
+	return max(size, config.MinAppStreamBuffer)
+}
+
+func (s *appStream) subscribe(ctx context.Context, loc *time.Location, ordered bool) (*appSubscriber, func()) {
 	sub := &appSubscriber{
-		id: fmt.Sprintf("%d", time.Now().UnixNano()),
-		ch: make(chan sseEvent, appStreamSubscriberBuffer),
+		id:       fmt.Sprintf("%d", time.Now().UnixNano()),
+		ch:       make(chan sseEvent, s.subBuffer),
+		done:     make(chan struct{}),
+		location: loc,
+		ordered:  ordered,
 	}
 
 	s.mu.Lock()
 	s.subscribers[sub.id] = sub
+	if ordered {
+		s.orderedSubs++
+	}
+	if s.noPods {
+		sub.ch <- newNoPodsMarker()
+	}
 	s.mu.Unlock()
 
 	s.startOnce.Do(func() {
+		s.wg.Add(1)
 		go s.run()
 	})
 
@@ -150,14 +260,22 @@ func (s *appStream) subscribe(ctx context.Context) (*appSubscriber, func()) {
 		s.mu.Lock()
 		if existing, ok := s.subscribers[sub.id]; ok {
 			delete(s.subscribers, sub.id)
+			if existing.ordered {
+				s.orderedSubs--
+			}
 			close(existing.ch)
+			close(existing.done)
 		}
 		s.mu.Unlock()
 	}
 
 	go func() {
-		<-ctx.Done()
-		unsubscribe()
+		defer s.handler.logHub.trackGoroutine()()
+		select {
+		case <-ctx.Done():
+			unsubscribe()
+		case <-sub.done:
+		}
 	}()
 
 	return sub, unsubscribe
@@ -176,6 +294,8 @@ func (s *appStream) stop() {
 }
 
 func (s *appStream) run() {
+	defer s.wg.Done()
+	defer s.handler.logHub.trackGoroutine()()
 	resyncTicker := time.NewTicker(s.resyncPeriod)
 	heartbeatTicker := time.NewTicker(appStreamHeartbeatPeriod)
 	statsTicker := time.NewTicker(appStreamStatsPeriod)
@@ -183,6 +303,15 @@ func (s *appStream) run() {
 	defer heartbeatTicker.Stop()
 	defer statsTicker.Stop()
 
+	reorderTicker := time.NewTicker(s.reorder.window / 2)
+	defer reorderTicker.Stop()
+
+	if s.handler.informers != nil {
+		if unwatch := s.handler.informers.watchPods(s.namespace, s.notifyPodChange); unwatch != nil {
+			defer unwatch()
+		}
+	}
+
 	if err := s.reconcilePods(true); err != nil {
 		s.broadcastMarker("error", "", fmt.Sprintf("failed to resolve pods: %v", err))
 	}
@@ -193,26 +322,47 @@ func (s *appStream) run() {
 			s.shutdown()
 			return
 		case entry := <-s.logCh:
-			s.broadcastEvent(newLogEvent(entry))
+			s.broadcastEntries([]logEntry{entry}, false)
+			if s.hasOrderedSubscribers() {
+				s.broadcastEntries(s.reorder.add(entry, time.Now()), true)
+			}
+		case <-reorderTicker.C:
+			s.broadcastEntries(s.reorder.release(time.Now()), true)
 		case <-resyncTicker.C:
 			if err := s.reconcilePods(false); err != nil {
 				s.broadcastMarker("error", "", fmt.Sprintf("pod resync failed: %v", err))
 			}
+		case <-s.podEvents:
+			if err := s.reconcilePods(false); err != nil {
+				s.broadcastMarker("error", "", fmt.Sprintf("pod resync failed: %v", err))
+			}
 		case <-heartbeatTicker.C:
 			s.broadcastHeartbeat()
 		case <-statsTicker.C:
+			s.checkQueuedPods()
 			s.broadcastStats()
 		}
 	}
 }
 
+func (s *appStream) notifyPodChange() {
+	select {
+	case s.podEvents <- struct{}{}:
+	default:
+	}
+}
+
+// shutdown flushes lines still waiting in the reorder buffer to ordered
+// subscribers, then closes every subscriber.
 func (s *appStream) shutdown() {
+	s.broadcastEntries(s.reorder.flush(), true)
 	s.mu.Lock()
 	for _, cancel := range s.activePods {
 		cancel()
 	}
 	for _, sub := range s.subscribers {
 		close(sub.ch)
+		close(sub.done)
 	}
 	s.activePods = map[string]context.CancelFunc{}
 	s.subscribers = map[string]*appSubscriber{}
@@ -249,24 +399,47 @@ func (s *appStream) reconcilePods(initial bool) error {
 		desired[pod.Name] = pod
 	}
 
-	if initial || changed || s.activePodCount() != len(desired) {
+	expected := len(desired)
+	if s.maxPods > 0 && expected > s.maxPods {
+		expected = s.maxPods
+	}
+	if initial || changed || s.activePodCount() != expected {
 		s.syncPodStreams(desired)
 	}
 	s.emitPodMarkers(desired, initial)
+	s.setNoPods(len(desired) == 0)
 
 	return nil
 }
 
 func (s *appStream) syncPodStreams(desired map[string]corev1.Pod) {
+	desired, omitted := newestPods(desired, s.maxPods)
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if omitted != s.omittedPods {
+		s.omittedPods = omitted
+		if omitted > 0 {
+			msg := fmt.Sprintf("streaming %d most recent pods; %d pods omitted (logs.max_pods_per_app_stream)", len(desired), omitted)
+			s.broadcastMarkerLocked("pods-omitted", "", msg)
+		}
+	}
+
 	for podName, cancel := range s.activePods {
 		if _, ok := desired[podName]; !ok {
 			cancel()
 			delete(s.activePods, podName)
 		}
 	}
+	// Cursors outlive their pod stream so a restart resumes where it left
+	// off; they go once the pod leaves the selected set, whether or not its
+	// stream is still running.
+	for podName := range s.podCursors {
+		if _, ok := desired[podName]; !ok {
+			delete(s.podCursors, podName)
+		}
+	}
 
 	for podName := range desired {
 		if _, ok := s.activePods[podName]; ok {
@@ -274,25 +447,95 @@ func (s *appStream) syncPodStreams(desired map[string]corev1.Pod) {
 		}
 		streamCtx, streamCancel := context.WithCancel(s.ctx)
 		s.activePods[podName] = streamCancel
+		s.wg.Add(1)
 		go s.consumePodStream(streamCtx, podName)
 	}
 }
 
+func newestPods(pods map[string]corev1.Pod, max int) (map[string]corev1.Pod, int) {
+	if max <= 0 || len(pods) <= max {
+		return pods, 0
+	}
+	items := make([]corev1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		items = append(items, pod)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		ti, tj := items[i].CreationTimestamp.Time, items[j].CreationTimestamp.Time
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return items[i].Name < items[j].Name
+	})
+	selected := make(map[string]corev1.Pod, max)
+	for _, pod := range items[:max] {
+		selected[pod.Name] = pod
+	}
+	return selected, len(pods) - max
+}
+
 func (s *appStream) consumePodStream(ctx context.Context, podName string) {
+	defer s.wg.Done()
+	defer s.handler.logHub.trackGoroutine()()
+	// Registered before markPodInactive so it runs after it: a pod stream
+	// that ended on its own is restarted by the next reconcile, which is
+	// requested right away instead of waiting for the resync period. A hub
+	// that is stopping closed it on purpose and would refuse the resubscribe.
+	sourceEnded := false
+	defer func() {
+		if sourceEnded && ctx.Err() == nil && !s.handler.logHub.isStopped() {
+			s.notifyPodChange()
+		}
+	}()
 	defer s.markPodInactive(podName)
-	sub, replay, unsubscribe, err := s.handler.logHub.SubscribePod(ctx, s.namespace, podName, s.container, s.tail, logResume{})
+
+	s.mu.Lock()
+	cursor, resuming := s.podCursors[podName]
+	s.mu.Unlock()
+	tail, resume := s.tail, logResume{}
+	if resuming {
+		// With a cursor, an empty resume means nothing was missed; a tail
+		// would repeat lines the subscribers already have.
+		tail, resume = 0, cursor.resume()
+	}
+
+	// The cursor is saved once on exit, before the pod is marked inactive and
+	// can be restarted, rather than under s.mu for every line.
+	var (
+		last podCursor
+		seen bool
+	)
+	defer func() {
+		if !seen {
+			return
+		}
+		s.mu.Lock()
+		if _, active := s.activePods[podName]; active {
+			s.podCursors[podName] = last
+		}
+		s.mu.Unlock()
+	}()
+
+	sub, replay, unsubscribe, err := s.handler.logHub.SubscribePod(ctx, s.namespace, podName, s.container, tail, resume)
 	if err != nil {
+		if errors.Is(err, errLogStreamLimit) {
+			s.broadcastMarker("error", podName, "log stream limit reached; pod logs unavailable until streams free up")
+		}
 		return
 	}
 	defer unsubscribe()
 
 	emit := func(entry logEntry) {
+		last, seen = newPodCursor(entry), true
 		select {
 		case s.logCh <- entry:
 		default:
 		}
 	}
 
+	if resuming {
+		replay = cursor.skipSeen(replay)
+	}
 	for _, entry := range replay {
 		emit(entry)
 	}
@@ -303,6 +546,7 @@ func (s *appStream) consumePodStream(ctx context.Context, podName string) {
 			return
 		case entry, ok := <-sub.ch:
 			if !ok {
+				sourceEnded = true
 				return
 			}
 			emit(entry)
@@ -310,6 +554,26 @@ func (s *appStream) consumePodStream(ctx context.Context, podName string) {
 	}
 }
 
+func (s *appStream) setNoPods(noPods bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if noPods == s.noPods {
+		return
+	}
+	s.noPods = noPods
+	if noPods {
+		s.broadcastEventLocked(newNoPodsMarker())
+	}
+}
+
+func newNoPodsMarker() sseEvent {
+	return newJSONEvent("marker", streamMarker{
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Kind:      "no-pods",
+		Message:   "app has no pods; waiting for pods to appear",
+	})
+}
+
 func (s *appStream) activePodCount() int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -372,6 +636,9 @@ func summarizePodStatus(pod corev1.Pod) (int32, bool) {
 }
 
 func isPodReady(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil {
+		return false
+	}
 	for _, cond := range pod.Status.Conditions {
 		if cond.Type == corev1.PodReady {
 			return cond.Status == corev1.ConditionTrue
@@ -382,17 +649,84 @@ func isPodReady(pod *corev1.Pod) bool {
 
 func (s *appStream) broadcastEvent(event sseEvent) {
 	s.mu.Lock()
+	s.broadcastEventLocked(event)
+	s.mu.Unlock()
+}
+
+func (s *appStream) broadcastEventLocked(event sseEvent) {
 	for _, sub := range s.subscribers {
 		select {
 		case sub.ch <- event:
 		default:
-			sub.dropped.Add(1)
+			s.dropEvent(sub)
 		}
 	}
+}
+
+// dropEvent counts an event a slow subscriber missed, both for its own stats
+// events and for the process-wide metric.
+func (s *appStream) dropEvent(sub *appSubscriber) {
+	sub.dropped.Add(1)
+	s.handler.logHub.sse.appEventsDropped.Add(1)
+}
+
+func (s *appStream) checkQueuedPods() {
+	s.mu.Lock()
+	pods := make([]string, 0, len(s.activePods))
+	for podName := range s.activePods {
+		pods = append(pods, podName)
+	}
 	s.mu.Unlock()
+
+	queued := make(map[string]bool)
+	for _, podName := range pods {
+		if status, ok := s.handler.logHub.Status(s.namespace, podName, s.container); ok && status.Queued {
+			queued[podName] = true
+			if !s.queuedPods[podName] {
+				s.broadcastMarker("queued", podName, "waiting for a free log connection slot")
+			}
+		}
+	}
+	s.queuedPods = queued
+}
+
+func (s *appStream) hasOrderedSubscribers() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.orderedSubs > 0
+}
+
+// broadcastEntries sends entries to the ordered or the unordered subscribers,
+// localizing each line once per distinct location among them.
+func (s *appStream) broadcastEntries(entries []logEntry, ordered bool) {
+	if len(entries) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range entries {
+		events := map[string]sseEvent{}
+		for _, sub := range s.subscribers {
+			if sub.ordered != ordered {
+				continue
+			}
+			zone := sub.location.String()
+			event, ok := events[zone]
+			if !ok {
+				event = newLogEvent(localizeLogEntry(entry, sub.location))
+				events[zone] = event
+			}
+			select {
+			case sub.ch <- event:
+			default:
+				s.dropEvent(sub)
+			}
+		}
+	}
 }
 
 func (s *appStream) broadcastStats() {
+	reordering := s.reorder.len()
 	s.mu.Lock()
 	sources := len(s.activePods)
 	for _, sub := range s.subscribers {
@@ -401,11 +735,14 @@ func (s *appStream) broadcastStats() {
 			Buffered: len(sub.ch),
 			Sources:  sources,
 		}
+		if sub.ordered {
+			stats.Buffered += reordering
+		}
 		event := newJSONEvent("stats", stats)
 		select {
 		case sub.ch <- event:
 		default:
-			sub.dropped.Add(1)
+			s.dropEvent(sub)
 		}
 	}
 	s.mu.Unlock()
@@ -433,7 +770,7 @@ func (s *appStream) broadcastMarkerLocked(kind, podName, message string) {
 		select {
 		case sub.ch <- event:
 		default:
-			sub.dropped.Add(1)
+			s.dropEvent(sub)
 		}
 	}
 }
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/halceonio/kubelens/backend/internal/config"
+)
+
+// TestAppStreamPoolSharesStreams checks that the display zone and ordering
+// are applied per subscriber rather than opening a stream per variant.
+func TestAppStreamPoolSharesStreams(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no zoneinfo: %v", err)
+	}
+	h, _ := newTestKubeHandler(t, nil)
+	opts := &corev1.PodLogOptions{Container: "app"}
+
+	variants := []struct {
+		loc     *time.Location
+		ordered bool
+	}{
+		{time.UTC, false},
+		{berlin, false},
+		{nil, false},
+		{time.UTC, true},
+		{berlin, true},
+	}
+	var unsubscribes []func()
+	for _, v := range variants {
+		_, unsubscribe, err := h.appStreams.subscribe(t.Context(), testNamespace, "web", opts, v.loc, v.ordered)
+		if err != nil {
+			t.Fatalf("subscribe %s ordered=%v: %v", v.loc, v.ordered, err)
+		}
+		unsubscribes = append(unsubscribes, unsubscribe)
+	}
+
+	h.appStreams.mu.Lock()
+	streams := len(h.appStreams.streams)
+	h.appStreams.mu.Unlock()
+	if streams != 1 {
+		t.Fatalf("streams = %d, want 1", streams)
+	}
+	for _, unsubscribe := range unsubscribes {
+		unsubscribe()
+	}
+}
+
+// TestSyncPodStreamsDropsCursors checks that a pod's resume cursor goes once
+// the pod is no longer selected, including when its stream already ended.
+func TestSyncPodStreamsDropsCursors(t *testing.T) {
+	h, _ := newTestKubeHandler(t, func(cfg *config.Config) { cfg.Logs.MaxPodsPerAppStream = 1 })
+	older := testPod("web-1", nil)
+	newer := testPod("web-2", nil, func(p *corev1.Pod) { p.CreationTimestamp = metav1.NewTime(time.Now()) })
+
+	tests := []struct {
+		name    string
+		desired map[string]corev1.Pod
+		want    []string
+	}{
+		{"pod still selected", map[string]corev1.Pod{"web-1": *older}, []string{"web-1"}},
+		{"pod removed", map[string]corev1.Pod{}, nil},
+		{"pod omitted by max pods", map[string]corev1.Pod{"web-1": *older, "web-2": *newer}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := newAppStream(h, "test", testNamespace, "web", &corev1.PodLogOptions{Container: "app"})
+			t.Cleanup(func() {
+				stream.stop()
+				stream.wg.Wait()
+			})
+			// web-1's stream has ended, so only its cursor is left.
+			stream.podCursors["web-1"] = podCursor{id: "1-0"}
+
+			stream.syncPodStreams(tt.desired)
+
+			stream.mu.Lock()
+			got := slices.Sorted(maps.Keys(stream.podCursors))
+			stream.mu.Unlock()
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("cursors = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,57 +1,264 @@
 package api
 
 import (
+	"encoding/json"
+	"io"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/charmbracelet/log"
 
 	"github.com/halceonio/kubelens/backend/internal/auth"
+	"github.com/halceonio/kubelens/backend/internal/config"
 )
 
+const (
+	auditFormatText = "text"
+	auditFormatJSON = "json"
+)
+
+type auditRecord struct {
+	Timestamp string         `json:"timestamp"`
+	Action    string         `json:"action"`
+	Subject   string         `json:"subject,omitempty"`
+	User      string         `json:"user,omitempty"`
+	Groups    []string       `json:"groups,omitempty"`
+	Secrets   bool           `json:"secrets"`
+	Namespace string         `json:"namespace,omitempty"`
+	Name      string         `json:"name,omitempty"`
+	Path      string         `json:"path"`
+	Method    string         `json:"method"`
+	Remote    string         `json:"remote"`
+	RequestID string         `json:"request_id,omitempty"`
+	Result    string         `json:"result"`
+	Extra     map[string]any `json:"extra,omitempty"`
+}
+
+type auditWriter struct {
+	format string
+	mu     sync.Mutex
+	out    io.Writer
+	closer io.Closer
+	text   *log.Logger
+}
+
+func newAuditWriter(cfg config.ServerConfig) *auditWriter {
+	writer := &auditWriter{
+		format: strings.ToLower(strings.TrimSpace(cfg.AuditFormat)),
+		out:    os.Stdout,
+	}
+	if writer.format == "" {
+		writer.format = auditFormatText
+	}
+	if path := strings.TrimSpace(cfg.AuditFile); path != "" {
+		file, err := os.OpenFile(filepath.Clean(path), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+		if err != nil {
+			log.Warn("audit: falling back to stdout", "path", path, "err", err)
+		} else {
+			writer.out = file
+			writer.closer = file
+		}
+	}
+	if writer.format == auditFormatText {
+		writer.text = log.NewWithOptions(writer.out, log.Options{
+			ReportTimestamp: true,
+			TimeFormat:      time.RFC3339,
+			Prefix:          "kubelens-audit",
+			Level:           log.InfoLevel,
+		})
+		writer.text.SetTimeFunction(log.NowUTC)
+	}
+	return writer
+}
+
+func (a *auditWriter) writeBatch(records []auditRecord) error {
+	for _, record := range records {
+		a.writeRecord(record)
+	}
+	return nil
+}
+
+func (a *auditWriter) writeRecord(record auditRecord) {
+	if a.format == auditFormatJSON {
+		payload, err := json.Marshal(record)
+		if err != nil {
+			return
+		}
+		a.mu.Lock()
+		_, _ = a.out.Write(append(payload, '\n'))
+		a.mu.Unlock()
+		return
+	}
+
+	fields := []any{
+		"action", record.Action,
+		"namespace", record.Namespace,
+		"name", record.Name,
+		"path", record.Path,
+		"method", record.Method,
+		"remote", record.Remote,
+	}
+	if record.Subject != "" {
+		fields = append(fields, "sub", record.Subject)
+		if record.User != "" && record.User != record.Subject {
+			fields = append(fields, "user", record.User)
+		}
+		if len(record.Groups) > 0 {
+			fields = append(fields, "groups", strings.Join(record.Groups, ","))
+		}
+		fields = append(fields, "secrets", record.Secrets)
+	}
+	if record.RequestID != "" {
+		fields = append(fields, "request_id", record.RequestID)
+	}
+	fields = append(fields, "result", record.Result)
+	for k, v := range record.Extra {
+		fields = append(fields, k, v)
+	}
+	a.text.Info("audit", fields...)
+}
+
+func (a *auditWriter) close() {
+	if a == nil || a.closer == nil {
+		return
+	}
+	_ = a.closer.Close()
+}
+
 func (h *KubeHandler) audit(r *http.Request, action, namespace, name string, extra map[string]any) {
 	if h == nil || !h.cfg.Server.AuditLogs {
 		return
 	}
 
-	fields := []any{
-		"action", action,
-		"namespace", namespace,
-		"name", name,
-		"path", r.URL.Path,
-		"method", r.Method,
-		"remote", remoteIP(r),
+	record := auditRecord{
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Action:    action,
+		Namespace: namespace,
+		Name:      name,
+		Path:      r.URL.Path,
+		Method:    r.Method,
+		Remote:    h.remoteIP(r),
+		RequestID: r.Header.Get("X-Request-Id"),
+		Result:    "allowed",
 	}
 
 	if user, ok := auth.UserFromContext(r.Context()); ok && user != nil {
-		fields = append(fields, "sub", user.Subject)
-		if len(user.Groups) > 0 {
-			fields = append(fields, "groups", strings.Join(user.Groups, ","))
-		}
-		fields = append(fields, "secrets", user.AllowedSecrets)
+		record.Subject = user.Subject
+		record.User = user.Name
+		record.Groups = user.Groups
+		record.Secrets = user.AllowedSecrets
 	}
 
-	if extra != nil {
+	if len(extra) > 0 {
+		record.Extra = make(map[string]any, len(extra))
 		for k, v := range extra {
-			fields = append(fields, k, v)
+			if k == "result" {
+				if result, ok := v.(string); ok {
+					record.Result = result
+					continue
+				}
+			}
+			record.Extra[k] = v
 		}
 	}
 
-	log.Info("audit", fields...)
+	h.auditOut.enqueue(record)
 }
 
-func remoteIP(r *http.Request) string {
+func (h *KubeHandler) auditRead(r *http.Request, action, namespace, name string, extra map[string]any) {
+	if h == nil || !h.cfg.Server.AuditReads {
+		return
+	}
+	h.audit(r, action, namespace, name, extra)
+}
+
+func (h *KubeHandler) auditSecretReveal(r *http.Request, namespace, name string) {
+	if !wantsRevealSecrets(r) {
+		return
+	}
+	result := "denied"
+	user, _ := auth.UserFromContext(r.Context())
+	if h.canRevealSecrets(user) {
+		result = "allowed"
+	} else if user != nil && user.AllowedSecrets {
+		result = "missing_scope"
+	}
+	h.audit(r, "secret_reveal", namespace, name, map[string]any{"result": result})
+}
+
+func parseTrustedProxies(items []string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			if ip := net.ParseIP(item); ip != nil {
+				bits := 32
+				if ip.To4() == nil {
+					bits = 128
+				}
+				nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			}
+			continue
+		}
+		if _, cidr, err := net.ParseCIDR(item); err == nil {
+			nets = append(nets, cidr)
+		}
+	}
+	return nets
+}
+
+func isTrustedProxy(trusted []*net.IPNet, raw string) bool {
+	ip := net.ParseIP(strings.TrimSpace(raw))
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range trusted {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *KubeHandler) remoteIP(r *http.Request) string {
+	return clientIP(r, h.trustedProxies)
+}
+
+// clientIP returns the caller's address, taking X-Forwarded-For and X-Real-IP
+// into account only when the direct peer is one of the trusted proxies.
+func clientIP(r *http.Request, trusted []*net.IPNet) string {
 	if r == nil {
 		return ""
 	}
+	peer := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+	if !isTrustedProxy(trusted, peer) {
+		return peer
+	}
 	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
 		parts := strings.Split(forwarded, ",")
-		if len(parts) > 0 {
-			return strings.TrimSpace(parts[0])
+		for i := len(parts) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(parts[i])
+			if hop == "" {
+				continue
+			}
+			if i == 0 || !isTrustedProxy(trusted, hop) {
+				return hop
+			}
 		}
 	}
-	if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
 		return realIP
 	}
-	return r.RemoteAddr
+	return peer
 }
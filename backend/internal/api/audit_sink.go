@@ -0,0 +1,262 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/halceonio/kubelens/backend/internal/config"
+	"github.com/halceonio/kubelens/backend/internal/storage"
+)
+
+const (
+	auditSinkStdout = "stdout"
+	auditSinkRedis  = "redis"
+	auditSinkHTTP   = "http"
+
+	defaultAuditBufferSize   = 1024
+	defaultAuditRedisStream  = "kubelens:audit"
+	defaultAuditRedisMaxLen  = 100000
+	auditBatchSize           = 100
+	auditFlushInterval       = time.Second
+	auditSinkWriteTimeout    = 5 * time.Second
+	auditRedisConnectTimeout = 5 * time.Second
+	auditHTTPClientTimeout   = 10 * time.Second
+	auditDroppedLogInterval  = time.Minute
+)
+
+type auditSink interface {
+	writeBatch(records []auditRecord) error
+	close()
+}
+
+type auditPipeline struct {
+	sink     auditSink
+	ch       chan auditRecord
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	stats    *ResourceStats
+	sinkName string
+	lastDrop time.Time
+	dropMu   sync.Mutex
+}
+
+type redisAuditSink struct {
+	client *redis.Client
+	stream string
+}
+
+type httpAuditSink struct {
+	url    string
+	client *http.Client
+}
+
+func newAuditPipeline(cfg *config.Config, stats *ResourceStats) *auditPipeline {
+	size := cfg.Server.AuditBufferSize
+	if size <= 0 {
+		size = defaultAuditBufferSize
+	}
+	sinkName := strings.ToLower(strings.TrimSpace(cfg.Server.AuditSink))
+	if sinkName == "" {
+		sinkName = auditSinkStdout
+	}
+	sink := newAuditSink(cfg, sinkName)
+	if sink == nil {
+		sinkName = auditSinkStdout
+		sink = newAuditWriter(cfg.Server)
+	}
+	pipeline := &auditPipeline{
+		sink:     sink,
+		ch:       make(chan auditRecord, size),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		stats:    stats,
+		sinkName: sinkName,
+	}
+	go pipeline.run()
+	return pipeline
+}
+
+func newAuditSink(cfg *config.Config, name string) auditSink {
+	switch name {
+	case auditSinkStdout:
+		return newAuditWriter(cfg.Server)
+	case auditSinkRedis:
+		redisURL := cfg.Server.AuditRedisURL
+		if redisURL == "" {
+			redisURL = cfg.Cache.RedisURL
+		}
+		if redisURL == "" {
+			log.Warn("audit: redis sink disabled (missing redis_url)")
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), auditRedisConnectTimeout)
+		defer cancel()
+		client, err := storage.NewRedisClientFromURL(ctx, redisURL)
+		if err != nil {
+			log.Warn("audit: redis sink disabled", "err", err)
+			return nil
+		}
+		stream := cfg.Server.AuditRedisStream
+		if stream == "" {
+			stream = defaultAuditRedisStream
+		}
+		return &redisAuditSink{client: client, stream: stream}
+	case auditSinkHTTP:
+		if cfg.Server.AuditHTTPURL == "" {
+			log.Warn("audit: http sink disabled (missing audit_http_url)")
+			return nil
+		}
+		return &httpAuditSink{
+			url:    cfg.Server.AuditHTTPURL,
+			client: &http.Client{Timeout: auditHTTPClientTimeout},
+		}
+	default:
+		log.Warn("audit: unknown sink, using stdout", "sink", name)
+		return nil
+	}
+}
+
+func (p *auditPipeline) enqueue(record auditRecord) {
+	if p == nil {
+		return
+	}
+	select {
+	case <-p.stop:
+		return
+	default:
+	}
+	select {
+	case p.ch <- record:
+	default:
+		p.recordDrop(1)
+	}
+}
+
+func (p *auditPipeline) recordDrop(n int) {
+	if p.stats != nil {
+		p.stats.addAuditDropped(int64(n))
+	}
+	p.dropMu.Lock()
+	defer p.dropMu.Unlock()
+	if time.Since(p.lastDrop) < auditDroppedLogInterval {
+		return
+	}
+	p.lastDrop = time.Now()
+	log.Warn("audit: dropping records", "sink", p.sinkName, "count", n)
+}
+
+func (p *auditPipeline) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(auditFlushInterval)
+	defer ticker.Stop()
+	batch := make([]auditRecord, 0, auditBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := p.sink.writeBatch(batch); err != nil {
+			log.Warn("audit: sink write failed", "sink", p.sinkName, "err", err)
+			p.recordDrop(len(batch))
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case record := <-p.ch:
+			batch = append(batch, record)
+			if len(batch) >= auditBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-p.stop:
+			for {
+				select {
+				case record := <-p.ch:
+					batch = append(batch, record)
+					if len(batch) >= auditBatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (p *auditPipeline) close() {
+	if p == nil {
+		return
+	}
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		<-p.done
+		p.sink.close()
+	})
+}
+
+func (s *redisAuditSink) writeBatch(records []auditRecord) error {
+	ctx, cancel := context.WithTimeout(context.Background(), auditSinkWriteTimeout)
+	defer cancel()
+	pipe := s.client.Pipeline()
+	for _, record := range records {
+		payload, err := json.Marshal(record)
+		if err != nil {
+			continue
+		}
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: s.stream,
+			MaxLen: defaultAuditRedisMaxLen,
+			Approx: true,
+			Values: map[string]any{
+				"action": record.Action,
+				"record": payload,
+			},
+		})
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *redisAuditSink) close() {
+	_ = s.client.Close()
+}
+
+func (s *httpAuditSink) writeBatch(records []auditRecord) error {
+	payload, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), auditSinkWriteTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("audit webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *httpAuditSink) close() {
+	s.client.CloseIdleConnections()
+}
@@ -3,37 +3,80 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
+	"sync"
 	"time"
 
+	"github.com/halceonio/kubelens/backend/internal/auth"
 	"github.com/halceonio/kubelens/backend/internal/config"
+	"github.com/halceonio/kubelens/backend/internal/storage"
 )
 
+// tokenRateScope keys the code-exchange buckets in the shared limiter type,
+// which otherwise groups buckets by namespace.
+const tokenRateScope = "auth_token"
+
 type AuthHandler struct {
-	getConfig  func() *config.Config
-	httpClient *http.Client
+	getConfig func() *config.Config
+	sessions  storage.SessionStore
+
+	limiterMu    sync.Mutex
+	limiter      *logLimiter
+	limiterRate  int
+	limiterBurst int
 }
 
+// tokenRequest carries the authorization code from the SPA. CodeVerifier is the
+// PKCE verifier (RFC 7636); it is required when the client is public, that is
+// when auth.client_secret is empty. State is only checked when
+// auth.stateful_login is on.
 type tokenRequest struct {
-	Code        string `json:"code"`
-	RedirectURI string `json:"redirect_uri"`
+	Code         string `json:"code"`
+	RedirectURI  string `json:"redirect_uri"`
+	CodeVerifier string `json:"code_verifier,omitempty"`
+	State        string `json:"state,omitempty"`
+}
+
+var pkceVerifierPattern = regexp.MustCompile(`^[A-Za-z0-9._~-]{43,128}$`)
+
+// NewAuthHandler serves the code exchange. sessions holds login states for
+// auth.stateful_login and may be nil when that is never enabled.
+func NewAuthHandler(getConfig func() *config.Config, sessions storage.SessionStore) *AuthHandler {
+	return &AuthHandler{getConfig: getConfig, sessions: sessions}
 }
 
-func NewAuthHandler(getConfig func() *config.Config) *AuthHandler {
-	return &AuthHandler{
-		getConfig: getConfig,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+// allowTokenRequest applies the per-IP auth.token_rate_* limit so a
+// misbehaving client cannot flood Keycloak through the code exchange.
+func (h *AuthHandler) allowTokenRequest(w http.ResponseWriter, r *http.Request, cfg *config.Config) bool {
+	ip := clientIP(r, parseTrustedProxies(cfg.Server.TrustedProxies))
+	allowed, state := h.tokenLimiter(cfg).Allow(tokenRateScope, ip, "")
+	setRateLimitHeaders(w, state, allowed)
+	if !allowed {
+		writeError(w, http.StatusTooManyRequests, "too many token requests")
 	}
+	return allowed
+}
+
+// tokenLimiter keeps buckets across requests and starts fresh only when a
+// reload changes the limits. A nil limiter allows everything.
+func (h *AuthHandler) tokenLimiter(cfg *config.Config) *logLimiter {
+	h.limiterMu.Lock()
+	defer h.limiterMu.Unlock()
+	if h.limiterRate != cfg.Auth.TokenRatePerMinute || h.limiterBurst != cfg.Auth.TokenRateBurst {
+		h.limiter = newLogLimiter(cfg.Auth.TokenRatePerMinute, cfg.Auth.TokenRateBurst, nil, 0, 0)
+		h.limiterRate = cfg.Auth.TokenRatePerMinute
+		h.limiterBurst = cfg.Auth.TokenRateBurst
+	}
+	return h.limiter
 }
 
 func (h *AuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	if !allowMethods(w, r, http.MethodPost) {
 		return
 	}
 
@@ -42,11 +85,9 @@ func (h *AuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "missing auth config")
 		return
 	}
-	if cfg.Auth.ClientSecret == "" {
-		writeError(w, http.StatusBadRequest, "auth.client_secret is required for code exchange")
+	if !h.allowTokenRequest(w, r, cfg) {
 		return
 	}
-
 	r.Body = http.MaxBytesReader(w, r.Body, 16*1024)
 	defer r.Body.Close()
 
@@ -60,14 +101,43 @@ func (h *AuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "code and redirect_uri are required")
 		return
 	}
+	if req.CodeVerifier != "" && !pkceVerifierPattern.MatchString(req.CodeVerifier) {
+		writeError(w, http.StatusBadRequest, "code_verifier must be 43-128 characters of [A-Za-z0-9-._~]")
+		return
+	}
+	if cfg.Auth.ClientSecret == "" && req.CodeVerifier == "" {
+		writeError(w, http.StatusBadRequest, "code_verifier is required when auth.client_secret is not set")
+		return
+	}
+	if cfg.Auth.StatefulLogin && h.sessions != nil {
+		ttl := time.Duration(cfg.Auth.LoginStateTTLSeconds) * time.Second
+		var binding string
+		if cookie, err := r.Cookie(loginStateCookie); err == nil {
+			binding = cookie.Value
+		}
+		clearLoginStateCookie(w, r, cfg.Server.BasePath)
+		if err := consumeLoginState(r.Context(), h.sessions, req.State, binding, ttl); err != nil {
+			if errors.Is(err, errInvalidLoginState) {
+				writeError(w, http.StatusBadRequest, err.Error())
+			} else {
+				writeError(w, http.StatusInternalServerError, "failed to verify login state")
+			}
+			return
+		}
+	}
 
 	tokenURL := fmt.Sprintf("%s/realms/%s/protocol/openid-connect/token", cfg.Auth.KeycloakURL, cfg.Auth.Realm)
 	form := url.Values{}
 	form.Set("grant_type", "authorization_code")
 	form.Set("client_id", cfg.Auth.ClientID)
-	form.Set("client_secret", cfg.Auth.ClientSecret)
+	if cfg.Auth.ClientSecret != "" {
+		form.Set("client_secret", cfg.Auth.ClientSecret)
+	}
 	form.Set("code", req.Code)
 	form.Set("redirect_uri", req.RedirectURI)
+	if req.CodeVerifier != "" {
+		form.Set("code_verifier", req.CodeVerifier)
+	}
 
 	httpReq, err := http.NewRequestWithContext(r.Context(), http.MethodPost, tokenURL, bytes.NewBufferString(form.Encode()))
 	if err != nil {
@@ -76,7 +146,13 @@ func (h *AuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := h.httpClient.Do(httpReq)
+	// The client is cheap; connections are pooled by the shared transport.
+	client, err := auth.NewHTTPClient(cfg.TLS, 10*time.Second)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp, err := client.Do(httpReq)
 	if err != nil {
 		writeError(w, http.StatusBadGateway, "token exchange failed")
 		return
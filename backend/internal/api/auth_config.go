@@ -12,12 +12,13 @@ type AuthConfigResponse struct {
 	ClientID             string   `json:"client_id"`
 	AllowedGroups        []string `json:"allowed_groups"`
 	AllowedSecretsGroups []string `json:"allowed_secrets_groups,omitempty"`
+	StatefulLogin        bool     `json:"stateful_login"`
+	RevealRequiredScope  string   `json:"reveal_required_scope,omitempty"`
 }
 
 func NewAuthConfigHandler(getConfig func() *config.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			w.WriteHeader(http.StatusMethodNotAllowed)
+		if !allowMethods(w, r, http.MethodGet) {
 			return
 		}
 
@@ -33,7 +34,9 @@ func NewAuthConfigHandler(getConfig func() *config.Config) http.HandlerFunc {
 			ClientID:             cfg.Auth.ClientID,
 			AllowedGroups:        cfg.Auth.AllowedGroups,
 			AllowedSecretsGroups: cfg.Auth.AllowedSecretsGroups,
+			StatefulLogin:        cfg.Auth.StatefulLogin,
+			RevealRequiredScope:  cfg.Auth.RevealRequiredScope,
 		}
-		writeJSON(w, resp)
+		writeJSON(w, r, resp)
 	}
 }
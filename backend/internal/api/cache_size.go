@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const cacheSizeInterval = 30 * time.Second
+
+type cacheSizeStat struct {
+	name    string
+	entries int
+	items   int
+	bytes   int64
+}
+
+// measureCache sums the sizes of store's entries. Each entry is marshaled only
+// once, the first time it is seen, so an unchanged cache costs a map walk
+// rather than re-encoding every cached object on each tick. Marshaling holds
+// the read lock because evicting a Secret zeroes its data in place.
+func measureCache[T any](cache *resourceCache, name string, store map[string]cacheEntry[T]) cacheSizeStat {
+	stat := cacheSizeStat{name: name}
+	measured := map[string]cacheEntry[T]{}
+	cache.mu.RLock()
+	for key, entry := range store {
+		stat.entries++
+		stat.items += len(entry.items)
+		if !entry.sized {
+			for i := range entry.items {
+				if data, err := json.Marshal(&entry.items[i]); err == nil {
+					entry.bytes += int64(len(data))
+				}
+			}
+			entry.sized = true
+			measured[key] = entry
+		}
+		stat.bytes += entry.bytes
+	}
+	cache.mu.RUnlock()
+	if len(measured) == 0 {
+		return stat
+	}
+
+	// Record the sizes unless the entry was replaced in the meantime.
+	cache.mu.Lock()
+	for key, entry := range measured {
+		if current, ok := store[key]; ok && current.fetched.Equal(entry.fetched) {
+			current.bytes, current.sized = entry.bytes, true
+			store[key] = current
+		}
+	}
+	cache.mu.Unlock()
+	return stat
+}
+
+func (c *resourceCache) sizeSnapshot() []cacheSizeStat {
+	if c == nil {
+		return nil
+	}
+	return []cacheSizeStat{
+		measureCache(c, "pods", c.pods),
+		measureCache(c, "deployments", c.deployments),
+		measureCache(c, "statefulsets", c.statefuls),
+		measureCache(c, "cnpg", c.cnpg),
+		measureCache(c, "dragonfly", c.dragonfly),
+		measureCache(c, "cnpg_by_name", c.cnpgByName),
+		measureCache(c, "dragonfly_by_name", c.dragonByName),
+		measureCache(c, "pods_metadata", c.metaPods),
+		measureCache(c, "deployments_metadata", c.metaDeps),
+		measureCache(c, "statefulsets_metadata", c.metaSts),
+		measureCache(c, "cnpg_metadata", c.metaCnpg),
+		measureCache(c, "dragonfly_metadata", c.metaDragon),
+		measureCache(c, "custom_metadata", c.metaCustom),
+		measureCache(c, "pod_metrics", c.podMetrics),
+		measureCache(c, "resourcequotas", c.quotas),
+		measureCache(c, "limitranges", c.limitRanges),
+		measureCache(c, "configmaps", c.configMaps),
+		measureCache(c, "secrets", c.secrets),
+	}
+}
+
+func (h *KubeHandler) startCacheSizer() {
+	if h.stats == nil || h.cache == nil {
+		return
+	}
+	h.stats.setCacheSizes(h.cache.sizeSnapshot())
+	ticker := time.NewTicker(cacheSizeInterval)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				h.stats.setCacheSizes(h.cache.sizeSnapshot())
+			case <-h.statsStop:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func podsJSONSize(t *testing.T, pods ...*corev1.Pod) int64 {
+	t.Helper()
+	var size int64
+	for _, pod := range pods {
+		data, err := json.Marshal(pod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size += int64(len(data))
+	}
+	return size
+}
+
+// TestMeasureCacheSizesEntriesOnce checks that the sizer reuses the sizes of
+// entries it has already measured and only marshals replaced ones.
+func TestMeasureCacheSizesEntriesOnce(t *testing.T) {
+	cache := newResourceCache(time.Minute, time.Minute, time.Minute, time.Minute, time.Minute, time.Minute, 0, 0, nil)
+	web1, web2, web3 := testPod("web-1", nil), testPod("web-2", nil), testPod("a-much-longer-pod-name", nil)
+	cache.setPods("default", []corev1.Pod{*web1})
+	cache.setPods("other", []corev1.Pod{*web2})
+
+	tests := []struct {
+		name      string
+		change    func()
+		wantBytes int64
+		remeasure []string
+	}{
+		{"first measurement", func() {}, podsJSONSize(t, web1, web2), []string{"default", "other"}},
+		{"unchanged", func() {}, podsJSONSize(t, web1, web2), nil},
+		{"one entry replaced", func() { cache.setPods("other", []corev1.Pod{*web3}) }, podsJSONSize(t, web1, web3), []string{"other"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.change()
+			var unsized []string
+			for key, entry := range cache.pods {
+				if !entry.sized {
+					unsized = append(unsized, key)
+				}
+			}
+			if len(unsized) != len(tt.remeasure) {
+				t.Errorf("entries to measure = %q, want %q", unsized, tt.remeasure)
+			}
+
+			stat := measureCache(cache, "pods", cache.pods)
+			if stat.entries != 2 || stat.items != 2 || stat.bytes != tt.wantBytes {
+				t.Fatalf("stat = %+v, want 2 entries, 2 items, %d bytes", stat, tt.wantBytes)
+			}
+			for key, entry := range cache.pods {
+				if !entry.sized {
+					t.Errorf("entry %s not sized after measuring", key)
+				}
+			}
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 )
 
 type ConfigResponse struct {
+	BasePath   string             `json:"base_path"`
+	ReadOnly   bool               `json:"read_only"`
 	Kubernetes KubernetesResponse `json:"kubernetes"`
 	Logs       LogsResponse       `json:"logs"`
 }
@@ -14,6 +16,7 @@ type ConfigResponse struct {
 type KubernetesResponse struct {
 	ClusterName       string            `json:"cluster_name"`
 	AllowedNamespaces []string          `json:"allowed_namespaces"`
+	DefaultNamespace  string            `json:"default_namespace,omitempty"`
 	LabelPrefix       string            `json:"label_prefix"`
 	AppGroups         AppGroupsResponse `json:"app_groups"`
 }
@@ -31,9 +34,10 @@ type AppGroupLabelsResponse struct {
 }
 
 type LogsResponse struct {
-	DefaultTailLines int `json:"default_tail_lines"`
-	MaxTailLines     int `json:"max_tail_lines"`
-	MaxLineLength    int `json:"max_line_length"`
+	Enabled          bool `json:"enabled"`
+	DefaultTailLines int  `json:"default_tail_lines"`
+	MaxTailLines     int  `json:"max_tail_lines"`
+	MaxLineLength    int  `json:"max_line_length"`
 }
 
 type ConfigValidationResponse struct {
@@ -44,8 +48,7 @@ type ConfigValidationResponse struct {
 
 func NewConfigHandler(getConfig func() *config.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			w.WriteHeader(http.StatusMethodNotAllowed)
+		if !allowMethods(w, r, http.MethodGet) {
 			return
 		}
 
@@ -56,9 +59,12 @@ func NewConfigHandler(getConfig func() *config.Config) http.HandlerFunc {
 		}
 
 		resp := ConfigResponse{
+			BasePath: cfg.Server.BasePath,
+			ReadOnly: cfg.Server.ReadOnly,
 			Kubernetes: KubernetesResponse{
 				ClusterName:       cfg.Kubernetes.ClusterName,
 				AllowedNamespaces: cfg.Kubernetes.AllowedNamespaces,
+				DefaultNamespace:  cfg.Kubernetes.DefaultNamespace,
 				LabelPrefix:       cfg.Kubernetes.LabelPrefix,
 				AppGroups: AppGroupsResponse{
 					Enabled: cfg.Kubernetes.AppGroups.Enabled,
@@ -71,20 +77,20 @@ func NewConfigHandler(getConfig func() *config.Config) http.HandlerFunc {
 				},
 			},
 			Logs: LogsResponse{
+				Enabled:          cfg.Logs.Enabled == nil || *cfg.Logs.Enabled,
 				DefaultTailLines: cfg.Logs.DefaultTailLines,
 				MaxTailLines:     cfg.Logs.MaxTailLines,
 				MaxLineLength:    cfg.Logs.MaxLineLength,
 			},
 		}
 
-		writeJSON(w, resp)
+		writeJSON(w, r, resp)
 	}
 }
 
 func NewConfigValidateHandler(getConfig func() *config.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			w.WriteHeader(http.StatusMethodNotAllowed)
+		if !allowMethods(w, r, http.MethodGet) {
 			return
 		}
 
@@ -100,6 +106,6 @@ func NewConfigValidateHandler(getConfig func() *config.Config) http.HandlerFunc
 			Errors:   result.Errors,
 			Warnings: result.Warnings,
 		}
-		writeJSON(w, resp)
+		writeJSON(w, r, resp)
 	}
 }
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type filterDebugResponse struct {
+	Namespace string           `json:"namespace"`
+	Pods      []filterDecision `json:"pods"`
+	Apps      []filterDecision `json:"apps"`
+	Included  int              `json:"included"`
+	Excluded  int              `json:"excluded"`
+	Errors    []string         `json:"errors,omitempty"`
+}
+
+type filterDecision struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Included bool   `json:"included"`
+	Rule     string `json:"rule,omitempty"`
+}
+
+// handleDebugFilters dry-runs the current pod and app filters against the
+// cached lists for one namespace and reports which rule hid each resource.
+func (h *KubeHandler) handleDebugFilters(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	namespace := strings.TrimSpace(r.URL.Query().Get("namespace"))
+	if namespace == "" {
+		writeError(w, http.StatusBadRequest, "namespace is required")
+		return
+	}
+	if !h.isAllowedNamespace(namespace) {
+		writeError(w, http.StatusForbidden, "namespace not allowed")
+		return
+	}
+	h.auditRead(r, "filters_inspect", namespace, "", nil)
+
+	resp := h.evaluateFilters(r.Context(), namespace)
+	writeJSON(w, r, resp)
+}
+
+func (h *KubeHandler) evaluateFilters(ctx context.Context, namespace string) filterDebugResponse {
+	resp := filterDebugResponse{
+		Namespace: namespace,
+		Pods:      []filterDecision{},
+		Apps:      []filterDecision{},
+	}
+	addError := func(kind string, err error) {
+		resp.Errors = append(resp.Errors, kind+": "+err.Error())
+	}
+	decide := func(kind, name, rule string) filterDecision {
+		if rule == "" {
+			resp.Included++
+		} else {
+			resp.Excluded++
+		}
+		return filterDecision{Kind: kind, Name: name, Included: rule == "", Rule: rule}
+	}
+
+	if pods, err := h.listPodsCached(ctx, namespace); err != nil {
+		addError("Pod", err)
+	} else {
+		for _, pod := range pods {
+			resp.Pods = append(resp.Pods, decide("Pod", pod.Name, h.podFilterRule(pod.Name, pod.Labels)))
+		}
+	}
+
+	if deployments, err := h.listDeploymentsCached(ctx, namespace); err != nil {
+		addError("Deployment", err)
+	} else {
+		for _, dep := range deployments {
+			resp.Apps = append(resp.Apps, decide("Deployment", dep.Name, h.appFilterRule(dep.Name, dep.Labels)))
+		}
+	}
+	if statefulSets, err := h.listStatefulSetsCached(ctx, namespace); err != nil {
+		addError("StatefulSet", err)
+	} else {
+		for _, sts := range statefulSets {
+			if hasOwnerKind(sts.OwnerReferences, dragonflyOwnerKind) {
+				continue
+			}
+			resp.Apps = append(resp.Apps, decide("StatefulSet", sts.Name, h.appFilterRule(sts.Name, sts.Labels)))
+		}
+	}
+	if clusters, err := h.listCnpgClustersCached(ctx, namespace); err != nil {
+		addError("Cluster", err)
+	} else {
+		for _, cluster := range clusters {
+			resp.Apps = append(resp.Apps, decide("Cluster", cluster.Metadata.Name, h.appFilterRule(cluster.Metadata.Name, cluster.Metadata.Labels)))
+		}
+	}
+	if dragonflies, err := h.listDragonfliesCached(ctx, namespace); err != nil {
+		addError("Dragonfly", err)
+	} else {
+		for _, dragonfly := range dragonflies {
+			resp.Apps = append(resp.Apps, decide("Dragonfly", dragonfly.Metadata.Name, h.appFilterRule(dragonfly.Metadata.Name, dragonfly.Metadata.Labels)))
+		}
+	}
+	for _, crd := range h.enabledCustomResources() {
+		items, err := h.listCustomResourcesMetadataCached(ctx, namespace, crd)
+		if err != nil {
+			addError(crd.Kind, err)
+			continue
+		}
+		for _, item := range items {
+			resp.Apps = append(resp.Apps, decide(crd.Kind, item.Name, h.appFilterRule(item.Name, item.Labels)))
+		}
+	}
+
+	sortFilterDecisions(resp.Pods)
+	sortFilterDecisions(resp.Apps)
+	return resp
+}
+
+func sortFilterDecisions(items []filterDecision) {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Kind != items[j].Kind {
+			return items[i].Kind < items[j].Kind
+		}
+		return items[i].Name < items[j].Name
+	})
+}
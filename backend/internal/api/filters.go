@@ -1,13 +1,52 @@
 package api
 
 import (
+	"net/http"
 	"regexp"
 	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/halceonio/kubelens/backend/internal/auth"
+	"github.com/halceonio/kubelens/backend/internal/config"
 )
 
 type labelFilter struct {
-	key   string
-	value string
+	key     string
+	value   string
+	pattern *regexp.Regexp
+	// invalid marks a key=~pattern whose regex does not compile. It fails
+	// closed: it is never satisfied as a required label and always excludes.
+	invalid bool
+}
+
+// matches reports whether a present label's value satisfies the filter:
+// any value for a bare key, a full regex match for key=~pattern, and exact
+// equality otherwise.
+func (f labelFilter) matches(value string) bool {
+	if f.invalid {
+		return false
+	}
+	if f.pattern != nil {
+		return f.pattern.MatchString(value)
+	}
+	return f.value == "" || value == f.value
+}
+
+func (f labelFilter) String() string {
+	switch {
+	case f.pattern != nil, f.invalid:
+		return f.key + "=~" + f.value
+	case f.value != "":
+		return f.key + "=" + f.value
+	default:
+		return f.key
+	}
+}
+
+type annotationFilter struct {
+	allow []*regexp.Regexp
+	deny  []*regexp.Regexp
 }
 
 func compileRegex(raw string) *regexp.Regexp {
@@ -16,6 +55,7 @@ func compileRegex(raw string) *regexp.Regexp {
 	}
 	compiled, err := regexp.Compile(raw)
 	if err != nil {
+		log.Warn("invalid filter include_regex; matching everything", "regex", raw, "err", err)
 		return regexp.MustCompile(".*")
 	}
 	return compiled
@@ -28,10 +68,23 @@ func parseLabelFilters(items []string) []labelFilter {
 		if item == "" {
 			continue
 		}
-		parts := strings.SplitN(item, "=", 2)
-		filter := labelFilter{key: strings.TrimSpace(parts[0])}
-		if len(parts) == 2 {
-			filter.value = strings.TrimSpace(parts[1])
+		var filter labelFilter
+		if key, expr, ok := strings.Cut(item, "=~"); ok {
+			filter = labelFilter{key: strings.TrimSpace(key), value: strings.TrimSpace(expr)}
+			pattern, err := compileLabelPattern(expr)
+			if err != nil {
+				// Config loading rejects these; a filter that still gets here
+				// hides everything rather than widening what users can see.
+				log.Warn("invalid label filter regex; matching nothing", "filter", item, "err", err)
+				filter.invalid = true
+			}
+			filter.pattern = pattern
+		} else {
+			parts := strings.SplitN(item, "=", 2)
+			filter = labelFilter{key: strings.TrimSpace(parts[0])}
+			if len(parts) == 2 {
+				filter.value = strings.TrimSpace(parts[1])
+			}
 		}
 		if filter.key != "" {
 			filters = append(filters, filter)
@@ -40,16 +93,183 @@ func parseLabelFilters(items []string) []labelFilter {
 	return filters
 }
 
-func matchesExcluded(labels map[string]string, filters []labelFilter) bool {
-	if len(filters) == 0 || len(labels) == 0 {
-		return false
+// compileLabelPattern anchors expr so "dev.*" must match the whole value.
+func compileLabelPattern(expr string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + strings.TrimSpace(expr) + ")$")
+}
+
+// firstExcluded returns the first filter that labels match. An invalid
+// filter excludes everything.
+func firstExcluded(labels map[string]string, filters []labelFilter) (labelFilter, bool) {
+	for _, filter := range filters {
+		if filter.invalid {
+			return filter, true
+		}
+		if value, ok := labels[filter.key]; ok && filter.matches(value) {
+			return filter, true
+		}
 	}
+	return labelFilter{}, false
+}
+
+// firstMissing returns the first filter that labels do not satisfy; an empty
+// filter list is always satisfied.
+func firstMissing(labels map[string]string, filters []labelFilter) (labelFilter, bool) {
 	for _, filter := range filters {
-		if value, ok := labels[filter.key]; ok {
-			if filter.value == "" || value == filter.value {
-				return true
-			}
+		value, ok := labels[filter.key]
+		if !ok || !filter.matches(value) {
+			return filter, true
+		}
+	}
+	return labelFilter{}, false
+}
+
+// appGroupFilters turns app_groups.labels.selector into a required label
+// ("key" or "key=value") when app groups are enabled.
+func appGroupFilters(cfg config.AppGroupsConfig) []labelFilter {
+	if !cfg.Enabled {
+		return nil
+	}
+	return parseLabelFilters([]string{cfg.Labels.Selector})
+}
+
+// UpdateFilters recompiles the pod and app filters from cfg in place, so a
+// reload that only touches filters keeps active log streams running.
+func (h *KubeHandler) UpdateFilters(cfg *config.Config) {
+	if h == nil || cfg == nil {
+		return
+	}
+	h.filterMu.Lock()
+	defer h.filterMu.Unlock()
+	h.setFilters(cfg)
+}
+
+func (h *KubeHandler) setFilters(cfg *config.Config) {
+	h.podInclude = compileRegex(cfg.Kubernetes.PodFilters.IncludeRegex)
+	h.appInclude = compileRegex(cfg.Kubernetes.AppFilters.IncludeRegex)
+	h.podExclude = parseLabelFilters(cfg.Kubernetes.PodFilters.ExcludeLabels)
+	h.appExclude = parseLabelFilters(cfg.Kubernetes.AppFilters.ExcludeLabels)
+	h.podRequired = parseLabelFilters(cfg.Kubernetes.PodFilters.IncludeLabels)
+	h.appRequired = parseLabelFilters(cfg.Kubernetes.AppFilters.IncludeLabels)
+	h.appGroup = appGroupFilters(cfg.Kubernetes.AppGroups)
+}
+
+func newAnnotationFilter(allow, deny []string) *annotationFilter {
+	filter := &annotationFilter{
+		allow: compileKeyPatterns(allow),
+		deny:  compileKeyPatterns(deny),
+	}
+	if len(filter.allow) == 0 && len(filter.deny) == 0 {
+		return nil
+	}
+	return filter
+}
+
+func compileKeyPatterns(items []string) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		expr := strings.ReplaceAll(regexp.QuoteMeta(item), `\*`, ".*")
+		patterns = append(patterns, regexp.MustCompile("^"+expr+"$"))
+	}
+	return patterns
+}
+
+func matchesAnyPattern(key string, patterns []*regexp.Regexp) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(key) {
+			return true
 		}
 	}
 	return false
 }
+
+func (f *annotationFilter) apply(annotations map[string]string) map[string]string {
+	if f == nil || len(annotations) == 0 {
+		return annotations
+	}
+	out := make(map[string]string, len(annotations))
+	for key, value := range annotations {
+		if len(f.allow) > 0 && !matchesAnyPattern(key, f.allow) {
+			continue
+		}
+		if matchesAnyPattern(key, f.deny) {
+			continue
+		}
+		out[key] = value
+	}
+	return out
+}
+
+func (h *KubeHandler) filterAnnotations(annotations map[string]string) map[string]string {
+	return h.annotations.apply(annotations)
+}
+
+func (h *KubeHandler) canViewMaskedMetadata(r *http.Request) bool {
+	if len(h.maskedKeys) == 0 {
+		return true
+	}
+	user, ok := auth.UserFromContext(r.Context())
+	return ok && user != nil && user.AllowedSecrets
+}
+
+func (h *KubeHandler) maskMetadata(values map[string]string) map[string]string {
+	if len(values) == 0 {
+		return values
+	}
+	var out map[string]string
+	for key := range values {
+		if !matchesAnyPattern(key, h.maskedKeys) {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string, len(values))
+			for k, v := range values {
+				out[k] = v
+			}
+		}
+		out[key] = "********"
+	}
+	if out == nil {
+		return values
+	}
+	return out
+}
+
+func (h *KubeHandler) maskPodResponse(r *http.Request, pod *podResponse) {
+	if h.canViewMaskedMetadata(r) {
+		return
+	}
+	pod.Labels = h.maskMetadata(pod.Labels)
+	pod.Annotations = h.maskMetadata(pod.Annotations)
+}
+
+func (h *KubeHandler) maskPodResponses(r *http.Request, pods []podResponse) {
+	if h.canViewMaskedMetadata(r) {
+		return
+	}
+	for i := range pods {
+		pods[i].Labels = h.maskMetadata(pods[i].Labels)
+		pods[i].Annotations = h.maskMetadata(pods[i].Annotations)
+	}
+}
+
+func (h *KubeHandler) maskAppResponses(r *http.Request, apps []appResponse) {
+	if h.canViewMaskedMetadata(r) {
+		return
+	}
+	for i := range apps {
+		apps[i].Labels = h.maskMetadata(apps[i].Labels)
+		apps[i].Annotations = h.maskMetadata(apps[i].Annotations)
+		h.maskPodResponses(r, apps[i].Pods)
+	}
+}
+
+func (h *KubeHandler) writeAppResponse(w http.ResponseWriter, r *http.Request, app appResponse) {
+	apps := []appResponse{app}
+	h.maskAppResponses(r, apps)
+	writeJSON(w, r, apps[0])
+}
@@ -0,0 +1,371 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/halceonio/kubelens/backend/internal/auth"
+	"github.com/halceonio/kubelens/backend/internal/config"
+)
+
+const testNamespace = "default"
+
+const testConfigYAML = `auth:
+  keycloak_url: https://keycloak.example.com
+  realm: kubelens
+  client_id: kubelens
+  allowed_groups: [devs]
+kubernetes:
+  allowed_namespaces: [default]
+`
+
+var testUser = &auth.User{Subject: "tester", Name: "tester", Groups: []string{"devs"}}
+
+func testConfig(t *testing.T) *config.Config {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(testConfigYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadFromPath(path)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	disabled := false
+	cfg.Kubernetes.APICache.EnableInformers = &disabled
+	return cfg
+}
+
+// newTestKubeHandler builds a KubeHandler on a fake clientset holding
+// objects. configure, if set, adjusts the defaulted config first.
+func newTestKubeHandler(t *testing.T, configure func(*config.Config), objects ...runtime.Object) (*KubeHandler, *fake.Clientset) {
+	t.Helper()
+	cfg := testConfig(t)
+	if configure != nil {
+		configure(cfg)
+	}
+	client := fake.NewClientset(objects...)
+	h := NewKubeHandler(cfg, client, nil)
+	h.raw = emptyRawClient{}
+	t.Cleanup(h.Stop)
+	return h, client
+}
+
+// emptyRawClient stands in for the REST client the fake clientset lacks: every
+// CRD and metrics path lists no items.
+type emptyRawClient struct{}
+
+func (emptyRawClient) getRaw(context.Context, string) ([]byte, error) {
+	return []byte(`{"items":[]}`), nil
+}
+
+type staticVerifier struct{ user *auth.User }
+
+func (v staticVerifier) AuthenticateRequest(*http.Request) (*auth.User, error) {
+	return v.user, nil
+}
+
+// serveAs sends a request through the auth middleware as user.
+func serveAs(h http.Handler, user *auth.User, method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	auth.Middleware(staticVerifier{user: user})(h).ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func decodeJSON[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body.String(), err)
+	}
+	return v
+}
+
+func testPod(name string, labels map[string]string, mutate ...func(*corev1.Pod)) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, Labels: labels},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:1"}}},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "app", Image: "app:1", Ready: true}},
+		},
+	}
+	for _, fn := range mutate {
+		fn(pod)
+	}
+	return pod
+}
+
+func TestHandlePodsListFilters(t *testing.T) {
+	pods := []runtime.Object{
+		testPod("web-1", map[string]string{"app": "web", "tier": "frontend"}),
+		testPod("web-2", map[string]string{"app": "web", "environment": "dev-eu"}),
+		testPod("db-1", map[string]string{"app": "db"}),
+		testPod("debug-shell", nil),
+	}
+	tests := []struct {
+		name      string
+		configure func(*config.Config)
+		want      []string
+	}{
+		{"no filters", nil, []string{"db-1", "debug-shell", "web-1", "web-2"}},
+		{"include regex", func(cfg *config.Config) {
+			cfg.Kubernetes.PodFilters.IncludeRegex = "^web-"
+		}, []string{"web-1", "web-2"}},
+		{"exclude label regex", func(cfg *config.Config) {
+			cfg.Kubernetes.PodFilters.ExcludeLabels = []string{"environment=~dev.*"}
+		}, []string{"db-1", "debug-shell", "web-1"}},
+		{"include label", func(cfg *config.Config) {
+			cfg.Kubernetes.PodFilters.IncludeLabels = []string{"app=web"}
+		}, []string{"web-1", "web-2"}},
+		{"include bare key", func(cfg *config.Config) {
+			cfg.Kubernetes.PodFilters.IncludeLabels = []string{"tier"}
+		}, []string{"web-1"}},
+		{"invalid include regex hides everything", func(cfg *config.Config) {
+			cfg.Kubernetes.PodFilters.IncludeLabels = []string{"app=~("}
+		}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestKubeHandler(t, tt.configure, pods...)
+			rec := serveAs(h, testUser, http.MethodGet, "/api/v1/namespaces/default/pods")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+			}
+			names := []string{}
+			for _, pod := range decodeJSON[[]podResponse](t, rec) {
+				names = append(names, pod.Name)
+			}
+			slices.Sort(names)
+			if !slices.Equal(names, tt.want) {
+				t.Fatalf("pods = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapPod(t *testing.T) {
+	tests := []struct {
+		name         string
+		pod          *corev1.Pod
+		wantStatus   string
+		wantRestarts int32
+		wantOwner    string
+	}{
+		{"running", testPod("web-1", nil), "Running", 0, ""},
+		{"restarts summed", testPod("web-1", nil, func(p *corev1.Pod) {
+			p.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "app", RestartCount: 2}, {Name: "sidecar", RestartCount: 3}}
+		}), "Running", 5, ""},
+		{"owner", testPod("web-1", nil, func(p *corev1.Pod) {
+			p.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-5d9c7"}}
+		}), "Running", 0, "web-5d9c7"},
+		{"terminating", testPod("web-1", nil, func(p *corev1.Pod) {
+			now := metav1.Now()
+			p.DeletionTimestamp = &now
+		}), "Terminating", 0, ""},
+		{"pending", testPod("web-1", nil, func(p *corev1.Pod) {
+			p.Status.Phase = corev1.PodPending
+		}), "Pending", 0, ""},
+	}
+	h, _ := newTestKubeHandler(t, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := h.mapPod(t.Context(), tt.pod, false, testUser, false, envResolve, nil)
+			if resp.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", resp.Status, tt.wantStatus)
+			}
+			if resp.Restarts != tt.wantRestarts {
+				t.Errorf("Restarts = %d, want %d", resp.Restarts, tt.wantRestarts)
+			}
+			if resp.OwnerApp != tt.wantOwner {
+				t.Errorf("OwnerApp = %q, want %q", resp.OwnerApp, tt.wantOwner)
+			}
+		})
+	}
+}
+
+func TestMapDeployment(t *testing.T) {
+	replicas := int32(3)
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace, Labels: map[string]string{"app": "web"}},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:  "app",
+				Image: "app:2",
+				Env:   []corev1.EnvVar{{Name: "MODE", Value: "prod"}},
+			}}}},
+		},
+		Status: appsv1.DeploymentStatus{ReadyReplicas: 2},
+	}
+	pods := []corev1.Pod{
+		*testPod("web-1", map[string]string{"app": "web"}),
+		*testPod("web-2", map[string]string{"app": "web"}),
+		*testPod("db-1", map[string]string{"app": "db"}),
+	}
+	h, _ := newTestKubeHandler(t, nil, dep)
+	resp := h.mapDeployment(t.Context(), dep, testUser, false, envResolve, pods, nil)
+	if resp.Type != "Deployment" || resp.Replicas != 3 || resp.ReadyReplicas != 2 {
+		t.Errorf("got type %q replicas %d/%d, want Deployment 2/3", resp.Type, resp.ReadyReplicas, resp.Replicas)
+	}
+	names := slices.Clone(resp.PodNames)
+	slices.Sort(names)
+	if !slices.Equal(names, []string{"web-1", "web-2"}) {
+		t.Errorf("PodNames = %v, want [web-1 web-2]", names)
+	}
+	if resp.Env["MODE"] != "prod" {
+		t.Errorf("Env = %v, want MODE=prod", resp.Env)
+	}
+}
+
+// envSources returns a container that reads env from a ConfigMap and a Secret.
+func envSources(c *corev1.Container) {
+	c.EnvFrom = []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "web-config"}}}}
+	c.Env = []corev1.EnvVar{{
+		Name: "PASSWORD",
+		ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "web-secret"},
+			Key:                  "password",
+		}},
+	}}
+}
+
+func envSourceReads(client *fake.Clientset) int {
+	reads := 0
+	for _, action := range client.Actions() {
+		if action.Matches("get", "configmaps") || action.Matches("get", "secrets") ||
+			action.Matches("list", "configmaps") || action.Matches("list", "secrets") {
+			reads++
+		}
+	}
+	return reads
+}
+
+// TestListResponsesOmitEnv guards list mode: listing pods or apps must not
+// read ConfigMaps or Secrets, and marks env as omitted rather than masked.
+func TestListResponsesOmitEnv(t *testing.T) {
+	pod := testPod("web-1", map[string]string{"app": "web"}, func(p *corev1.Pod) { envSources(&p.Spec.Containers[0]) })
+	replicas := int32(1)
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: corev1.PodTemplateSpec{Spec: *pod.Spec.DeepCopy()},
+		},
+	}
+	objects := []runtime.Object{
+		pod, dep,
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "web-config", Namespace: testNamespace}, Data: map[string]string{"MODE": "prod"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "web-secret", Namespace: testNamespace}, Data: map[string][]byte{"password": []byte("hunter2")}},
+	}
+	revealer := &auth.User{Subject: "admin", Groups: []string{"devs"}, AllowedSecrets: true}
+
+	tests := []struct {
+		name      string
+		target    string
+		wantReads bool
+		check     func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{"pod list", "/api/v1/namespaces/default/pods", false, func(t *testing.T, rec *httptest.ResponseRecorder) {
+			items := decodeJSON[[]podResponse](t, rec)
+			if len(items) == 0 {
+				t.Fatal("no pods listed")
+			}
+			for _, item := range items {
+				if !item.EnvOmitted || len(item.Env) != 0 {
+					t.Errorf("pod %s: envOmitted = %v, env = %v", item.Name, item.EnvOmitted, item.Env)
+				}
+			}
+		}},
+		{"app list", "/api/v1/namespaces/default/apps", false, func(t *testing.T, rec *httptest.ResponseRecorder) {
+			items := decodeJSON[[]appResponse](t, rec)
+			if len(items) == 0 {
+				t.Fatal("no apps listed")
+			}
+			for _, item := range items {
+				if !item.EnvOmitted || len(item.Env) != 0 {
+					t.Errorf("app %s: envOmitted = %v, env = %v", item.Name, item.EnvOmitted, item.Env)
+				}
+			}
+		}},
+		{"pod list with reveal", "/api/v1/namespaces/default/pods?reveal_secrets=true", false, nil},
+		{"pod details", "/api/v1/namespaces/default/pods/web-1/details", true, func(t *testing.T, rec *httptest.ResponseRecorder) {
+			item := decodeJSON[podResponse](t, rec)
+			if item.EnvOmitted || item.Env["MODE"] != "prod" {
+				t.Errorf("envOmitted = %v, env = %v, want MODE=prod", item.EnvOmitted, item.Env)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, client := newTestKubeHandler(t, nil, objects...)
+			rec := serveAs(h, revealer, http.MethodGet, tt.target)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+			}
+			if reads := envSourceReads(client); (reads > 0) != tt.wantReads {
+				t.Errorf("ConfigMap/Secret reads = %d, want reads %v", reads, tt.wantReads)
+			}
+			if tt.check != nil {
+				tt.check(t, rec)
+			}
+		})
+	}
+}
+
+func TestHandleAppsListExpandPods(t *testing.T) {
+	replicas := int32(1)
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+	}
+	pod := testPod("web-1", map[string]string{"app": "web"})
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"default", "", nil},
+		{"expand", "?expand=pods", []string{"web-1"}},
+		{"expand overrides light", "?expand=pods&light=true", []string{"web-1"}},
+		{"light", "?light=true", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestKubeHandler(t, nil, dep, pod)
+			rec := serveAs(h, testUser, http.MethodGet, "/api/v1/namespaces/default/apps"+tt.query)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+			}
+			apps := decodeJSON[[]appResponse](t, rec)
+			if len(apps) != 1 {
+				t.Fatalf("got %d apps, want 1", len(apps))
+			}
+			var got []string
+			for _, p := range apps[0].Pods {
+				got = append(got, p.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("pods = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
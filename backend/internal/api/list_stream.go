@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	ndjsonContentType = "application/x-ndjson"
+	ndjsonFlushEvery  = 100
+
+	listTruncatedHeader  = "X-Kubelens-Truncated"
+	listTotalCountHeader = "X-Kubelens-Total-Count"
+)
+
+type ndjsonWriter struct {
+	enc     *json.Encoder
+	flusher http.Flusher
+	pending int
+}
+
+func wantsNDJSON(r *http.Request) bool {
+	return strings.Contains(strings.ToLower(r.Header.Get("Accept")), ndjsonContentType)
+}
+
+func newNDJSONWriter(w http.ResponseWriter) *ndjsonWriter {
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	return &ndjsonWriter{enc: json.NewEncoder(w), flusher: flusher}
+}
+
+func (n *ndjsonWriter) write(item any) error {
+	if err := n.enc.Encode(item); err != nil {
+		return err
+	}
+	n.pending++
+	if n.pending >= ndjsonFlushEvery {
+		n.flush()
+	}
+	return nil
+}
+
+func (n *ndjsonWriter) flush() {
+	n.pending = 0
+	if n.flusher != nil {
+		n.flusher.Flush()
+	}
+}
+
+func truncateList[T any](w http.ResponseWriter, items []T, max int) []T {
+	if max <= 0 || len(items) <= max {
+		return items
+	}
+	w.Header().Set(listTruncatedHeader, "true")
+	w.Header().Set(listTotalCountHeader, strconv.Itoa(len(items)))
+	return items[:max]
+}
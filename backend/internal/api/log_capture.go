@@ -0,0 +1,158 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/halceonio/kubelens/backend/internal/auth"
+)
+
+const logCaptureHeader = "X-Kubelens-Capture-Id"
+
+var (
+	errLogCaptureDisabled  = errors.New("log capture disabled")
+	errLogCaptureForbidden = errors.New("log capture not permitted")
+)
+
+type logCapture struct {
+	id       string
+	path     string
+	maxBytes int64
+	mu       sync.Mutex
+	file     *os.File
+	written  int64
+}
+
+type logCaptureStarted struct {
+	ID string `json:"id"`
+}
+
+func wantsCapture(r *http.Request) bool {
+	val := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("capture")))
+	return val == "1" || val == "true" || val == "yes"
+}
+
+func (h *KubeHandler) canCaptureLogs(r *http.Request) bool {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok || user == nil {
+		return false
+	}
+	if len(h.cfg.Logs.CaptureGroups) == 0 {
+		return user.AllowedSecrets
+	}
+	for _, group := range user.Groups {
+		for _, allowed := range h.cfg.Logs.CaptureGroups {
+			if group == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (h *KubeHandler) startLogCapture(r *http.Request, namespace, name string) (*logCapture, error) {
+	if !h.cfg.Logs.CaptureEnabled || h.cfg.Logs.CaptureDir == "" {
+		return nil, errLogCaptureDisabled
+	}
+	if !h.canCaptureLogs(r) {
+		return nil, errLogCaptureForbidden
+	}
+	if err := os.MkdirAll(h.cfg.Logs.CaptureDir, 0o750); err != nil {
+		return nil, fmt.Errorf("create capture dir: %w", err)
+	}
+	id := fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102T150405Z"), randomID())
+	path := filepath.Join(h.cfg.Logs.CaptureDir, fmt.Sprintf("%s_%s_%s.ndjson", namespace, name, id))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("open capture file: %w", err)
+	}
+	h.audit(r, "log_capture_start", namespace, name, map[string]any{
+		"capture_id": id,
+		"path":       path,
+	})
+	return &logCapture{
+		id:       id,
+		path:     path,
+		maxBytes: int64(h.cfg.Logs.CaptureMaxBytes),
+		file:     file,
+	}, nil
+}
+
+func writeCaptureError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errLogCaptureDisabled):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, errLogCaptureForbidden):
+		writeError(w, http.StatusForbidden, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func (c *logCapture) writeEvent(event sseEvent) {
+	if c == nil || event.Event != "log" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return
+	}
+	if c.maxBytes > 0 && c.written+int64(len(event.Data))+1 > c.maxBytes {
+		if err := c.rotate(); err != nil {
+			log.Warn("log capture rotate failed", "id", c.id, "err", err)
+			c.closeFile()
+			return
+		}
+	}
+	line := make([]byte, 0, len(event.Data)+1)
+	line = append(append(line, event.Data...), '\n')
+	n, err := c.file.Write(line)
+	c.written += int64(n)
+	if err != nil {
+		log.Warn("log capture write failed", "id", c.id, "err", err)
+		c.closeFile()
+	}
+}
+
+func (c *logCapture) rotate() error {
+	err := c.file.Close()
+	c.file = nil
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(c.path, c.path+".1"); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(c.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	c.file = file
+	c.written = 0
+	return nil
+}
+
+func (c *logCapture) closeFile() {
+	if c.file != nil {
+		_ = c.file.Close()
+		c.file = nil
+	}
+}
+
+func (c *logCapture) close() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeFile()
+}
@@ -1,21 +1,42 @@
 package api
 
 import (
+	"math"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
+const (
+	logLimiterIdleTTL       = 10 * time.Minute
+	logLimiterSweepInterval = time.Minute
+	logLimiterMaxBuckets    = 50000
+	globalBucketPrefix      = "*|"
+)
+
 type logLimiter struct {
 	mu           sync.Mutex
 	defaultRate  float64
 	defaultBurst float64
 	overrides    map[string]rateConfig
+	globalRate   float64
+	globalBurst  float64
 	buckets      map[string]*tokenBucket
+	lastSweep    time.Time
 }
 
 type tokenBucket struct {
 	tokens float64
 	last   time.Time
+	cfg    rateConfig
+}
+
+type rateLimitState struct {
+	limit      int
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
 }
 
 type rateConfig struct {
@@ -23,15 +44,19 @@ type rateConfig struct {
 	burst float64
 }
 
-func newLogLimiter(ratePerMinute int, burst int, overrides []rateConfigEntry) *logLimiter {
+func newLogLimiter(ratePerMinute int, burst int, overrides []rateConfigEntry, globalPerMinute int, globalBurst int) *logLimiter {
 	hasDefault := ratePerMinute > 0
 	hasOverrides := len(overrides) > 0
-	if !hasDefault && !hasOverrides {
+	hasGlobal := globalPerMinute > 0
+	if !hasDefault && !hasOverrides && !hasGlobal {
 		return nil
 	}
 	if burst <= 0 {
 		burst = ratePerMinute
 	}
+	if globalBurst <= 0 {
+		globalBurst = globalPerMinute
+	}
 	configOverrides := map[string]rateConfig{}
 	for _, entry := range overrides {
 		if entry.namespace == "" || entry.ratePerMinute <= 0 {
@@ -50,7 +75,10 @@ func newLogLimiter(ratePerMinute int, burst int, overrides []rateConfigEntry) *l
 		defaultRate:  implementRate(ratePerMinute),
 		defaultBurst: float64(burst),
 		overrides:    configOverrides,
+		globalRate:   implementRate(globalPerMinute),
+		globalBurst:  float64(globalBurst),
 		buckets:      map[string]*tokenBucket{},
+		lastSweep:    time.Now(),
 	}
 }
 
@@ -58,38 +86,199 @@ func implementRate(ratePerMinute int) float64 {
 	return float64(ratePerMinute) / 60.0
 }
 
-func (l *logLimiter) Allow(namespace, key string) bool {
+func (l *logLimiter) Allow(namespace, key, identity string) (bool, rateLimitState) {
 	if l == nil {
-		return true
+		return true, rateLimitState{}
 	}
 	cfg := l.lookupConfig(namespace)
-	if cfg.rate <= 0 {
-		return true
+	global := rateConfig{rate: l.globalRate, burst: l.globalBurst}
+	if identity == "" {
+		global = rateConfig{}
+	}
+	if cfg.rate <= 0 && global.rate <= 0 {
+		return true, rateLimitState{}
 	}
 
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	bucketKey := namespace + "|" + key
+	now := time.Now()
+	if now.Sub(l.lastSweep) >= logLimiterSweepInterval {
+		l.sweepLocked(now, logLimiterIdleTTL)
+	}
+
+	var buckets []*tokenBucket
+	if cfg.rate > 0 {
+		buckets = append(buckets, l.refillLocked(namespace+"|"+key, cfg, now))
+	}
+	if global.rate > 0 {
+		buckets = append(buckets, l.refillLocked(globalBucketPrefix+identity, global, now))
+	}
+	for _, bucket := range buckets {
+		if bucket.tokens < 1 {
+			return false, limitState(buckets)
+		}
+	}
+	for _, bucket := range buckets {
+		bucket.tokens -= 1
+	}
+	return true, limitState(buckets)
+}
+
+func limitState(buckets []*tokenBucket) rateLimitState {
+	var state rateLimitState
+	for i, bucket := range buckets {
+		remaining := int(bucket.tokens)
+		if remaining < 0 {
+			remaining = 0
+		}
+		if i > 0 && remaining >= state.remaining {
+			continue
+		}
+		state = rateLimitState{
+			limit:     int(bucket.cfg.burst),
+			remaining: remaining,
+			reset:     secondsUntil(bucket.cfg.burst-bucket.tokens, bucket.cfg.rate),
+		}
+		if bucket.tokens < 1 {
+			state.retryAfter = secondsUntil(1-bucket.tokens, bucket.cfg.rate)
+		}
+	}
+	return state
+}
+
+func secondsUntil(tokens, rate float64) time.Duration {
+	if tokens <= 0 || rate <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(tokens/rate)) * time.Second
+}
+
+func (l *logLimiter) refillLocked(bucketKey string, cfg rateConfig, now time.Time) *tokenBucket {
 	bucket, ok := l.buckets[bucketKey]
 	if !ok {
-		bucket = &tokenBucket{tokens: cfg.burst, last: time.Now()}
+		if len(l.buckets) >= logLimiterMaxBuckets {
+			l.sweepLocked(now, 0)
+			if len(l.buckets) >= logLimiterMaxBuckets {
+				l.evictOldestLocked()
+			}
+		}
+		bucket = &tokenBucket{tokens: cfg.burst, last: now, cfg: cfg}
 		l.buckets[bucketKey] = bucket
+		return bucket
 	}
 
-	now := time.Now()
 	elapsed := now.Sub(bucket.last).Seconds()
 	bucket.last = now
-
 	bucket.tokens += elapsed * cfg.rate
 	if bucket.tokens > cfg.burst {
 		bucket.tokens = cfg.burst
 	}
-	if bucket.tokens < 1 {
-		return false
+	return bucket
+}
+
+type rateLimitBucketResponse struct {
+	Namespace string  `json:"namespace,omitempty"`
+	Scope     string  `json:"scope"`
+	Tokens    float64 `json:"tokens"`
+	Limit     int     `json:"limit"`
+	LastSeen  string  `json:"lastSeen"`
+}
+
+func bucketMatchesIdentity(bucketKey, identity string) (string, bool) {
+	if bucketKey == globalBucketPrefix+identity {
+		return "", true
+	}
+	parts := strings.SplitN(bucketKey, "|", 2)
+	if len(parts) != 2 {
+		return "", false
+	}
+	if parts[1] == identity+"|"+parts[0] {
+		return parts[0], true
+	}
+	return "", false
+}
+
+func (l *logLimiter) inspect(identity string) []rateLimitBucketResponse {
+	out := []rateLimitBucketResponse{}
+	if l == nil || identity == "" {
+		return out
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	for key, bucket := range l.buckets {
+		namespace, ok := bucketMatchesIdentity(key, identity)
+		if !ok {
+			continue
+		}
+		tokens := bucket.tokens + now.Sub(bucket.last).Seconds()*bucket.cfg.rate
+		if tokens > bucket.cfg.burst {
+			tokens = bucket.cfg.burst
+		}
+		scope := "namespace"
+		if namespace == "" {
+			scope = "global"
+		}
+		out = append(out, rateLimitBucketResponse{
+			Namespace: namespace,
+			Scope:     scope,
+			Tokens:    math.Floor(tokens*100) / 100,
+			Limit:     int(bucket.cfg.burst),
+			LastSeen:  bucket.last.UTC().Format(time.RFC3339),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Scope != out[j].Scope {
+			return out[i].Scope < out[j].Scope
+		}
+		return out[i].Namespace < out[j].Namespace
+	})
+	return out
+}
+
+func (l *logLimiter) reset(identity string) int {
+	if l == nil || identity == "" {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	removed := 0
+	for key := range l.buckets {
+		if _, ok := bucketMatchesIdentity(key, identity); ok {
+			delete(l.buckets, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (l *logLimiter) sweepLocked(now time.Time, ttl time.Duration) {
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		idle := now.Sub(bucket.last)
+		if idle < ttl {
+			continue
+		}
+		if bucket.tokens+idle.Seconds()*bucket.cfg.rate < bucket.cfg.burst {
+			continue
+		}
+		delete(l.buckets, key)
+	}
+}
+
+func (l *logLimiter) evictOldestLocked() {
+	var oldestKey string
+	var oldest time.Time
+	for key, bucket := range l.buckets {
+		if oldestKey == "" || bucket.last.Before(oldest) {
+			oldestKey = key
+			oldest = bucket.last
+		}
+	}
+	if oldestKey != "" {
+		delete(l.buckets, oldestKey)
 	}
-	bucket.tokens -= 1
-	return true
 }
 
 func (l *logLimiter) lookupConfig(namespace string) rateConfig {
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLogLimiterBucketsStayBounded(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity string
+	}{
+		{"namespace buckets", ""},
+		{"namespace and global buckets", "user"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newLogLimiter(60, 10, nil, 600, 100)
+			for i := range logLimiterMaxBuckets + 500 {
+				limiter.Allow("default", fmt.Sprintf("10.0.%d.%d", i/256, i%256), tt.identity)
+			}
+			if got := len(limiter.buckets); got > logLimiterMaxBuckets {
+				t.Fatalf("len(buckets) = %d, want <= %d", got, logLimiterMaxBuckets)
+			}
+		})
+	}
+}
+
+func TestLogLimiterSweep(t *testing.T) {
+	now := time.Now()
+	cfg := rateConfig{rate: 1, burst: 10}
+	tests := []struct {
+		name   string
+		bucket tokenBucket
+		ttl    time.Duration
+		kept   bool
+	}{
+		{"idle and full", tokenBucket{tokens: 10, last: now.Add(-time.Hour), cfg: cfg}, logLimiterIdleTTL, false},
+		{"idle and refilled", tokenBucket{tokens: 0, last: now.Add(-time.Hour), cfg: cfg}, logLimiterIdleTTL, false},
+		{"recently used", tokenBucket{tokens: 10, last: now.Add(-time.Second), cfg: cfg}, logLimiterIdleTTL, true},
+		{"draining without ttl", tokenBucket{tokens: 2, last: now, cfg: cfg}, 0, true},
+		{"full without ttl", tokenBucket{tokens: 10, last: now, cfg: cfg}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newLogLimiter(60, 10, nil, 0, 0)
+			bucket := tt.bucket
+			limiter.buckets["default|key"] = &bucket
+			limiter.sweepLocked(now, tt.ttl)
+			if _, kept := limiter.buckets["default|key"]; kept != tt.kept {
+				t.Fatalf("kept = %v, want %v", kept, tt.kept)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type redisOp int
+
+const (
+	redisOpXAdd redisOp = iota
+	redisOpXRead
+	redisOpXRange
+	redisOpLock
+	redisOpCount
+)
+
+var redisOpNames = [redisOpCount]string{"xadd", "xread", "xrange", "lock"}
+
+// redisOpBuckets are histogram upper bounds in seconds. XREAD blocks for up
+// to logs.redis_stream_block_millis, so its samples land in the upper buckets.
+var redisOpBuckets = [...]float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+type redisOpMetrics struct {
+	buckets  [redisOpCount][len(redisOpBuckets)]atomic.Int64
+	count    [redisOpCount]atomic.Int64
+	sumNanos [redisOpCount]atomic.Int64
+	errors   [redisOpCount]atomic.Int64
+}
+
+// RedisOpStats is a point-in-time histogram for one Redis operation. Buckets
+// are cumulative and line up with RedisOpBuckets.
+type RedisOpStats struct {
+	Op         string
+	Buckets    []int64
+	Count      int64
+	SumSeconds float64
+	Errors     int64
+}
+
+// RedisOpBuckets returns the histogram upper bounds in seconds.
+func RedisOpBuckets() []float64 {
+	return redisOpBuckets[:]
+}
+
+// observe records the duration since start and counts err unless it only
+// signals an empty read or a canceled caller.
+func (m *redisOpMetrics) observe(op redisOp, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	elapsed := time.Since(start)
+	seconds := elapsed.Seconds()
+	for i, bound := range redisOpBuckets {
+		if seconds <= bound {
+			m.buckets[op][i].Add(1)
+			break
+		}
+	}
+	m.count[op].Add(1)
+	m.sumNanos[op].Add(int64(elapsed))
+	if err != nil && err != redis.Nil && !errors.Is(err, context.Canceled) {
+		m.errors[op].Add(1)
+	}
+}
+
+func (m *redisOpMetrics) snapshot() []RedisOpStats {
+	if m == nil {
+		return nil
+	}
+	items := make([]RedisOpStats, 0, redisOpCount)
+	for op := redisOp(0); op < redisOpCount; op++ {
+		item := RedisOpStats{
+			Op:         redisOpNames[op],
+			Buckets:    make([]int64, len(redisOpBuckets)),
+			Count:      m.count[op].Load(),
+			SumSeconds: time.Duration(m.sumNanos[op].Load()).Seconds(),
+			Errors:     m.errors[op].Load(),
+		}
+		cumulative := int64(0)
+		for i := range redisOpBuckets {
+			cumulative += m.buckets[op][i].Load()
+			item.Buckets[i] = cumulative
+		}
+		items = append(items, item)
+	}
+	return items
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	defaultReorderWindow   = 500 * time.Millisecond
+	defaultReorderMaxLines = 5000
+)
+
+type reorderItem struct {
+	entry   logEntry
+	ts      time.Time
+	arrived time.Time
+}
+
+type logReorderBuffer struct {
+	window   time.Duration
+	maxLines int
+	items    []reorderItem
+}
+
+func newLogReorderBuffer(window time.Duration, maxLines int) *logReorderBuffer {
+	if window <= 0 {
+		window = defaultReorderWindow
+	}
+	if maxLines <= 0 {
+		maxLines = defaultReorderMaxLines
+	}
+	return &logReorderBuffer{
+		window:   window,
+		maxLines: maxLines,
+	}
+}
+
+func (b *logReorderBuffer) add(entry logEntry, now time.Time) []logEntry {
+	ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+	if err != nil {
+		ts = now
+	}
+	b.items = append(b.items, reorderItem{entry: entry, ts: ts, arrived: now})
+	if len(b.items) <= b.maxLines {
+		return nil
+	}
+	b.sort()
+	return b.take(len(b.items) - b.maxLines)
+}
+
+// release emits every buffered line at or before the newest timestamp among
+// lines that have waited a full window, so output stays monotonic.
+func (b *logReorderBuffer) release(now time.Time) []logEntry {
+	if len(b.items) == 0 {
+		return nil
+	}
+	b.sort()
+	cutoff := now.Add(-b.window)
+	n := 0
+	for i, item := range b.items {
+		if !item.arrived.After(cutoff) {
+			n = i + 1
+		}
+	}
+	return b.take(n)
+}
+
+// flush emits every buffered line in order, for a stream that is stopping.
+func (b *logReorderBuffer) flush() []logEntry {
+	b.sort()
+	return b.take(len(b.items))
+}
+
+func (b *logReorderBuffer) sort() {
+	sort.SliceStable(b.items, func(i, j int) bool {
+		if !b.items[i].ts.Equal(b.items[j].ts) {
+			return b.items[i].ts.Before(b.items[j].ts)
+		}
+		return b.items[i].entry.Seq < b.items[j].entry.Seq
+	})
+}
+
+func (b *logReorderBuffer) take(n int) []logEntry {
+	if n <= 0 {
+		return nil
+	}
+	out := make([]logEntry, 0, n)
+	for _, item := range b.items[:n] {
+		out = append(out, item.entry)
+	}
+	b.items = append(b.items[:0], b.items[n:]...)
+	return out
+}
+
+func (b *logReorderBuffer) len() int {
+	return len(b.items)
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLogReorderBuffer(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	line := func(offset time.Duration, message string) logEntry {
+		return logEntry{Timestamp: start.Add(offset).Format(time.RFC3339Nano), Message: message}
+	}
+	messages := func(entries []logEntry) []string {
+		out := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			out = append(out, entry.Message)
+		}
+		return out
+	}
+	tests := []struct {
+		name    string
+		drain   func(b *logReorderBuffer) []logEntry
+		want    []string
+		wantLen int
+	}{
+		{"release before window", func(b *logReorderBuffer) []logEntry { return b.release(start) }, []string{}, 3},
+		{"release after window", func(b *logReorderBuffer) []logEntry { return b.release(start.Add(time.Second)) }, []string{"a", "b", "c"}, 0},
+		{"flush", func(b *logReorderBuffer) []logEntry { return b.flush() }, []string{"a", "b", "c"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newLogReorderBuffer(500*time.Millisecond, 10)
+			b.add(line(2*time.Millisecond, "c"), start)
+			b.add(line(0, "a"), start)
+			b.add(line(time.Millisecond, "b"), start)
+			if got := messages(tt.drain(b)); !slices.Equal(got, tt.want) {
+				t.Errorf("emitted %q, want %q", got, tt.want)
+			}
+			if b.len() != tt.wantLen {
+				t.Errorf("len = %d, want %d", b.len(), tt.wantLen)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultLogSearchLimit       = 200
+	defaultLogSearchConcurrency = 4
+	defaultLogSearchMaxBytes    = 64 * 1024 * 1024
+	defaultLogSearchMaxResults  = 1000
+	logSearchTimeout            = 30 * time.Second
+)
+
+type logSearchResponse struct {
+	Items        []logEntry `json:"items"`
+	Matched      int        `json:"matched"`
+	Truncated    bool       `json:"truncated"`
+	ScannedBytes int64      `json:"scannedBytes"`
+	Pods         int        `json:"pods"`
+	Errors       []string   `json:"errors,omitempty"`
+}
+
+type logSearchTarget struct {
+	pod       string
+	container string
+}
+
+type logSearchResult struct {
+	entries []logEntry
+	matched int
+	err     error
+}
+
+func (h *KubeHandler) searchAppLogs(w http.ResponseWriter, r *http.Request, namespace, name string) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	query := r.URL.Query()
+	pattern := query.Get("q")
+	if pattern == "" {
+		writeError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid q: "+err.Error())
+		return
+	}
+	since, err := parseLogSearchSince(query.Get("since"), time.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	maxResults := h.cfg.Logs.SearchMaxResults
+	if maxResults <= 0 {
+		maxResults = defaultLogSearchMaxResults
+	}
+	limit := defaultLogSearchLimit
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+	if limit > maxResults {
+		limit = maxResults
+	}
+	loc, err := h.resolveLogLocation(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !h.allowLogRequest(w, r, namespace) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), logSearchTimeout)
+	defer cancel()
+
+	selector, err := h.appSelector(ctx, namespace, name)
+	if err == nil && selector == "" {
+		err = errAppNotFound
+	}
+	if err != nil {
+		status := http.StatusNotFound
+		if !errors.Is(err, errAppNotFound) {
+			status = http.StatusBadGateway
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+	pods, err := h.listPodsBySelectorCached(ctx, namespace, selector)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	h.audit(r, "app_logs_search", namespace, name, map[string]any{
+		"q":         pattern,
+		"container": query.Get("container"),
+	})
+
+	targets := logSearchTargets(pods, query.Get("container"))
+	resp := h.runLogSearch(ctx, namespace, targets, re, since, limit)
+	resp.Pods = len(pods)
+	for i := range resp.Items {
+		resp.Items[i] = localizeLogEntry(resp.Items[i], loc)
+	}
+	writeJSON(w, r, resp)
+}
+
+// runLogSearch reads non-follow logs for every target with bounded
+// concurrency and a shared byte budget, keeping the newest limit matches.
+func (h *KubeHandler) runLogSearch(ctx context.Context, namespace string, targets []logSearchTarget, re *regexp.Regexp, since *time.Time, limit int) logSearchResponse {
+	concurrency := h.cfg.Logs.SearchMaxConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultLogSearchConcurrency
+	}
+	budget := int64(h.cfg.Logs.SearchMaxBytes)
+	if budget <= 0 {
+		budget = defaultLogSearchMaxBytes
+	}
+
+	var scanned atomic.Int64
+	var exhausted atomic.Bool
+	results := make([]logSearchResult, len(targets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target logSearchTarget) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results[i].err = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+			results[i] = h.searchPodLogs(ctx, namespace, target, re, since, limit, budget, &scanned, &exhausted)
+		}(i, target)
+	}
+	wg.Wait()
+
+	resp := logSearchResponse{Items: []logEntry{}}
+	for i, result := range results {
+		resp.Matched += result.matched
+		resp.Items = append(resp.Items, result.entries...)
+		if result.err != nil {
+			resp.Errors = append(resp.Errors, targets[i].pod+"/"+targets[i].container+": "+result.err.Error())
+		}
+	}
+	sortLogEntries(resp.Items)
+	if len(resp.Items) > limit {
+		resp.Items = resp.Items[len(resp.Items)-limit:]
+	}
+	resp.Truncated = exhausted.Load() || resp.Matched > len(resp.Items)
+	resp.ScannedBytes = scanned.Load()
+	return resp
+}
+
+func (h *KubeHandler) searchPodLogs(ctx context.Context, namespace string, target logSearchTarget, re *regexp.Regexp, since *time.Time, limit int, budget int64, scanned *atomic.Int64, exhausted *atomic.Bool) logSearchResult {
+	if !h.logHub.acquireGetLogsSlot(ctx) {
+		return logSearchResult{err: ctx.Err()}
+	}
+	defer h.logHub.releaseGetLogsSlot()
+
+	opts := &corev1.PodLogOptions{
+		Timestamps: true,
+		Container:  target.container,
+		LimitBytes: h.logLimitBytes(),
+	}
+	if since != nil {
+		opts.SinceTime = &metav1.Time{Time: since.UTC()}
+	}
+	stream, err := h.client.CoreV1().Pods(namespace).GetLogs(target.pod, opts).Stream(ctx)
+	if err != nil {
+		return logSearchResult{err: err}
+	}
+	defer stream.Close()
+
+	var result logSearchResult
+	reader := bufio.NewReader(stream)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			if scanned.Add(int64(len(line))) > budget {
+				exhausted.Store(true)
+				return result
+			}
+			entry := h.parseLogLine(strings.TrimRight(line, "\n"), target.pod, target.container)
+			if re.MatchString(entry.Message) {
+				result.matched++
+				// Each pod keeps only its newest limit matches; the merge trims again.
+				result.entries = append(result.entries, entry)
+				if len(result.entries) > limit {
+					result.entries = result.entries[1:]
+				}
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				result.err = err
+			}
+			return result
+		}
+	}
+}
+
+func sortLogEntries(entries []logEntry) {
+	keys := make([]time.Time, len(entries))
+	for i, entry := range entries {
+		keys[i], _ = time.Parse(time.RFC3339Nano, entry.Timestamp)
+	}
+	sort.Stable(logEntriesByTime{entries: entries, keys: keys})
+}
+
+type logEntriesByTime struct {
+	entries []logEntry
+	keys    []time.Time
+}
+
+func (s logEntriesByTime) Len() int           { return len(s.entries) }
+func (s logEntriesByTime) Less(i, j int) bool { return s.keys[i].Before(s.keys[j]) }
+func (s logEntriesByTime) Swap(i, j int) {
+	s.entries[i], s.entries[j] = s.entries[j], s.entries[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+func logSearchTargets(pods []corev1.Pod, container string) []logSearchTarget {
+	targets := []logSearchTarget{}
+	for _, pod := range pods {
+		for _, c := range pod.Spec.Containers {
+			if container != "" && c.Name != container {
+				continue
+			}
+			targets = append(targets, logSearchTarget{pod: pod.Name, container: c.Name})
+		}
+	}
+	return targets
+}
+
+// parseLogSearchSince accepts an RFC3339 timestamp or a Go duration
+// relative to now (for example 15m or 2h).
+func parseLogSearchSince(raw string, now time.Time) (*time.Time, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	if t, ok := parseLogTime(raw); ok {
+		return &t, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return nil, errors.New("since must be an RFC3339 timestamp or a positive duration")
+	}
+	t := now.Add(-d)
+	return &t, nil
+}
@@ -7,6 +7,8 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -19,6 +21,7 @@ import (
 	"github.com/charmbracelet/log"
 	"github.com/redis/go-redis/v9"
 
+	"github.com/halceonio/kubelens/backend/internal/config"
 	"github.com/halceonio/kubelens/backend/internal/storage"
 )
 
@@ -27,11 +30,20 @@ const (
 	defaultWorkerBufferLines  = 10000
 	defaultWorkerBufferBytes  = 50 * 1024 * 1024
 	defaultSubscriberBuffer   = 2000
-	defaultRedisStreamPrefix  = "kubelens:logs"
+	defaultRedisStreamPrefix  = config.DefaultRedisStreamPrefix
 	defaultRedisStreamMaxLen  = 10000
 	defaultRedisStreamBlock   = 2 * time.Second
 	defaultRedisLockTTL       = 15 * time.Second
 	defaultRedisLockKeySuffix = ":lock"
+	defaultRedisJanitorEvery  = 5 * time.Minute
+	defaultRedisPublishEvery  = 20 * time.Millisecond
+	replayFallbackTimeout     = 10 * time.Second
+	hubDrainPoll              = 50 * time.Millisecond
+)
+
+var (
+	errLogStreamLimit = errors.New("log stream limit reached")
+	errLogHubStopped  = errors.New("log streams are shutting down")
 )
 
 type logStreamHub struct {
@@ -42,14 +54,24 @@ type logStreamHub struct {
 	redisMaxLen      int64
 	redisBlock       time.Duration
 	redisLockTTL     time.Duration
+	janitorEvery     time.Duration
+	janitorStop      chan struct{}
+	publishBatch     int
+	publishEvery     time.Duration
 	bufferLines      int
 	bufferBytes      int
 	subscriberBuffer int
 	idleTTL          time.Duration
+	maxStreams       int
 	instanceID       string
 	clusterName      string
 	mu               sync.Mutex
 	streams          map[string]*logStream
+	stopped          bool
+	goroutines       atomic.Int64
+	getLogsSlots     chan struct{}
+	redisOps         redisOpMetrics
+	sse              sseMetrics
 }
 
 type logStream struct {
@@ -78,12 +100,15 @@ type logStream struct {
 	seq         atomic.Uint64
 	lastEventAt atomic.Int64
 	reconnects  atomic.Int64
+	queued      atomic.Bool
+	lockOwner   atomic.Value
 	startSince  *time.Time
 }
 
 type logSubscriber struct {
 	id      string
 	ch      chan logEntry
+	done    chan struct{}
 	dropped atomic.Int64
 }
 
@@ -99,16 +124,27 @@ type logStreamStatus struct {
 	Role          string `json:"role"`
 	RedisEnabled  bool   `json:"redis_enabled"`
 	Leader        bool   `json:"leader"`
+	Instance      string `json:"instance"`
+	LockOwner     string `json:"lock_owner,omitempty"`
 	Reconnects    int64  `json:"reconnects"`
 	LastEventAt   string `json:"last_event_at"`
 	LagMillis     int64  `json:"lag_ms"`
 	Subscribers   int    `json:"subscribers"`
 	BufferedLines int    `json:"buffered_lines"`
 	BufferBytes   int    `json:"buffer_bytes"`
+	Queued        bool   `json:"queued"`
+}
+
+type logStreamInfo struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	logStreamStatus
 }
 
 type LogStreamStats struct {
 	ActiveStreams      int
+	MaxStreams         int
 	ActiveSubscribers  int
 	DroppedTotal       int64
 	BufferedLinesTotal int
@@ -117,6 +153,10 @@ type LogStreamStats struct {
 	ReconnectsTotal    int64
 	LagMsMax           int64
 	LagMsAvg           int64
+	Goroutines         int64
+	RedisOps           []RedisOpStats
+	SSECloses          []SSECloseStats
+	AppEventsDropped   int64
 }
 
 func newLogStreamHub(handler *KubeHandler) *logStreamHub {
@@ -149,15 +189,23 @@ func newLogStreamHub(handler *KubeHandler) *logStreamHub {
 		redisMaxLen:      int64(cfg.RedisStreamMaxLen),
 		redisBlock:       time.Duration(cfg.RedisStreamBlockMillis) * time.Millisecond,
 		redisLockTTL:     time.Duration(cfg.RedisLockTTLSeconds) * time.Second,
+		janitorEvery:     time.Duration(cfg.RedisJanitorSeconds) * time.Second,
+		publishBatch:     cfg.RedisPublishBatch,
+		publishEvery:     time.Duration(cfg.RedisPublishMillis) * time.Millisecond,
 		bufferLines:      bufferLines,
 		bufferBytes:      bufferBytes,
 		subscriberBuffer: subscriberBuffer,
 		idleTTL:          idleTTL,
-		instanceID:       randomID(),
+		maxStreams:       cfg.MaxStreams,
+		instanceID:       newInstanceID(),
 		clusterName:      clusterName,
 		streams:          map[string]*logStream{},
 	}
 
+	if cfg.MaxConcurrentGetLogs > 0 {
+		hub.getLogsSlots = make(chan struct{}, cfg.MaxConcurrentGetLogs)
+	}
+
 	if hub.redisPrefix == "" {
 		hub.redisPrefix = defaultRedisStreamPrefix
 	}
@@ -170,6 +218,12 @@ func newLogStreamHub(handler *KubeHandler) *logStreamHub {
 	if hub.redisLockTTL <= 0 {
 		hub.redisLockTTL = defaultRedisLockTTL
 	}
+	if hub.janitorEvery <= 0 {
+		hub.janitorEvery = defaultRedisJanitorEvery
+	}
+	if hub.publishEvery <= 0 {
+		hub.publishEvery = defaultRedisPublishEvery
+	}
 
 	if cfg.UseRedisStreams {
 		redisURL := cfg.RedisURLOverride
@@ -183,6 +237,7 @@ func newLogStreamHub(handler *KubeHandler) *logStreamHub {
 			} else {
 				hub.redis = client
 				hub.redisEnabled = true
+				hub.startJanitor()
 			}
 		} else {
 			log.Warn("log streams: redis disabled (missing redis_url)")
@@ -193,15 +248,50 @@ func newLogStreamHub(handler *KubeHandler) *logStreamHub {
 }
 
 func (h *logStreamHub) stop() {
+	h.stopStreams()
+	if h.redis != nil {
+		_ = h.redis.Close()
+	}
+}
+
+// shutdown stops every stream and waits until their goroutines have exited,
+// so batched Redis writes are flushed before the client is closed. It gives
+// up when ctx is done.
+func (h *logStreamHub) shutdown(ctx context.Context) error {
+	h.stopStreams()
+	ticker := time.NewTicker(hubDrainPoll)
+	defer ticker.Stop()
+	var err error
+	for h.goroutines.Load() > 0 && err == nil {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	if h.redis != nil {
+		_ = h.redis.Close()
+	}
+	return err
+}
+
+// isStopped reports whether stop or shutdown has begun. Subscribers closed by
+// it should not re-subscribe.
+func (h *logStreamHub) isStopped() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.stopped
+}
+
+func (h *logStreamHub) stopStreams() {
 	h.mu.Lock()
+	h.stopped = true
 	for _, stream := range h.streams {
 		stream.stop()
 	}
 	h.streams = map[string]*logStream{}
 	h.mu.Unlock()
-	if h.redis != nil {
-		_ = h.redis.Close()
-	}
+	h.stopJanitor()
 }
 
 func (h *logStreamHub) Stats() LogStreamStats {
@@ -215,7 +305,15 @@ func (h *logStreamHub) Stats() LogStreamStats {
 	}
 	h.mu.Unlock()
 
-	stats := LogStreamStats{}
+	stats := LogStreamStats{
+		MaxStreams:       h.maxStreams,
+		Goroutines:       h.goroutines.Load(),
+		SSECloses:        h.sse.snapshot(),
+		AppEventsDropped: h.sse.appEventsDropped.Load(),
+	}
+	if h.redisEnabled {
+		stats.RedisOps = h.redisOps.snapshot()
+	}
 	lagTotal := int64(0)
 	lagCount := int64(0)
 	for _, stream := range streams {
@@ -241,6 +339,13 @@ func (h *logStreamHub) Stats() LogStreamStats {
 	return stats
 }
 
+func (h *logStreamHub) trackGoroutine() func() {
+	h.goroutines.Add(1)
+	return func() {
+		h.goroutines.Add(-1)
+	}
+}
+
 func (h *logStreamHub) Status(namespace, pod, container string) (logStreamStatus, bool) {
 	if h == nil {
 		return logStreamStatus{}, false
@@ -255,12 +360,56 @@ func (h *logStreamHub) Status(namespace, pod, container string) (logStreamStatus
 	return stream.statusSnapshot(), true
 }
 
+// Streams lists the status of every worker on this instance, sorted by key.
+func (h *logStreamHub) Streams() []logStreamInfo {
+	if h == nil {
+		return []logStreamInfo{}
+	}
+	h.mu.Lock()
+	streams := make([]*logStream, 0, len(h.streams))
+	for _, stream := range h.streams {
+		streams = append(streams, stream)
+	}
+	h.mu.Unlock()
+
+	items := make([]logStreamInfo, 0, len(streams))
+	for _, stream := range streams {
+		items = append(items, logStreamInfo{
+			Namespace:       stream.namespace,
+			Pod:             stream.pod,
+			Container:       stream.container,
+			logStreamStatus: stream.statusSnapshot(),
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Namespace != items[j].Namespace {
+			return items[i].Namespace < items[j].Namespace
+		}
+		if items[i].Pod != items[j].Pod {
+			return items[i].Pod < items[j].Pod
+		}
+		return items[i].Container < items[j].Container
+	})
+	return items
+}
+
 func (h *logStreamHub) SubscribePod(ctx context.Context, namespace, pod, container string, tail int64, resume logResume) (*logSubscriber, []logEntry, func(), error) {
 	key := h.streamKey(namespace, pod, container)
 
 	h.mu.Lock()
+	if h.stopped {
+		h.mu.Unlock()
+		return nil, nil, nil, errLogHubStopped
+	}
 	stream, ok := h.streams[key]
 	if !ok {
+		if h.maxStreams > 0 && len(h.streams) >= h.maxStreams {
+			h.reapIdleStreamsLocked()
+		}
+		if h.maxStreams > 0 && len(h.streams) >= h.maxStreams {
+			h.mu.Unlock()
+			return nil, nil, nil, errLogStreamLimit
+		}
 		stream = newLogStream(h, namespace, pod, container, resume.sinceTime)
 		h.streams[key] = stream
 	}
@@ -277,6 +426,22 @@ func (h *logStreamHub) SubscribePod(ctx context.Context, namespace, pod, contain
 	return sub, replay, unsubscribe, nil
 }
 
+func (h *logStreamHub) reapIdleStreamsLocked() {
+	for key, stream := range h.streams {
+		if !stream.isIdle() {
+			continue
+		}
+		stream.mu.Lock()
+		if stream.idleTimer != nil {
+			stream.idleTimer.Stop()
+			stream.idleTimer = nil
+		}
+		stream.mu.Unlock()
+		delete(h.streams, key)
+		stream.stop()
+	}
+}
+
 func (h *logStreamHub) streamKey(namespace, pod, container string) string {
 	if container == "" {
 		container = "default"
@@ -315,8 +480,9 @@ func newLogStream(hub *logStreamHub, namespace, pod, container string, startSinc
 
 func (s *logStream) subscribe(ctx context.Context, resume logResume, tail int64) (*logSubscriber, []logEntry) {
 	sub := &logSubscriber{
-		id: fmt.Sprintf("%d", time.Now().UnixNano()),
-		ch: make(chan logEntry, s.hub.subscriberBuffer),
+		id:   fmt.Sprintf("%d", time.Now().UnixNano()),
+		ch:   make(chan logEntry, s.hub.subscriberBuffer),
+		done: make(chan struct{}),
 	}
 
 	s.mu.Lock()
@@ -334,7 +500,12 @@ func (s *logStream) subscribe(ctx context.Context, resume logResume, tail int64)
 	replay := s.replay(ctx, resume, tail)
 
 	go func() {
-		<-ctx.Done()
+		defer s.hub.trackGoroutine()()
+		select {
+		case <-ctx.Done():
+		case <-sub.done:
+			return
+		}
 		s.unsubscribe(sub.id)
 		if s.isIdle() {
 			s.scheduleIdleStop()
@@ -349,6 +520,7 @@ func (s *logStream) unsubscribe(id string) {
 	if sub, ok := s.subscribers[id]; ok {
 		delete(s.subscribers, id)
 		close(sub.ch)
+		close(sub.done)
 	}
 	s.mu.Unlock()
 }
@@ -376,13 +548,24 @@ func (s *logStream) scheduleIdleStop() {
 	})
 }
 
+// stop ends the stream and closes any remaining subscribers, so their
+// consumers see the source end and can re-subscribe to a fresh stream
+// instead of waiting on one that will never deliver again.
 func (s *logStream) stop() {
 	s.stopOnce.Do(func() {
 		s.cancel()
+		s.mu.Lock()
+		for id, sub := range s.subscribers {
+			delete(s.subscribers, id)
+			close(sub.ch)
+			close(sub.done)
+		}
+		s.mu.Unlock()
 	})
 }
 
 func (s *logStream) run() {
+	defer s.hub.trackGoroutine()()
 	if !s.hub.redisEnabled {
 		s.startK8s()
 		<-s.ctx.Done()
@@ -392,11 +575,12 @@ func (s *logStream) run() {
 
 	if s.tryAcquireLock() {
 		s.leader = true
+		s.persistRedisStream()
 		s.startK8s()
 	} else {
 		s.startRedisConsumer()
 	}
-	lockTicker := time.NewTicker(s.lockRefreshInterval())
+	lockTicker := time.NewTicker(s.hub.lockRefreshInterval())
 	defer lockTicker.Stop()
 
 	for {
@@ -416,6 +600,7 @@ func (s *logStream) run() {
 			} else {
 				if s.tryAcquireLock() {
 					s.leader = true
+					s.persistRedisStream()
 					s.stopRedisConsumer()
 					s.startK8s()
 				}
@@ -424,11 +609,13 @@ func (s *logStream) run() {
 	}
 }
 
-func (s *logStream) lockRefreshInterval() time.Duration {
-	if s.hub.redisLockTTL <= 0 {
+// lockRefreshInterval is how often leaders renew their locks and replicas
+// refresh their heartbeat.
+func (h *logStreamHub) lockRefreshInterval() time.Duration {
+	if h.redisLockTTL <= 0 {
 		return 5 * time.Second
 	}
-	interval := s.hub.redisLockTTL / 2
+	interval := h.redisLockTTL / 2
 	if interval < 2*time.Second {
 		return 2 * time.Second
 	}
@@ -468,7 +655,13 @@ func (s *logStream) stopRedisConsumer() {
 }
 
 func (s *logStream) consumeK8s(ctx context.Context) {
+	defer s.hub.trackGoroutine()()
+	pub := s.startRedisPublisher()
+	if pub != nil {
+		defer pub.close()
+	}
 	backoff := time.Second
+	var cursor k8sCursor
 	for {
 		select {
 		case <-ctx.Done():
@@ -476,6 +669,8 @@ func (s *logStream) consumeK8s(ctx context.Context) {
 		default:
 		}
 
+		// LimitBytes is left off: the apiserver ends a follow stream at the
+		// limit, and the stream would only reconnect.
 		opts := &corev1.PodLogOptions{
 			Follow:     true,
 			Timestamps: true,
@@ -485,29 +680,140 @@ func (s *logStream) consumeK8s(ctx context.Context) {
 		if s.startSince != nil {
 			opts.SinceTime = &metav1.Time{Time: s.startSince.UTC()}
 		}
+		cursor.resume(opts)
 
+		if !s.acquireGetLogsSlot(ctx) {
+			return
+		}
 		stream, err := s.handler.client.CoreV1().Pods(s.namespace).GetLogs(s.pod, opts).Stream(ctx)
 		if err != nil {
-			time.Sleep(backoff)
-			if backoff < 10*time.Second {
-				backoff *= 2
+			s.releaseGetLogsSlot()
+			if !sleepOrDone(ctx, backoff) {
+				return
 			}
+			backoff = min(backoff*2, 10*time.Second)
 			continue
 		}
-		backoff = time.Second
 
+		ingested := 0
 		reader := bufio.NewReader(stream)
 		for {
 			line, err := reader.ReadString('\n')
 			if err != nil {
 				s.reconnects.Add(1)
 				_ = stream.Close()
+				s.releaseGetLogsSlot()
 				break
 			}
 			entry := s.handler.parseLogLine(strings.TrimRight(line, "\n"), s.pod, s.container)
-			s.ingestK8sEntry(ctx, entry)
+			if cursor.seen(entry) {
+				continue
+			}
+			ingested++
+			s.ingestK8sEntry(ctx, pub, entry)
+		}
+
+		// A stream that ends without new lines, such as one for a terminated
+		// container, backs off instead of reopening in a tight loop.
+		if ingested > 0 {
+			backoff = time.Second
+			continue
 		}
+		if !sleepOrDone(ctx, backoff) {
+			return
+		}
+		backoff = min(backoff*2, 10*time.Second)
+	}
+}
+
+// k8sCursor is the last line a follow stream ingested from Kubernetes. A
+// reconnect resumes from its timestamp instead of re-reading the tail.
+// SinceTime is sent with second precision, so the reopened stream starts at
+// the top of that second; seen skips what was already ingested up to and
+// including the cursor, counting lines that share its timestamp.
+type k8sCursor struct {
+	last   time.Time
+	atLast int
+	skip   int
+	valid  bool
+}
+
+func (c *k8sCursor) resume(opts *corev1.PodLogOptions) {
+	if !c.valid {
+		return
 	}
+	opts.TailLines = nil
+	opts.SinceTime = &metav1.Time{Time: c.last}
+	c.skip = c.atLast
+}
+
+func (c *k8sCursor) seen(entry logEntry) bool {
+	ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+	if err != nil {
+		return false
+	}
+	switch {
+	case !c.valid || ts.After(c.last):
+		c.last, c.atLast, c.skip, c.valid = ts, 1, 0, true
+	case ts.Before(c.last):
+		return true
+	case c.skip > 0:
+		c.skip--
+		return true
+	default:
+		c.atLast++
+	}
+	return false
+}
+
+// sleepOrDone waits for d and reports false if ctx ends first.
+func sleepOrDone(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s *logStream) acquireGetLogsSlot(ctx context.Context) bool {
+	slots := s.hub.getLogsSlots
+	if slots == nil {
+		return true
+	}
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+	}
+	s.queued.Store(true)
+	defer s.queued.Store(false)
+	return s.hub.acquireGetLogsSlot(ctx)
+}
+
+func (s *logStream) releaseGetLogsSlot() {
+	s.hub.releaseGetLogsSlot()
+}
+
+func (h *logStreamHub) acquireGetLogsSlot(ctx context.Context) bool {
+	if h.getLogsSlots == nil {
+		return true
+	}
+	select {
+	case h.getLogsSlots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (h *logStreamHub) releaseGetLogsSlot() {
+	if h.getLogsSlots == nil {
+		return
+	}
+	<-h.getLogsSlots
 }
 
 func (s *logStream) k8sTailLines() *int64 {
@@ -521,11 +827,15 @@ func (s *logStream) k8sTailLines() *int64 {
 	return &tail
 }
 
-func (s *logStream) ingestK8sEntry(ctx context.Context, entry logEntry) {
+func (s *logStream) ingestK8sEntry(ctx context.Context, pub *redisPublisher, entry logEntry) {
 	seq := s.seq.Add(1)
 	entry.Seq = seq
 	entry.ID = strconv.FormatUint(seq, 10)
 	s.lastEventAt.Store(time.Now().UTC().UnixNano())
+	if pub != nil {
+		pub.publish(ctx, entry)
+		return
+	}
 	if s.hub.redisEnabled {
 		id, err := s.addRedisEntry(ctx, entry)
 		if err != nil {
@@ -539,6 +849,7 @@ func (s *logStream) ingestK8sEntry(ctx context.Context, entry logEntry) {
 }
 
 func (s *logStream) consumeRedis(ctx context.Context) {
+	defer s.hub.trackGoroutine()()
 	prefill, lastID, err := s.fetchRedisTail(ctx, s.hub.bufferLines)
 	if err == nil {
 		for _, entry := range prefill {
@@ -562,7 +873,9 @@ func (s *logStream) consumeRedis(ctx context.Context) {
 			Streams: []string{s.redisKey, s.lastRedisID},
 			Block:   s.hub.redisBlock,
 		}
+		start := time.Now()
 		res, err := s.hub.redis.XRead(ctx, args).Result()
+		s.hub.redisOps.observe(redisOpXRead, start, err)
 		if err != nil && !errors.Is(err, context.Canceled) && err != redis.Nil {
 			s.reconnects.Add(1)
 			time.Sleep(time.Second)
@@ -587,6 +900,13 @@ func (s *logStream) consumeRedis(ctx context.Context) {
 }
 
 func (s *logStream) addRedisEntry(ctx context.Context, entry logEntry) (string, error) {
+	start := time.Now()
+	id, err := s.hub.redis.XAdd(ctx, s.redisAddArgs(entry)).Result()
+	s.hub.redisOps.observe(redisOpXAdd, start, err)
+	return id, err
+}
+
+func (s *logStream) redisAddArgs(entry logEntry) *redis.XAddArgs {
 	args := &redis.XAddArgs{
 		Stream: s.redisKey,
 		Values: map[string]any{
@@ -601,14 +921,16 @@ func (s *logStream) addRedisEntry(ctx context.Context, entry logEntry) (string,
 		args.MaxLen = s.hub.redisMaxLen
 		args.Approx = true
 	}
-	return s.hub.redis.XAdd(ctx, args).Result()
+	return args
 }
 
 func (s *logStream) fetchRedisTail(ctx context.Context, count int) ([]logEntry, string, error) {
 	if !s.hub.redisEnabled || count <= 0 {
 		return nil, "", nil
 	}
+	start := time.Now()
 	msgs, err := s.hub.redis.XRevRangeN(ctx, s.redisKey, "+", "-", int64(count)).Result()
+	s.hub.redisOps.observe(redisOpXRange, start, err)
 	if err != nil && err != redis.Nil {
 		return nil, "", err
 	}
@@ -627,6 +949,8 @@ func (s *logStream) fetchRedisTail(ctx context.Context, count int) ([]logEntry,
 	return entries, msgs[0].ID, nil
 }
 
+// fetchRedisSince returns up to count of the newest entries after sinceID,
+// so a capped resume stays contiguous with the live stream.
 func (s *logStream) fetchRedisSince(ctx context.Context, sinceID string, count int) ([]logEntry, string, error) {
 	if !s.hub.redisEnabled {
 		return nil, "", nil
@@ -637,7 +961,9 @@ func (s *logStream) fetchRedisSince(ctx context.Context, sinceID string, count i
 	if count <= 0 {
 		count = s.hub.bufferLines
 	}
-	msgs, err := s.hub.redis.XRangeN(ctx, s.redisKey, sinceID, "+", int64(count)).Result()
+	start := time.Now()
+	msgs, err := s.hub.redis.XRevRangeN(ctx, s.redisKey, "+", sinceID, int64(count)+1).Result()
+	s.hub.redisOps.observe(redisOpXRange, start, err)
 	if err != nil && err != redis.Nil {
 		return nil, "", err
 	}
@@ -645,7 +971,8 @@ func (s *logStream) fetchRedisSince(ctx context.Context, sinceID string, count i
 		return nil, "", nil
 	}
 	entries := make([]logEntry, 0, len(msgs))
-	for _, msg := range msgs {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		msg := msgs[i]
 		if msg.ID == sinceID {
 			continue
 		}
@@ -655,32 +982,51 @@ func (s *logStream) fetchRedisSince(ctx context.Context, sinceID string, count i
 		}
 		entries = append(entries, entry)
 	}
-	return entries, msgs[len(msgs)-1].ID, nil
+	if len(entries) > count {
+		entries = entries[len(entries)-count:]
+	}
+	return entries, msgs[0].ID, nil
 }
 
+// replay returns the backlog for a new subscriber. Resume and tail paths
+// are all capped at replayLimit so an old since/since_id cannot pull the
+// whole buffer or Redis stream in one reconnect.
 func (s *logStream) replay(ctx context.Context, resume logResume, tail int64) []logEntry {
+	limit := s.replayLimit()
 	if resume.sinceID != "" {
 		if entries, ok := s.buffer.sinceID(resume.sinceID); ok {
-			return entries
+			return capReplay(entries, limit)
 		}
 		if s.hub.redisEnabled && isRedisID(resume.sinceID) {
-			if entries, _, err := s.fetchRedisSince(ctx, resume.sinceID, int(tail)); err == nil && len(entries) > 0 {
+			entries, _, err := s.fetchRedisSince(ctx, resume.sinceID, limit)
+			if err == nil && len(entries) > 0 {
 				return entries
 			}
+			if err != nil {
+				since := redisIDTime(resume.sinceID)
+				return s.replayFromK8s(ctx, &since, limit, err)
+			}
 		}
 	}
 	if resume.sinceTime != nil {
 		entries := s.buffer.sinceTime(*resume.sinceTime)
 		if len(entries) > 0 {
-			return entries
+			return capReplay(entries, limit)
 		}
 		if s.hub.redisEnabled {
 			startID := redisIDFromTime(*resume.sinceTime)
-			if entries, _, err := s.fetchRedisSince(ctx, startID, int(tail)); err == nil && len(entries) > 0 {
+			entries, _, err := s.fetchRedisSince(ctx, startID, limit)
+			if err == nil && len(entries) > 0 {
 				return entries
 			}
+			if err != nil {
+				return s.replayFromK8s(ctx, resume.sinceTime, limit, err)
+			}
 		}
 	}
+	if limit > 0 && tail > int64(limit) {
+		tail = int64(limit)
+	}
 	if tail > 0 {
 		entries := s.buffer.tail(int(tail))
 		if len(entries) > 0 {
@@ -691,11 +1037,77 @@ func (s *logStream) replay(ctx context.Context, resume logResume, tail int64) []
 			if err == nil && len(entries) > 0 {
 				return entries
 			}
+			if err != nil {
+				return s.replayFromK8s(ctx, nil, int(tail), err)
+			}
 		}
 	}
 	return nil
 }
 
+// replayFromK8s fills a replay with a bounded non-follow GetLogs when Redis
+// errored, so reconnecting clients still get history during a Redis hiccup.
+// The lines carry no Redis IDs; clients fall back to timestamps for resume.
+func (s *logStream) replayFromK8s(ctx context.Context, since *time.Time, limit int, redisErr error) []logEntry {
+	if limit <= 0 || s.handler.client == nil {
+		return nil
+	}
+	log.Warn("log stream redis replay failed; reading from kubernetes", "stream", s.key, "err", redisErr)
+	ctx, cancel := context.WithTimeout(ctx, replayFallbackTimeout)
+	defer cancel()
+	if !s.hub.acquireGetLogsSlot(ctx) {
+		return nil
+	}
+	defer s.hub.releaseGetLogsSlot()
+
+	tail := int64(limit)
+	opts := &corev1.PodLogOptions{
+		Timestamps: true,
+		TailLines:  &tail,
+		Container:  s.container,
+		LimitBytes: s.handler.logLimitBytes(),
+	}
+	if since != nil {
+		opts.SinceTime = &metav1.Time{Time: since.UTC()}
+	}
+	stream, err := s.handler.client.CoreV1().Pods(s.namespace).GetLogs(s.pod, opts).Stream(ctx)
+	if err != nil {
+		log.Warn("log stream replay fallback failed", "stream", s.key, "err", err)
+		return nil
+	}
+	defer stream.Close()
+
+	entries := []logEntry{}
+	reader := bufio.NewReader(stream)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			entries = append(entries, s.handler.parseLogLine(strings.TrimRight(line, "\n"), s.pod, s.container))
+		}
+		if err != nil {
+			break
+		}
+	}
+	return capReplay(entries, limit)
+}
+
+func (s *logStream) replayLimit() int {
+	if limit := s.handler.cfg.Logs.MaxReplayLines; limit > 0 {
+		return limit
+	}
+	if limit := s.handler.cfg.Logs.MaxTailLines; limit > 0 {
+		return limit
+	}
+	return s.hub.bufferLines
+}
+
+func capReplay(entries []logEntry, limit int) []logEntry {
+	if limit <= 0 || len(entries) <= limit {
+		return entries
+	}
+	return entries[len(entries)-limit:]
+}
+
 type streamSnapshot struct {
 	subscribers   int
 	dropped       int64
@@ -746,23 +1158,29 @@ func (s *logStream) statusSnapshot() logStreamStatus {
 		lagMs = time.Since(lastTime).Milliseconds()
 	}
 	role := "single"
+	owner := ""
 	if s.hub.redisEnabled {
 		if s.leader {
 			role = "leader"
+			owner = s.hub.instanceID
 		} else {
 			role = "follower"
+			owner, _ = s.lockOwner.Load().(string)
 		}
 	}
 	return logStreamStatus{
 		Role:          role,
 		RedisEnabled:  s.hub.redisEnabled,
 		Leader:        s.leader,
+		Instance:      s.hub.instanceID,
+		LockOwner:     owner,
 		Reconnects:    s.reconnects.Load(),
 		LastEventAt:   lastAt,
 		LagMillis:     lagMs,
 		Subscribers:   subs,
 		BufferedLines: lines,
 		BufferBytes:   bytes,
+		Queued:        s.queued.Load(),
 	}
 }
 
@@ -782,10 +1200,20 @@ func (s *logStream) tryAcquireLock() bool {
 	if !s.hub.redisEnabled {
 		return false
 	}
+	start := time.Now()
 	ok, err := s.hub.redis.SetNX(context.Background(), s.lockKey, s.lockValue, s.hub.redisLockTTL).Result()
+	s.hub.redisOps.observe(redisOpLock, start, err)
 	if err != nil {
 		return false
 	}
+	if !ok {
+		// The lock value is the leader's instance ID; remember it for status.
+		owner, err := s.hub.redis.Get(context.Background(), s.lockKey).Result()
+		if err != nil {
+			owner = ""
+		}
+		s.lockOwner.Store(owner)
+	}
 	return ok
 }
 
@@ -794,7 +1222,9 @@ func (s *logStream) refreshLock() bool {
 		return false
 	}
 	script := redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) else return 0 end`)
+	start := time.Now()
 	res, err := script.Run(context.Background(), s.hub.redis, []string{s.lockKey}, s.lockValue, int64(s.hub.redisLockTTL/time.Millisecond)).Result()
+	s.hub.redisOps.observe(redisOpLock, start, err)
 	if err != nil {
 		return false
 	}
@@ -807,7 +1237,9 @@ func (s *logStream) releaseLock() {
 		return
 	}
 	script := redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`)
-	_, _ = script.Run(context.Background(), s.hub.redis, []string{s.lockKey}, s.lockValue).Result()
+	start := time.Now()
+	_, err := script.Run(context.Background(), s.hub.redis, []string{s.lockKey}, s.lockValue).Result()
+	s.hub.redisOps.observe(redisOpLock, start, err)
 }
 
 func (b *logBuffer) append(entry logEntry) {
@@ -928,6 +1360,16 @@ func randomID() string {
 	return hex.EncodeToString(buf)
 }
 
+// newInstanceID prefixes a random ID with the hostname (the pod name in
+// Kubernetes) so lock owners can be traced back to a replica.
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return randomID()
+	}
+	return host + "-" + randomID()
+}
+
 func isRedisID(id string) bool {
 	if id == "" {
 		return false
@@ -945,6 +1387,12 @@ func isRedisID(id string) bool {
 	return true
 }
 
+// redisIDTime returns the millisecond timestamp encoded in a Redis stream ID.
+func redisIDTime(id string) time.Time {
+	ms, _ := strconv.ParseInt(strings.SplitN(id, "-", 2)[0], 10, 64)
+	return time.UnixMilli(ms).UTC()
+}
+
 func redisIDFromTime(t time.Time) string {
 	ms := t.UnixNano() / int64(time.Millisecond)
 	if ms < 0 {
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	restclient "k8s.io/client-go/rest"
+	fakerest "k8s.io/client-go/rest/fake"
+)
+
+// waitForIdleHub polls until the hub has no workers and its tracked
+// goroutines are back to baseline.
+func waitForIdleHub(t *testing.T, hub *logStreamHub, baseline int64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(hub.Streams()) != 0 || hub.goroutines.Load() != baseline {
+		if time.Now().After(deadline) {
+			t.Fatalf("streams = %d, tracked goroutines = %d, want 0 and %d", len(hub.Streams()), hub.goroutines.Load(), baseline)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestLogStreamHubConnectionChurn opens and drops 1000 subscriptions, the way
+// clients that vanish mid-stream do, and checks that every stream goroutine
+// exits once the idle worker is stopped.
+func TestLogStreamHubConnectionChurn(t *testing.T) {
+	h, _ := newTestKubeHandler(t, nil, testPod("web-1", nil))
+	hub := h.logHub
+	hub.idleTTL = 10 * time.Millisecond
+	baseline := hub.goroutines.Load()
+
+	for i := range 1000 {
+		ctx, cancel := context.WithCancel(t.Context())
+		_, _, unsubscribe, err := hub.SubscribePod(ctx, testNamespace, "web-1", "app", 0, logResume{})
+		if err != nil {
+			t.Fatalf("subscribe %d: %v", i, err)
+		}
+		if i%2 == 0 {
+			cancel()
+		} else {
+			unsubscribe()
+			cancel()
+		}
+	}
+
+	waitForIdleHub(t, hub, baseline)
+}
+
+// TestLogStreamSubscribeWatcherExits checks that a subscription's context
+// watcher exits when the subscription ends some other way, while the parent
+// context stays live.
+func TestLogStreamSubscribeWatcherExits(t *testing.T) {
+	tests := []struct {
+		name string
+		end  func(hub *logStreamHub, unsubscribes []func())
+	}{
+		{"explicit unsubscribe", func(_ *logStreamHub, unsubscribes []func()) {
+			for _, unsubscribe := range unsubscribes {
+				unsubscribe()
+			}
+		}},
+		{"hub stop", func(hub *logStreamHub, _ []func()) {
+			hub.stopStreams()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestKubeHandler(t, nil, testPod("web-1", nil))
+			hub := h.logHub
+			hub.idleTTL = 10 * time.Millisecond
+			baseline := hub.goroutines.Load()
+
+			parent := t.Context()
+			unsubscribes := make([]func(), 0, 100)
+			for i := range 100 {
+				_, _, unsubscribe, err := hub.SubscribePod(parent, testNamespace, "web-1", "app", 0, logResume{})
+				if err != nil {
+					t.Fatalf("subscribe %d: %v", i, err)
+				}
+				unsubscribes = append(unsubscribes, unsubscribe)
+			}
+			tt.end(hub, unsubscribes)
+
+			if parent.Err() != nil {
+				t.Fatal("parent context ended early")
+			}
+			waitForIdleHub(t, hub, baseline)
+		})
+	}
+}
+
+// podLogServer plays a pod's log for GetLogs the way the apiserver would:
+// SinceTime is honored at second precision, then TailLines, and each call
+// ends at the end of what has been written so far.
+type podLogServer struct {
+	mu    sync.Mutex
+	lines []string
+	calls []corev1.PodLogOptions
+	// onCall runs after each call is recorded, to write more lines.
+	onCall func(call int)
+}
+
+func (p *podLogServer) body(opts *corev1.PodLogOptions) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, *opts)
+	lines := p.lines
+	if opts.SinceTime != nil {
+		since := opts.SinceTime.Time.Truncate(time.Second)
+		kept := []string{}
+		for _, line := range lines {
+			ts, _, _ := strings.Cut(line, " ")
+			if parsed, err := time.Parse(time.RFC3339Nano, ts); err == nil && !parsed.Before(since) {
+				kept = append(kept, line)
+			}
+		}
+		lines = kept
+	}
+	if opts.TailLines != nil && int(*opts.TailLines) < len(lines) {
+		lines = lines[len(lines)-int(*opts.TailLines):]
+	}
+	var body strings.Builder
+	for _, line := range lines {
+		body.WriteString(line + "\n")
+	}
+	if p.onCall != nil {
+		p.onCall(len(p.calls))
+	}
+	return body.String()
+}
+
+func (p *podLogServer) options() []corev1.PodLogOptions {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]corev1.PodLogOptions(nil), p.calls...)
+}
+
+// podLogClientset serves GetLogs from a podLogServer; the fake clientset
+// always answers "fake logs".
+type podLogClientset struct {
+	*fake.Clientset
+	logs *podLogServer
+}
+
+func (c podLogClientset) CoreV1() corev1client.CoreV1Interface {
+	return podLogCoreV1{CoreV1Interface: c.Clientset.CoreV1(), logs: c.logs}
+}
+
+type podLogCoreV1 struct {
+	corev1client.CoreV1Interface
+	logs *podLogServer
+}
+
+func (c podLogCoreV1) Pods(namespace string) corev1client.PodInterface {
+	return podLogPods{PodInterface: c.CoreV1Interface.Pods(namespace), namespace: namespace, logs: c.logs}
+}
+
+type podLogPods struct {
+	corev1client.PodInterface
+	namespace string
+	logs      *podLogServer
+}
+
+func (p podLogPods) GetLogs(name string, opts *corev1.PodLogOptions) *restclient.Request {
+	client := &fakerest.RESTClient{
+		Client: fakerest.CreateHTTPClient(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(p.logs.body(opts)))}, nil
+		}),
+		NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		GroupVersion:         corev1.SchemeGroupVersion,
+		VersionedAPIPath:     fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/log", p.namespace, name),
+	}
+	return client.Request()
+}
+
+var _ kubernetes.Interface = podLogClientset{}
+
+// TestLogStreamFollowReconnectResumes ends the follow stream after every
+// read, as a dropped connection would, and checks that each reconnect resumes
+// after the last ingested line instead of re-reading the tail.
+func TestLogStreamFollowReconnectResumes(t *testing.T) {
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	line := func(offset time.Duration, message string) string {
+		return base.Add(offset).Format(time.RFC3339Nano) + " " + message
+	}
+	logs := &podLogServer{lines: []string{
+		line(100*time.Millisecond, "a"),
+		line(200*time.Millisecond, "b"),
+		line(200*time.Millisecond, "c"),
+	}}
+	logs.onCall = func(call int) {
+		// Lines written while the stream was reconnecting: one in the same
+		// second as the cursor, sharing its timestamp, and one after it.
+		if call == 1 {
+			logs.lines = append(logs.lines, line(200*time.Millisecond, "d"), line(1500*time.Millisecond, "e"))
+		}
+	}
+
+	cfg := testConfig(t)
+	cfg.Logs.MaxBytesPerRequest = 1 << 20
+	h := NewKubeHandler(cfg, podLogClientset{Clientset: fake.NewClientset(), logs: logs}, nil)
+	h.raw = emptyRawClient{}
+	t.Cleanup(h.Stop)
+
+	sub, _, unsubscribe, err := h.logHub.SubscribePod(t.Context(), testNamespace, "web-1", "app", 0, logResume{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unsubscribe()
+
+	var got []string
+	timeout := time.After(3 * time.Second)
+	for len(logs.options()) < 3 {
+		select {
+		case entry := <-sub.ch:
+			got = append(got, entry.Message)
+		case <-timeout:
+			t.Fatalf("only %d GetLogs calls, got %q", len(logs.options()), got)
+		}
+	}
+	// Drain what the third call may still be ingesting.
+	drain := time.After(100 * time.Millisecond)
+	for done := false; !done; {
+		select {
+		case entry := <-sub.ch:
+			got = append(got, entry.Message)
+		case <-drain:
+			done = true
+		}
+	}
+	if want := []string{"a", "b", "c", "d", "e"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("ingested %d lines %q, want %q", len(got), got[:min(len(got), 10)], want)
+	}
+
+	for i, opts := range logs.options() {
+		if opts.LimitBytes != nil {
+			t.Errorf("call %d: LimitBytes = %d on a follow stream", i, *opts.LimitBytes)
+		}
+		if i == 0 {
+			continue
+		}
+		if opts.TailLines != nil {
+			t.Errorf("call %d: TailLines = %d on a reconnect", i, *opts.TailLines)
+		}
+		if opts.SinceTime == nil {
+			t.Errorf("call %d: reconnect without SinceTime", i)
+		}
+	}
+}
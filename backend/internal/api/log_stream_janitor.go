@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisJanitorScanCount = 200
+	redisJanitorTimeout   = 30 * time.Second
+	redisInstanceKeyInfix = ":instances:"
+)
+
+// expireOrphanStreamScript sets a TTL on a stream only while no instance holds
+// its lock, so a leader that acquires the lock concurrently is never expired.
+var expireOrphanStreamScript = redis.NewScript(`if redis.call("EXISTS", KEYS[2]) == 0 and redis.call("TTL", KEYS[1]) == -1 then return redis.call("EXPIRE", KEYS[1], ARGV[1]) else return 0 end`)
+
+// deleteDeadLockScript removes a lock only if it still belongs to the owner we
+// saw and that owner's heartbeat key is gone.
+var deleteDeadLockScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] and redis.call("EXISTS", KEYS[2]) == 0 then return redis.call("DEL", KEYS[1]) else return 0 end`)
+
+// startJanitor publishes this instance's heartbeat on the lock renewal cadence
+// and periodically expires orphaned streams and locks left behind by crashed
+// instances.
+func (h *logStreamHub) startJanitor() {
+	if !h.redisEnabled || h.janitorStop != nil {
+		return
+	}
+	h.janitorStop = make(chan struct{})
+	h.heartbeat(context.Background())
+	heartbeatTicker := time.NewTicker(h.lockRefreshInterval())
+	ticker := time.NewTicker(h.janitorEvery)
+	go func() {
+		defer h.trackGoroutine()()
+		defer heartbeatTicker.Stop()
+		defer ticker.Stop()
+		for {
+			select {
+			case <-heartbeatTicker.C:
+				h.heartbeat(context.Background())
+			case <-ticker.C:
+				h.sweepRedis()
+			case <-h.janitorStop:
+				return
+			}
+		}
+	}()
+}
+
+func (h *logStreamHub) stopJanitor() {
+	if h.janitorStop == nil {
+		return
+	}
+	close(h.janitorStop)
+	h.janitorStop = nil
+	_ = h.redis.Del(context.Background(), h.instanceKey(h.instanceID)).Err()
+}
+
+func (h *logStreamHub) instanceKey(id string) string {
+	return h.redisPrefix + redisInstanceKeyInfix + id
+}
+
+// heartbeat expires after one lock TTL, so a crashed instance's heartbeat lapses
+// on the same timescale as its locks rather than minutes later.
+func (h *logStreamHub) heartbeat(ctx context.Context) {
+	ttl := h.redisLockTTL
+	if refresh := h.lockRefreshInterval(); ttl <= refresh {
+		ttl = 2 * refresh
+	}
+	if err := h.redis.Set(ctx, h.instanceKey(h.instanceID), time.Now().UTC().Format(time.RFC3339), ttl).Err(); err != nil {
+		log.Warn("log stream janitor: heartbeat failed", "err", err)
+	}
+}
+
+// sweepRedis walks this cluster's keys with SCAN. Streams without a lock have
+// no subscribers on any instance and are left to expire after one janitor
+// interval; locks held by instances without a heartbeat are deleted.
+func (h *logStreamHub) sweepRedis() {
+	ctx, cancel := context.WithTimeout(context.Background(), redisJanitorTimeout)
+	defer cancel()
+
+	match := h.redisPrefix + ":" + h.clusterName + ":*"
+	ttlSeconds := int64(h.janitorEvery / time.Second)
+	if ttlSeconds <= 0 {
+		ttlSeconds = 1
+	}
+	expired, cleared := 0, 0
+	var cursor uint64
+	for {
+		keys, next, err := h.redis.Scan(ctx, cursor, match, redisJanitorScanCount).Result()
+		if err != nil {
+			log.Warn("log stream janitor: scan failed", "err", err)
+			return
+		}
+		for _, key := range keys {
+			if strings.HasSuffix(key, defaultRedisLockKeySuffix) {
+				if h.clearDeadLock(ctx, key) {
+					cleared++
+				}
+				continue
+			}
+			res, err := expireOrphanStreamScript.Run(ctx, h.redis, []string{key, key + defaultRedisLockKeySuffix}, ttlSeconds).Int64()
+			if err == nil && res > 0 {
+				expired++
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+	if expired > 0 || cleared > 0 {
+		log.Info("log stream janitor", "expired_streams", expired, "cleared_locks", cleared)
+	}
+}
+
+func (h *logStreamHub) clearDeadLock(ctx context.Context, key string) bool {
+	owner, err := h.redis.Get(ctx, key).Result()
+	if err != nil || owner == "" || owner == h.instanceID {
+		return false
+	}
+	res, err := deleteDeadLockScript.Run(ctx, h.redis, []string{key, h.instanceKey(owner)}, owner).Int64()
+	return err == nil && res > 0
+}
+
+// persistRedisStream drops a TTL the janitor may have set while the stream
+// had no leader.
+func (s *logStream) persistRedisStream() {
+	if !s.hub.redisEnabled {
+		return
+	}
+	_ = s.hub.redis.Persist(context.Background(), s.redisKey).Err()
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisPublishQueueFactor = 4
+	redisPublishFlushWait   = 5 * time.Second
+)
+
+// redisPublisher batches a leader's ingested lines into pipelined XADDs so a
+// busy pod does not wait on one Redis round-trip per line. Its queue is
+// bounded: when Redis falls behind, the Kubernetes reader blocks instead of
+// buffering without limit.
+type redisPublisher struct {
+	stream  *logStream
+	entries chan logEntry
+	done    chan struct{}
+}
+
+// startRedisPublisher returns nil when batching is disabled, in which case
+// lines are published synchronously one XADD at a time.
+func (s *logStream) startRedisPublisher() *redisPublisher {
+	if !s.hub.redisEnabled || s.hub.publishBatch <= 1 {
+		return nil
+	}
+	p := &redisPublisher{
+		stream:  s,
+		entries: make(chan logEntry, s.hub.publishBatch*redisPublishQueueFactor),
+		done:    make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *redisPublisher) publish(ctx context.Context, entry logEntry) {
+	select {
+	case p.entries <- entry:
+	case <-ctx.Done():
+	}
+}
+
+// close flushes queued lines and waits for the publisher to exit.
+func (p *redisPublisher) close() {
+	close(p.entries)
+	<-p.done
+}
+
+func (p *redisPublisher) run() {
+	defer p.stream.hub.trackGoroutine()()
+	defer close(p.done)
+	size := p.stream.hub.publishBatch
+	batch := make([]logEntry, 0, size)
+	timer := time.NewTimer(p.stream.hub.publishEvery)
+	timer.Stop()
+	for {
+		select {
+		case entry, ok := <-p.entries:
+			if !ok {
+				p.flush(batch)
+				return
+			}
+			if len(batch) == 0 {
+				timer.Reset(p.stream.hub.publishEvery)
+			}
+			batch = append(batch, entry)
+			if len(batch) >= size {
+				timer.Stop()
+				p.flush(batch)
+				batch = batch[:0]
+			}
+		case <-timer.C:
+			p.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// flush writes the batch in one pipeline, then buffers and broadcasts each
+// line in order with its Redis ID so resume via since_id keeps working. Lines
+// whose XADD failed keep their sequence ID, as in the synchronous path.
+func (p *redisPublisher) flush(batch []logEntry) {
+	if len(batch) == 0 {
+		return
+	}
+	s := p.stream
+	ctx, cancel := context.WithTimeout(context.Background(), redisPublishFlushWait)
+	defer cancel()
+
+	pipe := s.hub.redis.Pipeline()
+	cmds := make([]*redis.StringCmd, len(batch))
+	for i, entry := range batch {
+		cmds[i] = pipe.XAdd(ctx, s.redisAddArgs(entry))
+	}
+	start := time.Now()
+	_, err := pipe.Exec(ctx)
+	s.hub.redisOps.observe(redisOpXAdd, start, err)
+	if err != nil {
+		log.Warn("log stream redis pipeline add failed", "err", err, "lines", len(batch))
+	}
+	for i, entry := range batch {
+		if id, err := cmds[i].Result(); err == nil {
+			entry.ID = id
+		}
+		s.buffer.append(entry)
+		s.broadcast(entry)
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const defaultLogPrefixFormat = "[{pod}/{container}] "
+
+func wantsPlainText(r *http.Request) bool {
+	if strings.EqualFold(r.URL.Query().Get("format"), "text") {
+		return true
+	}
+	return strings.HasPrefix(strings.ToLower(r.Header.Get("Accept")), "text/plain")
+}
+
+func wantsLogPrefix(r *http.Request) bool {
+	return strings.EqualFold(r.URL.Query().Get("prefix"), "true")
+}
+
+func setPlainTextHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+}
+
+func formatLogPrefix(format string, entry logEntry) string {
+	if format == "" {
+		format = defaultLogPrefixFormat
+	}
+	return strings.NewReplacer(
+		"{pod}", entry.PodName,
+		"{container}", entry.ContainerName,
+		"{timestamp}", entry.Timestamp,
+	).Replace(format)
+}
+
+func (h *KubeHandler) writeTextEvent(w http.ResponseWriter, event sseEvent, prefix bool) error {
+	if event.Event != "log" {
+		return nil
+	}
+	var entry logEntry
+	if err := json.Unmarshal(event.Data, &entry); err != nil {
+		return nil
+	}
+	var b strings.Builder
+	if prefix {
+		b.WriteString(formatLogPrefix(h.cfg.Logs.PrefixFormat, entry))
+	}
+	b.WriteString(entry.Message)
+	b.WriteByte('\n')
+	_, err := w.Write([]byte(b.String()))
+	return err
+}
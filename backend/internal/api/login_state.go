@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/halceonio/kubelens/backend/internal/storage"
+)
+
+// loginStatePrefix namespaces login states in the session store, whose other
+// keys are user IDs.
+const loginStatePrefix = "login_state:"
+
+// loginStateCookie binds an issued state to the browser that asked for it.
+// Only a hash of its value is stored with the state, and /auth/token requires
+// the cookie, so a state minted by someone else cannot complete a login here.
+const loginStateCookie = "kubelens_login_state"
+
+var errInvalidLoginState = errors.New("invalid or expired login state")
+
+type loginStateResponse struct {
+	State     string    `json:"state"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// StateHandler issues a one-time OAuth state for auth.stateful_login. The SPA
+// sends it to Keycloak and back to /auth/token, which rejects codes whose
+// state this server did not issue to the same browser. It shares the token
+// endpoint's per-IP limit, since each call writes to the session store.
+func (h *AuthHandler) StateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethods(w, r, http.MethodPost) {
+			return
+		}
+		cfg := h.getConfig()
+		if cfg == nil {
+			writeError(w, http.StatusServiceUnavailable, "config unavailable")
+			return
+		}
+		if !cfg.Auth.StatefulLogin || h.sessions == nil {
+			writeError(w, http.StatusNotFound, "stateful login is disabled")
+			return
+		}
+		if !h.allowTokenRequest(w, r, cfg) {
+			return
+		}
+
+		state, err := randomToken()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to generate login state")
+			return
+		}
+		binding, err := randomToken()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to generate login state")
+			return
+		}
+		ttl := time.Duration(cfg.Auth.LoginStateTTLSeconds) * time.Second
+		if err := putLoginState(r.Context(), h.sessions, state, binding, ttl); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to store login state")
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     loginStateCookie,
+			Value:    binding,
+			Path:     loginStateCookiePath(cfg.Server.BasePath),
+			MaxAge:   int(ttl / time.Second),
+			HttpOnly: true,
+			Secure:   requestIsHTTPS(r),
+			SameSite: http.SameSiteLaxMode,
+		})
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, r, loginStateResponse{State: state, ExpiresAt: time.Now().UTC().Add(ttl)})
+	}
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func loginStateCookiePath(basePath string) string {
+	return basePath + "/api/v1/auth"
+}
+
+// clearLoginStateCookie expires the binding cookie once its state is used.
+func clearLoginStateCookie(w http.ResponseWriter, r *http.Request, basePath string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginStateCookie,
+		Path:     loginStateCookiePath(basePath),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   requestIsHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// requestIsHTTPS marks the cookie Secure when the browser reached us over
+// TLS, directly or through a proxy that terminated it.
+func requestIsHTTPS(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+func hashBinding(binding string) []byte {
+	sum := sha256.Sum256([]byte(binding))
+	return []byte(hex.EncodeToString(sum[:]))
+}
+
+func putLoginState(ctx context.Context, store storage.SessionStore, state, binding string, ttl time.Duration) error {
+	key := loginStatePrefix + state
+	if expiring, ok := store.(storage.ExpiringStore); ok {
+		return expiring.PutWithTTL(ctx, key, hashBinding(binding), ttl)
+	}
+	return store.Put(ctx, key, hashBinding(binding))
+}
+
+// consumeLoginState checks that state was issued within ttl to the browser
+// holding binding, and deletes it so it cannot be replayed. The age check
+// also covers stores that cannot expire records on their own.
+func consumeLoginState(ctx context.Context, store storage.SessionStore, state, binding string, ttl time.Duration) error {
+	if state == "" || binding == "" {
+		return errInvalidLoginState
+	}
+	key := loginStatePrefix + state
+	rec, err := store.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return errInvalidLoginState
+	}
+	if err != nil {
+		return err
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		return err
+	}
+	if time.Since(rec.UpdatedAt) > ttl {
+		return errInvalidLoginState
+	}
+	if subtle.ConstantTimeCompare(rec.Data, hashBinding(binding)) != 1 {
+		return errInvalidLoginState
+	}
+	return nil
+}
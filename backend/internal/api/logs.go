@@ -9,15 +9,20 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"net"
 	"net/http"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/metadata"
 
@@ -26,29 +31,43 @@ import (
 )
 
 type KubeHandler struct {
-	cfg         *config.Config
-	client      *kubernetes.Clientset
-	podInclude  *regexp.Regexp
-	appInclude  *regexp.Regexp
-	podExclude  []labelFilter
-	appExclude  []labelFilter
-	appStreams  *appStreamPool
-	cache       *resourceCache
-	informers   *resourceInformers
-	stats       *ResourceStats
-	statsStop   chan struct{}
-	metaClient  metadata.Interface
-	logHub      *logStreamHub
-	logLimiter  *logLimiter
-	metricsStop chan struct{}
-}
-
-func NewKubeHandler(cfg *config.Config, client *kubernetes.Clientset, meta metadata.Interface) *KubeHandler {
+	cfg            *config.Config
+	client         kubernetes.Interface
+	raw            rawClient
+	filterMu       sync.RWMutex
+	podInclude     *regexp.Regexp
+	appInclude     *regexp.Regexp
+	podExclude     []labelFilter
+	appExclude     []labelFilter
+	podRequired    []labelFilter
+	appRequired    []labelFilter
+	appGroup       []labelFilter
+	annotations    *annotationFilter
+	maskedKeys     []*regexp.Regexp
+	logLocation    *time.Location
+	auditOut       *auditPipeline
+	trustedProxies []*net.IPNet
+	appStreams     *appStreamPool
+	cache          *resourceCache
+	informers      *resourceInformers
+	stats          *ResourceStats
+	statsStop      chan struct{}
+	metaClient     metadata.Interface
+	logHub         *logStreamHub
+	logLimiter     *logLimiter
+	metricsStop    chan struct{}
+	metricsHistory *metricsHistory
+	overview       overviewCache
+}
+
+func NewKubeHandler(cfg *config.Config, client kubernetes.Interface, meta metadata.Interface) *KubeHandler {
 	apiCache := cfg.Kubernetes.APICache
 	podTTL := time.Duration(apiCache.PodListTTLSeconds) * time.Second
 	appTTL := time.Duration(apiCache.AppListTTLSeconds) * time.Second
 	crdTTL := time.Duration(apiCache.CRDListTTLSeconds) * time.Second
 	metricsTTL := time.Duration(apiCache.MetricsListTTLSeconds) * time.Second
+	configMapTTL := time.Duration(apiCache.ConfigMapTTLSeconds) * time.Second
+	secretTTL := time.Duration(apiCache.SecretTTLSeconds) * time.Second
 	retryBase := time.Duration(apiCache.RetryBaseDelayMillis) * time.Millisecond
 	stats := newResourceStats()
 	overrideEntries := make([]rateConfigEntry, 0, len(cfg.Logs.RateLimitOverrides))
@@ -59,28 +78,42 @@ func NewKubeHandler(cfg *config.Config, client *kubernetes.Clientset, meta metad
 			burst:         entry.RateLimitBurst,
 		})
 	}
-	limiter := newLogLimiter(cfg.Logs.RateLimitPerMinute, cfg.Logs.RateLimitBurst, overrideEntries)
+	limiter := newLogLimiter(cfg.Logs.RateLimitPerMinute, cfg.Logs.RateLimitBurst, overrideEntries, cfg.Logs.GlobalRatePerMinute, cfg.Logs.GlobalRateBurst)
 	handler := &KubeHandler{
-		cfg:        cfg,
-		client:     client,
-		podInclude: compileRegex(cfg.Kubernetes.PodFilters.IncludeRegex),
-		appInclude: compileRegex(cfg.Kubernetes.AppFilters.IncludeRegex),
-		podExclude: parseLabelFilters(cfg.Kubernetes.PodFilters.ExcludeLabels),
-		appExclude: parseLabelFilters(cfg.Kubernetes.AppFilters.ExcludeLabels),
-		cache:      newResourceCache(podTTL, appTTL, crdTTL, metricsTTL, apiCache.RetryAttempts, retryBase, stats),
-		stats:      stats,
-		statsStop:  make(chan struct{}),
-		metaClient: meta,
-		logLimiter: limiter,
-	}
-	handler.logHub = newLogStreamHub(handler)
-	handler.appStreams = newAppStreamPool(handler)
+		cfg:    cfg,
+		client: client,
+		raw:    newRawClient(client),
+		annotations: newAnnotationFilter(
+			cfg.Kubernetes.AnnotationFilters.Allow,
+			cfg.Kubernetes.AnnotationFilters.Deny,
+		),
+		maskedKeys:     compileKeyPatterns(cfg.Kubernetes.MaskedMetadataKeys),
+		logLocation:    loadLogLocation(cfg.Logs.DisplayTimezone),
+		trustedProxies: parseTrustedProxies(cfg.Server.TrustedProxies),
+		cache:          newResourceCache(podTTL, appTTL, crdTTL, metricsTTL, configMapTTL, secretTTL, apiCache.RetryAttempts, retryBase, stats),
+		stats:          stats,
+		statsStop:      make(chan struct{}),
+		metaClient:     meta,
+		logLimiter:     limiter,
+		metricsHistory: newMetricsHistory(apiCache.MetricsHistorySamples, apiCache.MetricsHistoryMaxPods),
+	}
+	handler.setFilters(cfg)
+	if cfg.Server.AuditLogs {
+		handler.auditOut = newAuditPipeline(cfg, stats)
+	}
+	if cfg.Logs.Enabled == nil || *cfg.Logs.Enabled {
+		handler.logHub = newLogStreamHub(handler)
+		handler.appStreams = newAppStreamPool(handler)
+	}
 	if !apiCache.MetadataOnly && apiCache.EnableInformers != nil && *apiCache.EnableInformers && client != nil {
 		resync := time.Duration(apiCache.InformerResyncSeconds) * time.Second
-		handler.informers = newResourceInformers(client, cfg.Kubernetes.AllowedNamespaces, resync)
+		syncWait := time.Duration(apiCache.InformerSyncWaitMillis) * time.Millisecond
+		handler.informers = newResourceInformers(client, cfg.Kubernetes.AllowedNamespaces, resync, syncWait)
 		handler.informers.Start()
 	}
 	handler.startStatsLogger()
+	handler.startCacheSizer()
+	handler.startSecretSweeper()
 	handler.startMetricsRefresh()
 	if cfg.Kubernetes.APICache.WarmOnStartup {
 		go handler.warmCaches()
@@ -92,6 +125,31 @@ func (h *KubeHandler) Stop() {
 	if h == nil {
 		return
 	}
+	h.stopBackground()
+	h.appStreams.stop()
+	if h.logHub != nil {
+		h.logHub.stop()
+	}
+	h.auditOut.close()
+}
+
+// Shutdown is Stop for process exit: it also waits, until ctx is done, for
+// log streams to flush their pending Redis writes.
+func (h *KubeHandler) Shutdown(ctx context.Context) error {
+	if h == nil {
+		return nil
+	}
+	h.stopBackground()
+	h.appStreams.stop()
+	var err error
+	if h.logHub != nil {
+		err = h.logHub.shutdown(ctx)
+	}
+	h.auditOut.close()
+	return err
+}
+
+func (h *KubeHandler) stopBackground() {
 	if h.statsStop != nil {
 		close(h.statsStop)
 	}
@@ -101,9 +159,6 @@ func (h *KubeHandler) Stop() {
 	if h.informers != nil {
 		h.informers.Stop()
 	}
-	if h.logHub != nil {
-		h.logHub.stop()
-	}
 }
 
 func (h *KubeHandler) Stats() *ResourceStats {
@@ -180,14 +235,17 @@ func (h *KubeHandler) refreshMetrics(jitter time.Duration) {
 			}
 		}
 		if h.cache == nil {
-			_, _ = listPodMetrics(ctx, h.client, ns, nil)
+			if items, err := listPodMetrics(ctx, h.raw, ns, nil); err == nil {
+				h.metricsHistory.record(ns, items, time.Now())
+			}
 			continue
 		}
-		items, err := listPodMetrics(ctx, h.client, ns, h.cache)
+		items, err := listPodMetrics(ctx, h.raw, ns, h.cache)
 		if err != nil {
 			continue
 		}
 		h.cache.setPodMetrics(ns, items)
+		h.metricsHistory.record(ns, items, time.Now())
 	}
 }
 
@@ -207,14 +265,45 @@ func (h *KubeHandler) warmCaches() {
 }
 
 func (h *KubeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path == "/api/v1/namespaces" || r.URL.Path == "/api/v1/namespaces/" {
+	if h.cfg.Server.ReadOnly && r.Method != http.MethodGet && r.Method != http.MethodHead {
+		// Checked before routing so every mutating endpoint is covered, whatever
+		// the caller's groups.
+		writeError(w, http.StatusForbidden, "server is in read-only mode")
+		return
+	}
+	// Mutations and secret reveals need a recent interactive login when
+	// auth.max_auth_age_seconds is set. Reveals by users who may not see
+	// secrets are masked anyway, so they are not challenged.
+	user, _ := auth.UserFromContext(r.Context())
+	sensitive := r.Method != http.MethodGet && r.Method != http.MethodHead
+	if sensitive || (wantsRevealSecrets(r) && h.canRevealSecrets(user)) {
+		if !h.requireRecentAuth(w, r) {
+			return
+		}
+	}
+	path := "/" + strings.Join(splitPath(r.URL.Path), "/")
+	if path == "/api/v1/admin/ratelimits" {
+		h.handleAdminRateLimits(w, r)
+		return
+	}
+	if path == "/api/v1/admin/logstreams" {
+		h.handleAdminLogStreams(w, r)
+		return
+	}
+	if path == "/api/v1/debug/filters" {
+		h.handleDebugFilters(w, r)
+		return
+	}
+	if path == "/api/v1/overview" {
+		h.handleOverview(w, r)
+		return
+	}
+	if path == "/api/v1/namespaces" {
 		h.handleNamespaces(w, r)
 		return
 	}
 
-	path := strings.TrimPrefix(r.URL.Path, "/api/v1/namespaces")
-	path = strings.Trim(path, "/")
-	parts := strings.Split(path, "/")
+	parts := splitPath(strings.TrimPrefix(path, "/api/v1/namespaces"))
 	if len(parts) < 2 {
 		http.NotFound(w, r)
 		return
@@ -232,21 +321,45 @@ func (h *KubeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.handlePods(w, r, ns, parts[2:])
 	case "apps":
 		h.handleApps(w, r, ns, parts[2:])
+	case "quota":
+		if len(parts) > 2 {
+			http.NotFound(w, r)
+			return
+		}
+		h.handleNamespaceQuota(w, r, ns)
+	case "permissions":
+		if len(parts) > 2 {
+			http.NotFound(w, r)
+			return
+		}
+		h.handleNamespacePermissions(w, r, ns)
 	default:
 		http.NotFound(w, r)
 	}
 }
 
+// splitPath returns the non-empty segments of path, so trailing and repeated
+// slashes route the same as the canonical URL.
+func splitPath(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+}
+
 func (h *KubeHandler) streamPodLogs(w http.ResponseWriter, r *http.Request, namespace, name string) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		writeError(w, http.StatusInternalServerError, "streaming unsupported")
 		return
 	}
 
-	if !h.allowLogRequest(r, namespace) {
-		w.Header().Set("Retry-After", "60")
-		writeError(w, http.StatusTooManyRequests, "log rate limit exceeded")
+	if err := h.validateLogRequest(r); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !h.allowLogRequest(w, r, namespace) {
 		return
 	}
 
@@ -254,19 +367,80 @@ func (h *KubeHandler) streamPodLogs(w http.ResponseWriter, r *http.Request, name
 		"container": r.URL.Query().Get("container"),
 	})
 
+	loc, err := h.resolveLogLocation(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	maxDuration, err := h.streamMaxDuration(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	req := parseLogRequest(r, h.cfg)
+	pod, err := h.client.CoreV1().Pods(namespace).Get(r.Context(), name, metav1.GetOptions{})
+	if err != nil {
+		status := http.StatusBadGateway
+		if apierrors.IsNotFound(err) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+	if req.container != "" && !podHasContainer(pod, req.container) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("container %q not found in pod %s; valid containers: %s", req.container, name, strings.Join(podContainerNames(pod), ", ")))
+		return
+	}
+
+	var capture *logCapture
+	if wantsCapture(r) {
+		capture, err = h.startLogCapture(r, namespace, name)
+		if err != nil {
+			writeCaptureError(w, err)
+			return
+		}
+		defer capture.close()
+	}
+
 	sub, replay, unsubscribe, err := h.logHub.SubscribePod(r.Context(), namespace, name, req.container, req.tail, req.resume)
+	if errors.Is(err, errLogStreamLimit) {
+		w.Header().Set("Retry-After", "30")
+		writeError(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if errors.Is(err, errLogHubStopped) {
+		w.Header().Set("Retry-After", "5")
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
 	if err != nil {
 		writeError(w, http.StatusBadGateway, fmt.Sprintf("log stream error: %v", err))
 		return
 	}
 	defer unsubscribe()
 
+	rec := &sseWriteRecorder{ResponseWriter: w}
+	w = rec
+	closeReason := sseCloseClientGone
+	defer func() { h.logHub.recordSSEClose(sseKindPod, closeReason, rec) }()
+
+	if capture != nil {
+		w.Header().Set(logCaptureHeader, capture.id)
+	}
 	setSSEHeaders(w)
 	flusher.Flush()
+	if capture != nil {
+		if err := writeSSEEvent(w, newJSONEvent("capture", logCaptureStarted{ID: capture.id})); err != nil {
+			return
+		}
+	}
 
 	for _, entry := range replay {
-		if err := writeSSEEvent(w, newLogEvent(entry)); err != nil {
+		event := newLogEvent(localizeLogEntry(entry, loc))
+		capture.writeEvent(event)
+		if err := writeSSEEvent(w, event); err != nil {
 			return
 		}
 	}
@@ -282,12 +456,11 @@ func (h *KubeHandler) streamPodLogs(w http.ResponseWriter, r *http.Request, name
 	}
 	statusTicker := time.NewTicker(statusPeriod)
 	defer statusTicker.Stop()
+	deadline, stopDeadline := streamDeadline(maxDuration)
+	defer stopDeadline()
 
-	var prevRestarts int32
-	var prevReady bool
-	if pod, err := h.client.CoreV1().Pods(namespace).Get(r.Context(), name, metav1.GetOptions{}); err == nil {
-		prevRestarts, prevReady = summarizePodStatus(*pod)
-	}
+	prevRestarts, prevReady := summarizePodStatus(*pod)
+	queuedNotified := false
 
 	sendMarker := func(kind, message string) error {
 		event := newJSONEvent("marker", streamMarker{
@@ -303,6 +476,11 @@ func (h *KubeHandler) streamPodLogs(w http.ResponseWriter, r *http.Request, name
 		select {
 		case <-r.Context().Done():
 			return
+		case <-deadline:
+			closeReason = sseCloseTimeout
+			_ = writeSSEEvent(w, newStreamTimeoutEvent(maxDuration))
+			flusher.Flush()
+			return
 		case <-heartbeat.C:
 			event := newJSONEvent("heartbeat", streamHeartbeat{Timestamp: time.Now().UTC().Format(time.RFC3339Nano)})
 			if err := writeSSEEvent(w, event); err != nil {
@@ -315,6 +493,12 @@ func (h *KubeHandler) streamPodLogs(w http.ResponseWriter, r *http.Request, name
 				if err := writeSSEEvent(w, event); err != nil {
 					return
 				}
+				if status.Queued && !queuedNotified {
+					if err := sendMarker("queued", "waiting for a free log connection slot"); err != nil {
+						return
+					}
+				}
+				queuedNotified = status.Queued
 			}
 			stats := streamStats{
 				Dropped:  sub.dropped.Load(),
@@ -354,9 +538,12 @@ func (h *KubeHandler) streamPodLogs(w http.ResponseWriter, r *http.Request, name
 			flusher.Flush()
 		case entry, ok := <-sub.ch:
 			if !ok {
+				closeReason = sseCloseSourceEnded
 				return
 			}
-			if err := writeSSEEvent(w, newLogEvent(entry)); err != nil {
+			event := newLogEvent(localizeLogEntry(entry, loc))
+			capture.writeEvent(event)
+			if err := writeSSEEvent(w, event); err != nil {
 				return
 			}
 			flusher.Flush()
@@ -365,15 +552,30 @@ func (h *KubeHandler) streamPodLogs(w http.ResponseWriter, r *http.Request, name
 }
 
 func (h *KubeHandler) streamAppLogs(w http.ResponseWriter, r *http.Request, namespace, name string) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		writeError(w, http.StatusInternalServerError, "streaming unsupported")
 		return
 	}
 
-	if !h.allowLogRequest(r, namespace) {
-		w.Header().Set("Retry-After", "60")
-		writeError(w, http.StatusTooManyRequests, "log rate limit exceeded")
+	if err := h.validateLogRequest(r); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !h.allowLogRequest(w, r, namespace) {
+		return
+	}
+
+	if _, err := h.appSelector(r.Context(), namespace, name); err != nil {
+		status := http.StatusNotFound
+		if !errors.Is(err, errAppNotFound) {
+			status = http.StatusBadGateway
+		}
+		writeError(w, status, err.Error())
 		return
 	}
 
@@ -381,11 +583,38 @@ func (h *KubeHandler) streamAppLogs(w http.ResponseWriter, r *http.Request, name
 		"container": r.URL.Query().Get("container"),
 	})
 
+	loc, err := h.resolveLogLocation(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	maxDuration, err := h.streamMaxDuration(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var capture *logCapture
+	if wantsCapture(r) {
+		capture, err = h.startLogCapture(r, namespace, name)
+		if err != nil {
+			writeCaptureError(w, err)
+			return
+		}
+		defer capture.close()
+	}
+
 	opts := h.buildLogOptions(r)
-	sub, unsubscribe, err := h.appStreams.subscribe(r.Context(), namespace, name, opts)
+	ordered := strings.EqualFold(r.URL.Query().Get("ordered"), "true")
+	sub, unsubscribe, err := h.appStreams.subscribe(r.Context(), namespace, name, opts, loc, ordered)
 	if err != nil {
 		status := http.StatusNotFound
-		if !errors.Is(err, errAppNotFound) {
+		switch {
+		case errors.Is(err, errLogHubStopped):
+			w.Header().Set("Retry-After", "5")
+			status = http.StatusServiceUnavailable
+		case !errors.Is(err, errAppNotFound):
 			status = http.StatusBadGateway
 		}
 		writeError(w, status, err.Error())
@@ -393,18 +622,54 @@ func (h *KubeHandler) streamAppLogs(w http.ResponseWriter, r *http.Request, name
 	}
 	defer unsubscribe()
 
-	setSSEHeaders(w)
+	rec := &sseWriteRecorder{ResponseWriter: w}
+	w = rec
+	closeReason := sseCloseClientGone
+	defer func() { h.logHub.recordSSEClose(sseKindApp, closeReason, rec) }()
+
+	plain := wantsPlainText(r)
+	prefix := wantsLogPrefix(r)
+	if capture != nil {
+		w.Header().Set(logCaptureHeader, capture.id)
+	}
+	if plain {
+		setPlainTextHeaders(w)
+	} else {
+		setSSEHeaders(w)
+	}
 	flusher.Flush()
+	if capture != nil && !plain {
+		if err := writeSSEEvent(w, newJSONEvent("capture", logCaptureStarted{ID: capture.id})); err != nil {
+			return
+		}
+	}
+
+	deadline, stopDeadline := streamDeadline(maxDuration)
+	defer stopDeadline()
 
 	for {
 		select {
 		case <-r.Context().Done():
 			return
+		case <-deadline:
+			closeReason = sseCloseTimeout
+			if !plain {
+				_ = writeSSEEvent(w, newStreamTimeoutEvent(maxDuration))
+				flusher.Flush()
+			}
+			return
 		case event, ok := <-sub.ch:
 			if !ok {
+				closeReason = sseCloseSourceEnded
 				return
 			}
-			if err := writeSSEEvent(w, event); err != nil {
+			capture.writeEvent(event)
+			if plain {
+				err = h.writeTextEvent(w, event, prefix)
+			} else {
+				err = writeSSEEvent(w, event)
+			}
+			if err != nil {
 				return
 			}
 			flusher.Flush()
@@ -486,6 +751,52 @@ func (h *KubeHandler) parseLogLine(line, podName, containerName string) logEntry
 	}
 }
 
+func loadLogLocation(name string) *time.Location {
+	if strings.TrimSpace(name) == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(strings.TrimSpace(name))
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func (h *KubeHandler) resolveLogLocation(r *http.Request) (*time.Location, error) {
+	if name := strings.TrimSpace(r.URL.Query().Get("tz")); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tz %q", name)
+		}
+		return loc, nil
+	}
+	if h.logLocation == nil {
+		return time.UTC, nil
+	}
+	return h.logLocation, nil
+}
+
+// localizeLogEntry converts the timestamp to loc. Lines without a Redis ID
+// get their UTC timestamp as ID in every zone, so resume cursors do not
+// depend on the client's zone.
+func localizeLogEntry(entry logEntry, loc *time.Location) logEntry {
+	if entry.Timestamp == "" {
+		return entry
+	}
+	if entry.ID == "" {
+		entry.ID = entry.Timestamp
+	}
+	if loc == nil || loc == time.UTC {
+		return entry
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+	if err != nil {
+		return entry
+	}
+	entry.Timestamp = parsed.In(loc).Format(time.RFC3339Nano)
+	return entry
+}
+
 func parseLogRequest(r *http.Request, cfg *config.Config) logRequest {
 	tail := parseTailLines(r.URL.Query().Get("tail"), cfg.Logs.DefaultTailLines, cfg.Logs.MaxTailLines)
 	container := r.URL.Query().Get("container")
@@ -517,6 +828,67 @@ func parseLogRequest(r *http.Request, cfg *config.Config) logRequest {
 	}
 }
 
+const maxLogSinceIDLength = 128
+
+func podContainerNames(pod *corev1.Pod) []string {
+	names := make([]string, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers)+len(pod.Spec.EphemeralContainers))
+	for _, c := range pod.Spec.InitContainers {
+		names = append(names, c.Name)
+	}
+	for _, c := range pod.Spec.Containers {
+		names = append(names, c.Name)
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func podHasContainer(pod *corev1.Pod, container string) bool {
+	for _, name := range podContainerNames(pod) {
+		if name == container {
+			return true
+		}
+	}
+	return false
+}
+
+// validateLogRequest rejects malformed log query parameters up front so
+// clients get a 400 instead of an empty or silently defaulted stream.
+func (h *KubeHandler) validateLogRequest(r *http.Request) error {
+	query := r.URL.Query()
+	if container := query.Get("container"); container != "" {
+		if errs := validation.IsDNS1123Label(container); len(errs) > 0 {
+			return fmt.Errorf("invalid container %q: %s", container, strings.Join(errs, "; "))
+		}
+	}
+	if raw := query.Get("tail"); raw != "" {
+		tail, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid tail %q: must be an integer", raw)
+		}
+		if tail < 0 {
+			return fmt.Errorf("tail must be >= 0")
+		}
+		if max := h.cfg.Logs.MaxTailLines; max > 0 && tail > max {
+			return fmt.Errorf("tail must be <= %d", max)
+		}
+	}
+	if raw := query.Get("since"); raw != "" {
+		since, ok := parseLogTime(raw)
+		if !ok {
+			return fmt.Errorf("invalid since %q: must be an RFC3339 timestamp", raw)
+		}
+		if since.After(time.Now().Add(time.Minute)) {
+			return fmt.Errorf("since must not be in the future")
+		}
+	}
+	if raw := query.Get("since_id"); len(raw) > maxLogSinceIDLength {
+		return fmt.Errorf("since_id must be at most %d characters", maxLogSinceIDLength)
+	}
+	return nil
+}
+
 func parseLogTime(raw string) (time.Time, bool) {
 	if parsed, err := time.Parse(time.RFC3339Nano, raw); err == nil {
 		return parsed, true
@@ -527,23 +899,73 @@ func parseLogTime(raw string) (time.Time, bool) {
 	return time.Time{}, false
 }
 
-func (h *KubeHandler) allowLogRequest(r *http.Request, namespace string) bool {
-	if h == nil || h.logLimiter == nil {
+// allowLogRequest checks logs.namespace_groups and then the rate limiter,
+// writing a 403 or 429 when the request may not read logs in namespace.
+func (h *KubeHandler) allowLogRequest(w http.ResponseWriter, r *http.Request, namespace string) bool {
+	if h == nil {
+		return true
+	}
+	user, _ := auth.UserFromContext(r.Context())
+	if !h.canReadLogs(user, namespace) {
+		h.audit(r, "log_access_denied", namespace, "", map[string]any{"result": "denied"})
+		writeError(w, http.StatusForbidden, "log access to this namespace is restricted")
+		return false
+	}
+	if h.logLimiter == nil {
 		return true
 	}
 	key := namespace
-	if user, ok := auth.UserFromContext(r.Context()); ok && user != nil {
+	identity := ""
+	if user != nil {
+		identity = user.Subject
 		key = user.Subject + "|" + namespace
 	} else if r != nil {
-		key = remoteIP(r) + "|" + namespace
+		identity = h.remoteIP(r)
+		key = identity + "|" + namespace
 	}
-	allowed := h.logLimiter.Allow(namespace, key)
+	allowed, state := h.logLimiter.Allow(namespace, key, identity)
+	setRateLimitHeaders(w, state, allowed)
 	if !allowed {
-		h.audit(r, "log_rate_limited", namespace, "", nil)
+		h.audit(r, "log_rate_limited", namespace, "", map[string]any{"result": "denied"})
+		writeError(w, http.StatusTooManyRequests, "log rate limit exceeded")
 	}
 	return allowed
 }
 
+// canReadLogs reports whether user may read logs in namespace. Namespaces
+// missing from logs.namespace_groups are open to every authenticated user.
+func (h *KubeHandler) canReadLogs(user *auth.User, namespace string) bool {
+	groups, ok := h.cfg.Logs.NamespaceGroups[namespace]
+	if !ok {
+		return true
+	}
+	if user == nil {
+		return false
+	}
+	for _, group := range user.Groups {
+		if slices.Contains(groups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, state rateLimitState, allowed bool) {
+	if state.limit <= 0 {
+		return
+	}
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(state.limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(state.remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.Itoa(int(state.reset.Seconds())))
+	if !allowed {
+		retry := int(state.retryAfter.Seconds())
+		if retry <= 0 {
+			retry = 1
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(retry))
+	}
+}
+
 func (h *KubeHandler) buildLogOptions(r *http.Request) *corev1.PodLogOptions {
 	tail := parseTailLines(r.URL.Query().Get("tail"), h.cfg.Logs.DefaultTailLines, h.cfg.Logs.MaxTailLines)
 
@@ -570,6 +992,63 @@ func (h *KubeHandler) buildLogOptions(r *http.Request) *corev1.PodLogOptions {
 	return opts
 }
 
+// logLimitBytes caps the raw bytes the apiserver sends per non-follow GetLogs
+// call; nil leaves the transfer unbounded. Follow streams never set it, since
+// the apiserver ends them at the limit.
+func (h *KubeHandler) logLimitBytes() *int64 {
+	limit := h.cfg.Logs.MaxBytesPerRequest
+	if limit <= 0 {
+		return nil
+	}
+	return &limit
+}
+
+type streamTimeout struct {
+	Timestamp          string `json:"timestamp"`
+	MaxDurationSeconds int    `json:"maxDurationSeconds"`
+}
+
+// streamMaxDuration resolves ?max_duration (a Go duration or seconds),
+// capped by logs.max_stream_duration_seconds, which also applies when the
+// parameter is absent. Zero means the stream is not time limited.
+func (h *KubeHandler) streamMaxDuration(r *http.Request) (time.Duration, error) {
+	limit := time.Duration(h.cfg.Logs.MaxStreamDuration) * time.Second
+	raw := strings.TrimSpace(r.URL.Query().Get("max_duration"))
+	if raw == "" {
+		return limit, nil
+	}
+	var requested time.Duration
+	if seconds, err := strconv.Atoi(raw); err == nil {
+		requested = time.Duration(seconds) * time.Second
+	} else if parsed, err := time.ParseDuration(raw); err == nil {
+		requested = parsed
+	} else {
+		return 0, fmt.Errorf("invalid max_duration %q", raw)
+	}
+	if requested <= 0 {
+		return 0, fmt.Errorf("max_duration must be positive")
+	}
+	if limit > 0 && requested > limit {
+		return limit, nil
+	}
+	return requested, nil
+}
+
+func streamDeadline(d time.Duration) (<-chan time.Time, func()) {
+	if d <= 0 {
+		return nil, func() {}
+	}
+	timer := time.NewTimer(d)
+	return timer.C, func() { timer.Stop() }
+}
+
+func newStreamTimeoutEvent(d time.Duration) sseEvent {
+	return newJSONEvent("timeout", streamTimeout{
+		Timestamp:          time.Now().UTC().Format(time.RFC3339Nano),
+		MaxDurationSeconds: int(d.Seconds()),
+	})
+}
+
 func parseTailLines(raw string, def int, max int) int64 {
 	tail := def
 	if raw != "" {
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSplitPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"", nil},
+		{"/", nil},
+		{"//", nil},
+		{"/default/pods", []string{"default", "pods"}},
+		{"/default/pods/", []string{"default", "pods"}},
+		{"//default//pods//web-1", []string{"default", "pods", "web-1"}},
+		{"default/pods/web-1/logs/", []string{"default", "pods", "web-1", "logs"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := splitPath(tt.path); !slices.Equal(got, tt.want) {
+				t.Fatalf("splitPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestServeHTTPPathVariants checks that extra and trailing slashes route the
+// same as the canonical path instead of falling through to a 404.
+func TestServeHTTPPathVariants(t *testing.T) {
+	h, _ := newTestKubeHandler(t, nil, testPod("web-1", map[string]string{"app": "web"}))
+	tests := []struct {
+		name   string
+		target string
+		want   int
+	}{
+		{"canonical pod", "/api/v1/namespaces/default/pods/web-1", http.StatusOK},
+		{"trailing slash", "/api/v1/namespaces/default/pods/web-1/", http.StatusOK},
+		{"double slash before name", "/api/v1/namespaces/default/pods//web-1", http.StatusOK},
+		{"double slash in prefix", "/api/v1//namespaces//default/pods/web-1", http.StatusOK},
+		{"list with trailing slash", "/api/v1/namespaces/default/pods/", http.StatusOK},
+		{"top-level with trailing slash", "/api/v1/namespaces/", http.StatusOK},
+		{"double slash before subresource", "/api/v1/namespaces/default/pods/web-1//details", http.StatusOK},
+		{"missing resource type", "/api/v1/namespaces/default//", http.StatusNotFound},
+		{"unknown pod", "/api/v1/namespaces/default/pods/web-2/", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveAs(h, testUser, http.MethodGet, tt.target)
+			if rec.Code != tt.want {
+				t.Fatalf("GET %s = %d, want %d: %s", tt.target, rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestLocalizeLogEntry(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no zoneinfo: %v", err)
+	}
+	const ts = "2026-01-02T03:04:05.123Z"
+	tests := []struct {
+		name   string
+		entry  logEntry
+		loc    *time.Location
+		wantTS string
+		wantID string
+	}{
+		{"utc without id", logEntry{Timestamp: ts}, time.UTC, ts, ts},
+		{"nil location without id", logEntry{Timestamp: ts}, nil, ts, ts},
+		{"zone without id", logEntry{Timestamp: ts}, berlin, "2026-01-02T04:04:05.123+01:00", ts},
+		{"zone keeps redis id", logEntry{Timestamp: ts, ID: "1-0"}, berlin, "2026-01-02T04:04:05.123+01:00", "1-0"},
+		{"utc keeps redis id", logEntry{Timestamp: ts, ID: "1-0"}, time.UTC, ts, "1-0"},
+		{"no timestamp", logEntry{}, berlin, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := localizeLogEntry(tt.entry, tt.loc)
+			if got.Timestamp != tt.wantTS || got.ID != tt.wantID {
+				t.Fatalf("got timestamp %q id %q, want %q %q", got.Timestamp, got.ID, tt.wantTS, tt.wantID)
+			}
+		})
+	}
+}
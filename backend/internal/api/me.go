@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/halceonio/kubelens/backend/internal/auth"
+)
+
+// meResponse describes the caller as the backend sees them after token
+// verification, which may differ from what the UI decodes from the token
+// when group lists or auth.name_claim change.
+type meResponse struct {
+	Subject        string     `json:"subject"`
+	Name           string     `json:"name"`
+	Groups         []string   `json:"groups"`
+	AllowedSecrets bool       `json:"allowedSecrets"`
+	Admin          bool       `json:"admin"`
+	AuthTime       *time.Time `json:"authTime,omitempty"`
+}
+
+func NewMeHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethods(w, r, http.MethodGet) {
+			return
+		}
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok || user == nil {
+			writeError(w, http.StatusUnauthorized, "missing user context")
+			return
+		}
+		resp := meResponse{
+			Subject:        user.Subject,
+			Name:           user.Name,
+			Groups:         user.Groups,
+			AllowedSecrets: user.AllowedSecrets,
+			Admin:          user.Admin,
+		}
+		if !user.AuthTime.IsZero() {
+			authTime := user.AuthTime.UTC()
+			resp.AuthTime = &authTime
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, r, resp)
+	}
+}
@@ -3,13 +3,14 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"runtime"
+	"strconv"
 	"strings"
 )
 
 func MetricsHandler(statsProvider func() *ResourceStats, logProvider func() *LogStreamStats) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			w.WriteHeader(http.StatusMethodNotAllowed)
+		if !allowMethods(w, r, http.MethodGet) {
 			return
 		}
 		stats := (*ResourceStats)(nil)
@@ -38,6 +39,7 @@ func MetricsHandler(statsProvider func() *ResourceStats, logProvider func() *Log
 				fmt.Sprintf("kubelens_k8s_cache_hits_total{resource=\"statefulsets\"} %d", snap.stsCacheHit),
 				fmt.Sprintf("kubelens_k8s_cache_hits_total{resource=\"cnpg\"} %d", snap.cnpgCacheHit),
 				fmt.Sprintf("kubelens_k8s_cache_hits_total{resource=\"dragonfly\"} %d", snap.dragonCacheHit),
+				fmt.Sprintf("kubelens_k8s_cache_hits_total{resource=\"pod_metrics\"} %d", snap.metricsHit),
 				"# HELP kubelens_k8s_cache_misses_total Cache misses by resource.",
 				"# TYPE kubelens_k8s_cache_misses_total counter",
 				fmt.Sprintf("kubelens_k8s_cache_misses_total{resource=\"pods\"} %d", snap.podsCacheMiss),
@@ -45,6 +47,7 @@ func MetricsHandler(statsProvider func() *ResourceStats, logProvider func() *Log
 				fmt.Sprintf("kubelens_k8s_cache_misses_total{resource=\"statefulsets\"} %d", snap.stsCacheMiss),
 				fmt.Sprintf("kubelens_k8s_cache_misses_total{resource=\"cnpg\"} %d", snap.cnpgCacheMiss),
 				fmt.Sprintf("kubelens_k8s_cache_misses_total{resource=\"dragonfly\"} %d", snap.dragonCacheMiss),
+				fmt.Sprintf("kubelens_k8s_cache_misses_total{resource=\"pod_metrics\"} %d", snap.metricsMiss),
 				"# HELP kubelens_k8s_informer_hits_total Informer hits by resource.",
 				"# TYPE kubelens_k8s_informer_hits_total counter",
 				fmt.Sprintf("kubelens_k8s_informer_hits_total{resource=\"pods\"} %d", snap.podsInformerHit),
@@ -57,6 +60,7 @@ func MetricsHandler(statsProvider func() *ResourceStats, logProvider func() *Log
 				fmt.Sprintf("kubelens_k8s_api_calls_total{resource=\"statefulsets\"} %d", snap.stsAPICall),
 				fmt.Sprintf("kubelens_k8s_api_calls_total{resource=\"cnpg\"} %d", snap.cnpgAPICall),
 				fmt.Sprintf("kubelens_k8s_api_calls_total{resource=\"dragonfly\"} %d", snap.dragonAPICall),
+				fmt.Sprintf("kubelens_k8s_api_calls_total{resource=\"pod_metrics\"} %d", snap.metricsAPICall),
 				"# HELP kubelens_k8s_api_errors_total API errors by resource.",
 				"# TYPE kubelens_k8s_api_errors_total counter",
 				fmt.Sprintf("kubelens_k8s_api_errors_total{resource=\"pods\"} %d", snap.podsAPIErr),
@@ -64,10 +68,51 @@ func MetricsHandler(statsProvider func() *ResourceStats, logProvider func() *Log
 				fmt.Sprintf("kubelens_k8s_api_errors_total{resource=\"statefulsets\"} %d", snap.stsAPIErr),
 				fmt.Sprintf("kubelens_k8s_api_errors_total{resource=\"cnpg\"} %d", snap.cnpgAPIErr),
 				fmt.Sprintf("kubelens_k8s_api_errors_total{resource=\"dragonfly\"} %d", snap.dragonAPIErr),
+				fmt.Sprintf("kubelens_k8s_api_errors_total{resource=\"pod_metrics\"} %d", snap.metricsAPIErr),
 				"# HELP kubelens_k8s_throttle_retries_total Retry attempts due to apiserver throttling.",
 				"# TYPE kubelens_k8s_throttle_retries_total counter",
 				fmt.Sprintf("kubelens_k8s_throttle_retries_total %d", snap.throttleRetries),
+				"# HELP kubelens_audit_dropped_total Audit records dropped due to sink backpressure or errors.",
+				"# TYPE kubelens_audit_dropped_total counter",
+				fmt.Sprintf("kubelens_audit_dropped_total %d", snap.auditDropped),
 			)
+			trackedPods, trackedApps := stats.trackedSnapshot()
+			lines = append(lines,
+				"# HELP kubelens_tracked_pods Pods returned by the last list per namespace (after filtering).",
+				"# TYPE kubelens_tracked_pods gauge",
+			)
+			for _, item := range trackedPods {
+				lines = append(lines, fmt.Sprintf("kubelens_tracked_pods{namespace=%q} %d", item.namespace, item.count))
+			}
+			lines = append(lines,
+				"# HELP kubelens_tracked_apps Apps returned by the last list per namespace and kind (after filtering).",
+				"# TYPE kubelens_tracked_apps gauge",
+			)
+			for _, item := range trackedApps {
+				lines = append(lines, fmt.Sprintf("kubelens_tracked_apps{namespace=%q,kind=%q} %d", item.namespace, item.kind, item.count))
+			}
+			sizes := stats.cacheSizeSnapshot()
+			lines = append(lines,
+				"# HELP kubelens_cache_entries Cached namespaces per resource cache.",
+				"# TYPE kubelens_cache_entries gauge",
+			)
+			for _, size := range sizes {
+				lines = append(lines, fmt.Sprintf("kubelens_cache_entries{cache=%q} %d", size.name, size.entries))
+			}
+			lines = append(lines,
+				"# HELP kubelens_cache_items Cached objects per resource cache.",
+				"# TYPE kubelens_cache_items gauge",
+			)
+			for _, size := range sizes {
+				lines = append(lines, fmt.Sprintf("kubelens_cache_items{cache=%q} %d", size.name, size.items))
+			}
+			lines = append(lines,
+				"# HELP kubelens_cache_bytes_approx Approximate cached bytes per resource cache (JSON-encoded size).",
+				"# TYPE kubelens_cache_bytes_approx gauge",
+			)
+			for _, size := range sizes {
+				lines = append(lines, fmt.Sprintf("kubelens_cache_bytes_approx{cache=%q} %d", size.name, size.bytes))
+			}
 		}
 
 		if logStats != nil {
@@ -75,6 +120,9 @@ func MetricsHandler(statsProvider func() *ResourceStats, logProvider func() *Log
 				"# HELP kubelens_log_workers_active Active pooled log workers.",
 				"# TYPE kubelens_log_workers_active gauge",
 				fmt.Sprintf("kubelens_log_workers_active %d", logStats.ActiveStreams),
+				"# HELP kubelens_log_workers_max Configured maximum pooled log workers (0 = unlimited).",
+				"# TYPE kubelens_log_workers_max gauge",
+				fmt.Sprintf("kubelens_log_workers_max %d", logStats.MaxStreams),
 				"# HELP kubelens_log_subscribers_active Active log subscribers.",
 				"# TYPE kubelens_log_subscribers_active gauge",
 				fmt.Sprintf("kubelens_log_subscribers_active %d", logStats.ActiveSubscribers),
@@ -99,7 +147,47 @@ func MetricsHandler(statsProvider func() *ResourceStats, logProvider func() *Log
 				"# HELP kubelens_log_buffer_bytes Total bytes in log buffers.",
 				"# TYPE kubelens_log_buffer_bytes gauge",
 				fmt.Sprintf("kubelens_log_buffer_bytes %d", logStats.BufferBytesTotal),
+				"# HELP kubelens_log_goroutines Active log stream goroutines (workers, consumers, subscriber watchers).",
+				"# TYPE kubelens_log_goroutines gauge",
+				fmt.Sprintf("kubelens_log_goroutines %d", logStats.Goroutines),
+				"# HELP kubelens_app_stream_events_dropped_total Events app log streams skipped because a subscriber's buffer was full.",
+				"# TYPE kubelens_app_stream_events_dropped_total counter",
+				fmt.Sprintf("kubelens_app_stream_events_dropped_total %d", logStats.AppEventsDropped),
+				"# HELP kubelens_goroutines Total goroutines in the process.",
+				"# TYPE kubelens_goroutines gauge",
+				fmt.Sprintf("kubelens_goroutines %d", runtime.NumGoroutine()),
+			)
+			lines = append(lines,
+				"# HELP kubelens_sse_streams_closed_total Ended log streams by kind and reason (write_error means the client stopped reading).",
+				"# TYPE kubelens_sse_streams_closed_total counter",
 			)
+			for _, item := range logStats.SSECloses {
+				lines = append(lines, fmt.Sprintf("kubelens_sse_streams_closed_total{stream=%q,reason=%q} %d", item.Stream, item.Reason, item.Count))
+			}
+			if len(logStats.RedisOps) > 0 {
+				lines = append(lines,
+					"# HELP kubelens_log_redis_op_duration_seconds Log hub Redis operation latency by operation (xread includes block time).",
+					"# TYPE kubelens_log_redis_op_duration_seconds histogram",
+				)
+				bounds := RedisOpBuckets()
+				for _, op := range logStats.RedisOps {
+					for i, bound := range bounds {
+						lines = append(lines, fmt.Sprintf("kubelens_log_redis_op_duration_seconds_bucket{op=%q,le=\"%s\"} %d", op.Op, strconv.FormatFloat(bound, 'g', -1, 64), op.Buckets[i]))
+					}
+					lines = append(lines,
+						fmt.Sprintf("kubelens_log_redis_op_duration_seconds_bucket{op=%q,le=\"+Inf\"} %d", op.Op, op.Count),
+						fmt.Sprintf("kubelens_log_redis_op_duration_seconds_sum{op=%q} %g", op.Op, op.SumSeconds),
+						fmt.Sprintf("kubelens_log_redis_op_duration_seconds_count{op=%q} %d", op.Op, op.Count),
+					)
+				}
+				lines = append(lines,
+					"# HELP kubelens_log_redis_errors_total Log hub Redis operation errors by operation.",
+					"# TYPE kubelens_log_redis_errors_total counter",
+				)
+				for _, op := range logStats.RedisOps {
+					lines = append(lines, fmt.Sprintf("kubelens_log_redis_errors_total{op=%q} %d", op.Op, op.Errors))
+				}
+			}
 		}
 		_, _ = w.Write([]byte(strings.Join(lines, "\n") + "\n"))
 	}
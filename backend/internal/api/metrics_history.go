@@ -0,0 +1,149 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type metricsSample struct {
+	Time          time.Time `json:"time"`
+	CPUMillicores int64     `json:"cpuMillicores"`
+	MemoryBytes   int64     `json:"memoryBytes"`
+}
+
+type metricsHistoryResponse struct {
+	Namespace       string          `json:"namespace"`
+	Pod             string          `json:"pod"`
+	IntervalSeconds int             `json:"intervalSeconds"`
+	Samples         []metricsSample `json:"samples"`
+}
+
+// metricsSeries is a fixed-size ring of samples for one pod.
+type metricsSeries struct {
+	samples []metricsSample
+	next    int
+	full    bool
+	updated time.Time
+}
+
+func (s *metricsSeries) add(sample metricsSample) {
+	s.samples[s.next] = sample
+	s.next = (s.next + 1) % len(s.samples)
+	if s.next == 0 {
+		s.full = true
+	}
+	s.updated = sample.Time
+}
+
+// ordered returns the samples oldest first.
+func (s *metricsSeries) ordered() []metricsSample {
+	if !s.full {
+		return append([]metricsSample(nil), s.samples[:s.next]...)
+	}
+	out := make([]metricsSample, 0, len(s.samples))
+	out = append(out, s.samples[s.next:]...)
+	return append(out, s.samples[:s.next]...)
+}
+
+// metricsHistory keeps the last few metrics-server samples per pod so the UI
+// can draw a trend without Prometheus. Memory is bounded by the sample count
+// per pod and by maxPods; the least recently updated pod is evicted first.
+type metricsHistory struct {
+	mu      sync.Mutex
+	size    int
+	maxPods int
+	series  map[string]*metricsSeries
+}
+
+// newMetricsHistory returns nil, disabling history, when size is not positive.
+func newMetricsHistory(size, maxPods int) *metricsHistory {
+	if size <= 0 || maxPods <= 0 {
+		return nil
+	}
+	return &metricsHistory{size: size, maxPods: maxPods, series: map[string]*metricsSeries{}}
+}
+
+// record appends one sample per pod in items and drops series for pods of
+// namespace that no longer report metrics.
+func (m *metricsHistory) record(namespace string, items []podMetricItem, at time.Time) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	seen := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		key := namespace + "/" + item.Name
+		seen[key] = struct{}{}
+		series, ok := m.series[key]
+		if !ok {
+			if len(m.series) >= m.maxPods {
+				m.evictOldestLocked()
+			}
+			series = &metricsSeries{samples: make([]metricsSample, m.size)}
+			m.series[key] = series
+		}
+		series.add(metricsSample{
+			Time:          at,
+			CPUMillicores: item.CPU.MilliValue(),
+			MemoryBytes:   item.Mem.Value(),
+		})
+	}
+	prefix := namespace + "/"
+	for key := range m.series {
+		if _, ok := seen[key]; !ok && strings.HasPrefix(key, prefix) {
+			delete(m.series, key)
+		}
+	}
+}
+
+func (m *metricsHistory) evictOldestLocked() {
+	oldestKey := ""
+	var oldest time.Time
+	for key, series := range m.series {
+		if oldestKey == "" || series.updated.Before(oldest) {
+			oldestKey = key
+			oldest = series.updated
+		}
+	}
+	delete(m.series, oldestKey)
+}
+
+func (m *metricsHistory) samples(namespace, pod string) []metricsSample {
+	if m == nil {
+		return []metricsSample{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	series, ok := m.series[namespace+"/"+pod]
+	if !ok {
+		return []metricsSample{}
+	}
+	return series.ordered()
+}
+
+func (h *KubeHandler) handlePodMetricsHistory(w http.ResponseWriter, r *http.Request, namespace, name string) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	pod, err := h.client.CoreV1().Pods(namespace).Get(r.Context(), name, metav1.GetOptions{})
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if !h.allowPod(pod) {
+		writeError(w, http.StatusForbidden, "pod not allowed")
+		return
+	}
+	writeJSON(w, r, metricsHistoryResponse{
+		Namespace:       namespace,
+		Pod:             name,
+		IntervalSeconds: h.cfg.Kubernetes.APICache.MetricsRefreshSeconds,
+		Samples:         h.metricsHistory.samples(namespace, name),
+	})
+}
@@ -0,0 +1,295 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// openAPIRoute describes one operation. Response schemas are reflected from
+// the Go types the handlers encode, so adding a field to a *Response struct
+// updates the spec without touching this file; new routes still need an
+// entry here. The server tests check that every entry reaches a handler.
+type openAPIRoute struct {
+	method      string
+	path        string
+	summary     string
+	tag         string
+	public      bool
+	params      []openAPIParam
+	body        reflect.Type
+	response    reflect.Type
+	contentType string
+}
+
+type openAPIParam struct {
+	name        string
+	in          string
+	description string
+	required    bool
+	schemaType  string
+}
+
+var (
+	nsParam      = openAPIParam{name: "namespace", in: "path", required: true, schemaType: "string", description: "Allowed namespace."}
+	podParam     = openAPIParam{name: "pod", in: "path", required: true, schemaType: "string", description: "Pod name."}
+	appParam     = openAPIParam{name: "app", in: "path", required: true, schemaType: "string", description: "App (workload) name."}
+	prettyParam  = openAPIParam{name: "pretty", in: "query", schemaType: "boolean", description: "Indent the JSON response."}
+	metricsParam = openAPIParam{name: "metrics", in: "query", schemaType: "boolean", description: "Include CPU/memory usage from metrics-server."}
+	lightParam   = openAPIParam{name: "light", in: "query", schemaType: "boolean", description: "Return trimmed list items."}
+	expandParam  = openAPIParam{name: "expand", in: "query", schemaType: "string", description: "Comma-separated extra sections to include."}
+	revealParam  = openAPIParam{name: "reveal_secrets", in: "query", schemaType: "boolean", description: "Return Secret-backed env values; requires auth.allowed_secrets_groups and, if set, auth.reveal_required_scope."}
+	logParams    = []openAPIParam{
+		{name: "container", in: "query", schemaType: "string", description: "Container name; defaults to the first container."},
+		{name: "tail", in: "query", schemaType: "integer", description: "Lines to replay before following."},
+		{name: "since", in: "query", schemaType: "string", description: "RFC3339 timestamp to resume from."},
+		{name: "since_id", in: "query", schemaType: "string", description: "Last seen log entry id to resume from."},
+		{name: "tz", in: "query", schemaType: "string", description: "IANA timezone for timestamps."},
+		{name: "format", in: "query", schemaType: "string", description: "Set to text for plain-text output."},
+		{name: "max_duration", in: "query", schemaType: "string", description: "Close the stream after this Go duration."},
+		{name: "capture", in: "query", schemaType: "boolean", description: "Record the stream for later download."},
+	}
+)
+
+var openAPIRoutes = []openAPIRoute{
+	{method: http.MethodGet, path: "/api/v1/auth/config", tag: "auth", public: true, summary: "Keycloak settings for the frontend.", response: reflect.TypeFor[AuthConfigResponse]()},
+	{method: http.MethodPost, path: "/api/v1/auth/token", tag: "auth", public: true, summary: "Exchange an authorization code for tokens.", body: reflect.TypeFor[tokenRequest]()},
+	{method: http.MethodPost, path: "/api/v1/auth/state", tag: "auth", public: true, summary: "Issue a one-time login state (auth.stateful_login).", response: reflect.TypeFor[loginStateResponse]()},
+	{method: http.MethodGet, path: "/api/v1/me", tag: "session", summary: "The authenticated caller's identity, groups, and display name.", response: reflect.TypeFor[meResponse]()},
+	{method: http.MethodGet, path: "/api/v1/session", tag: "session", summary: "Load the caller's stored UI session."},
+	{method: http.MethodPut, path: "/api/v1/session", tag: "session", summary: "Replace the caller's stored UI session."},
+	{method: http.MethodDelete, path: "/api/v1/session", tag: "session", summary: "Delete the caller's stored UI session."},
+	{method: http.MethodGet, path: "/api/v1/config", tag: "config", summary: "Frontend-relevant backend configuration.", response: reflect.TypeFor[ConfigResponse]()},
+	{method: http.MethodGet, path: "/api/v1/config/validate", tag: "config", summary: "Validate the running configuration.", response: reflect.TypeFor[ConfigValidationResponse]()},
+	{method: http.MethodGet, path: "/api/v1/namespaces", tag: "namespaces", summary: "List allowed namespaces.", response: reflect.TypeFor[[]namespaceResponse]()},
+	{method: http.MethodGet, path: "/api/v1/overview", tag: "namespaces", summary: "Pod, app, and resource rollup across allowed namespaces.", response: reflect.TypeFor[overviewResponse]()},
+	{method: http.MethodGet, path: "/api/v1/namespaces/{namespace}/quota", tag: "namespaces", summary: "ResourceQuotas and LimitRanges in a namespace.", params: []openAPIParam{nsParam}, response: reflect.TypeFor[namespaceQuotaResponse]()},
+	{method: http.MethodGet, path: "/api/v1/namespaces/{namespace}/permissions", tag: "namespaces", summary: "What the caller may do in a namespace.", params: []openAPIParam{nsParam}, response: reflect.TypeFor[permissionsResponse]()},
+	{method: http.MethodGet, path: "/api/v1/namespaces/{namespace}/pods", tag: "pods", summary: "List pods. Send Accept: application/x-ndjson to stream items.", params: []openAPIParam{nsParam, metricsParam, lightParam}, response: reflect.TypeFor[[]podResponse]()},
+	{method: http.MethodGet, path: "/api/v1/namespaces/{namespace}/pods/{pod}", tag: "pods", summary: "Get a pod.", params: []openAPIParam{nsParam, podParam, metricsParam, expandParam}, response: reflect.TypeFor[podResponse]()},
+	{method: http.MethodGet, path: "/api/v1/namespaces/{namespace}/pods/{pod}/details", tag: "pods", summary: "Get a pod with resolved env.", params: []openAPIParam{nsParam, podParam, metricsParam, revealParam}, response: reflect.TypeFor[podResponse]()},
+	{method: http.MethodGet, path: "/api/v1/namespaces/{namespace}/pods/{pod}/metrics", tag: "pods", summary: "Current CPU/memory usage of a pod.", params: []openAPIParam{nsParam, podParam}, response: reflect.TypeFor[resourceUsage]()},
+	{method: http.MethodGet, path: "/api/v1/namespaces/{namespace}/pods/{pod}/metrics/history", tag: "pods", summary: "Recent usage samples of a pod.", params: []openAPIParam{nsParam, podParam}, response: reflect.TypeFor[metricsHistoryResponse]()},
+	{method: http.MethodGet, path: "/api/v1/namespaces/{namespace}/pods/{pod}/restarts", tag: "pods", summary: "Container restarts and related events.", params: []openAPIParam{nsParam, podParam}, response: reflect.TypeFor[podRestartsResponse]()},
+	{method: http.MethodGet, path: "/api/v1/namespaces/{namespace}/pods/{pod}/logs", tag: "logs", summary: "Stream pod logs.", params: append([]openAPIParam{nsParam, podParam}, logParams...), response: reflect.TypeFor[logEntry](), contentType: "text/event-stream"},
+	{method: http.MethodGet, path: "/api/v1/namespaces/{namespace}/apps", tag: "apps", summary: "List apps. Send Accept: application/x-ndjson to stream items.", params: []openAPIParam{nsParam, metricsParam, lightParam, expandParam}, response: reflect.TypeFor[[]appResponse]()},
+	{method: http.MethodGet, path: "/api/v1/namespaces/{namespace}/apps/{app}", tag: "apps", summary: "Get an app.", params: []openAPIParam{nsParam, appParam, metricsParam, revealParam}, response: reflect.TypeFor[appResponse]()},
+	{method: http.MethodGet, path: "/api/v1/namespaces/{namespace}/apps/{app}/logs", tag: "logs", summary: "Stream merged logs of an app's pods.", params: append([]openAPIParam{nsParam, appParam, {name: "ordered", in: "query", schemaType: "boolean", description: "Reorder lines by timestamp across pods."}}, logParams...), response: reflect.TypeFor[logEntry](), contentType: "text/event-stream"},
+	{method: http.MethodGet, path: "/api/v1/namespaces/{namespace}/apps/{app}/logs/search", tag: "logs", summary: "Search recent logs of an app's pods.", params: []openAPIParam{nsParam, appParam, {name: "q", in: "query", required: true, schemaType: "string", description: "Go regular expression."}, {name: "since", in: "query", schemaType: "string", description: "Duration or RFC3339 lower bound."}, {name: "limit", in: "query", schemaType: "integer", description: "Maximum matches to return."}}, response: reflect.TypeFor[logSearchResponse]()},
+	{method: http.MethodGet, path: "/api/v1/admin/ratelimits", tag: "admin", summary: "Inspect a subject's log rate-limit buckets.", params: []openAPIParam{{name: "subject", in: "query", required: true, schemaType: "string"}}, response: reflect.TypeFor[rateLimitInspectResponse]()},
+	{method: http.MethodDelete, path: "/api/v1/admin/ratelimits", tag: "admin", summary: "Reset a subject's log rate-limit buckets.", params: []openAPIParam{{name: "subject", in: "query", required: true, schemaType: "string"}}, response: reflect.TypeFor[rateLimitResetResponse]()},
+	{method: http.MethodGet, path: "/api/v1/admin/logstreams", tag: "admin", summary: "Log workers on this instance.", response: reflect.TypeFor[logStreamsResponse]()},
+	{method: http.MethodGet, path: "/api/v1/debug/filters", tag: "admin", summary: "Dry-run pod/app filters over a namespace.", params: []openAPIParam{{name: "namespace", in: "query", required: true, schemaType: "string"}}, response: reflect.TypeFor[filterDebugResponse]()},
+}
+
+var buildOpenAPISpec = sync.OnceValue(func() map[string]any {
+	schemas := &openAPISchemas{components: map[string]any{}}
+	paths := map[string]any{}
+	for _, route := range openAPIRoutes {
+		item, _ := paths[route.path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[route.path] = item
+		}
+		item[strings.ToLower(route.method)] = schemas.operation(route)
+	}
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "KubeLens API",
+			"version": "v1",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas.components,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+		"security": []any{map[string]any{"bearerAuth": []any{}}},
+	}
+})
+
+// OpenAPIHandler serves the OpenAPI 3 document for the routes above.
+func OpenAPIHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethods(w, r, http.MethodGet) {
+			return
+		}
+		writeJSON(w, r, buildOpenAPISpec())
+	}
+}
+
+type openAPISchemas struct {
+	components map[string]any
+}
+
+func (s *openAPISchemas) operation(route openAPIRoute) map[string]any {
+	op := map[string]any{
+		"summary":     route.summary,
+		"tags":        []string{route.tag},
+		"operationId": operationID(route),
+	}
+	if route.public {
+		op["security"] = []any{}
+	}
+	params := make([]any, 0, len(route.params)+1)
+	for _, p := range append(route.params, prettyParam) {
+		param := map[string]any{
+			"name":     p.name,
+			"in":       p.in,
+			"required": p.required,
+			"schema":   map[string]any{"type": p.schemaType},
+		}
+		if p.description != "" {
+			param["description"] = p.description
+		}
+		params = append(params, param)
+	}
+	op["parameters"] = params
+	if route.body != nil {
+		op["requestBody"] = map[string]any{
+			"required": true,
+			"content":  map[string]any{"application/json": map[string]any{"schema": s.schemaFor(route.body)}},
+		}
+	}
+	ok := map[string]any{"description": "OK"}
+	contentType := route.contentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	if route.response != nil {
+		ok["content"] = map[string]any{contentType: map[string]any{"schema": s.schemaFor(route.response)}}
+	} else {
+		ok["content"] = map[string]any{contentType: map[string]any{"schema": map[string]any{"type": "object"}}}
+	}
+	op["responses"] = map[string]any{
+		"200":     ok,
+		"default": map[string]any{"description": "Error", "content": map[string]any{"application/json": map[string]any{"schema": s.errorSchema()}}},
+	}
+	return op
+}
+
+func operationID(route openAPIRoute) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(route.method))
+	for _, part := range strings.FieldsFunc(strings.TrimPrefix(route.path, "/api/v1/"), func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '.' || r == '_'
+	}) {
+		b.WriteString(exportedName(part))
+	}
+	return b.String()
+}
+
+func (s *openAPISchemas) errorSchema() map[string]any {
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"error": map[string]any{"type": "string"}},
+	}
+}
+
+var (
+	timeType      = reflect.TypeFor[time.Time]()
+	marshalerType = reflect.TypeFor[json.Marshaler]()
+)
+
+// schemaFor maps a Go type to a JSON schema following encoding/json rules.
+// Named structs become components referenced by $ref.
+func (s *openAPISchemas) schemaFor(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+		// Custom encodings can't be reflected; leave the schema open.
+		return map[string]any{}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": s.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		name := exportedName(t.Name())
+		if _, ok := s.components[name]; !ok {
+			// Reserve the name first so self-referencing types terminate.
+			s.components[name] = map[string]any{}
+			s.components[name] = s.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]any{}
+	}
+}
+
+func (s *openAPISchemas) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	s.addFields(t, properties, &required)
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (s *openAPISchemas) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = s.schemaFor(field.Type)
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") && field.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var openAPIPathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+func TestOpenAPIRoutesAreConsistent(t *testing.T) {
+	seen := map[string]bool{}
+	for _, route := range openAPIRoutes {
+		id := route.method + " " + route.path
+		t.Run(id, func(t *testing.T) {
+			if seen[id] {
+				t.Fatalf("duplicate route")
+			}
+			seen[id] = true
+			if !strings.HasPrefix(route.path, "/api/v1/") {
+				t.Errorf("path must start with /api/v1/")
+			}
+			if route.summary == "" || route.tag == "" {
+				t.Errorf("summary and tag are required")
+			}
+
+			var declared []string
+			for _, param := range route.params {
+				if param.in == "path" {
+					declared = append(declared, param.name)
+				}
+			}
+			var placeholders []string
+			for _, match := range openAPIPathParam.FindAllStringSubmatch(route.path, -1) {
+				placeholders = append(placeholders, match[1])
+			}
+			if !slices.Equal(declared, placeholders) {
+				t.Errorf("path params %v do not match placeholders %v", declared, placeholders)
+			}
+
+			for _, typ := range []reflect.Type{route.body, route.response} {
+				if typ == nil {
+					continue
+				}
+				for typ.Kind() == reflect.Slice || typ.Kind() == reflect.Pointer {
+					typ = typ.Elem()
+				}
+				if typ.Kind() != reflect.Struct {
+					t.Errorf("%s is not a struct or slice of structs", typ)
+				}
+			}
+		})
+	}
+}
+
+// TestOpenAPISpecRefsResolve reflects over every request and response type
+// and checks that each schema reference points at an emitted component.
+func TestOpenAPISpecRefsResolve(t *testing.T) {
+	rec := httptest.NewRecorder()
+	OpenAPIHandler()(rec, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var spec struct {
+		Paths      map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	body := rec.Body.Bytes()
+	if err := json.Unmarshal(body, &spec); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+
+	operations := 0
+	for _, item := range spec.Paths {
+		operations += len(item)
+	}
+	if operations != len(openAPIRoutes) {
+		t.Errorf("spec has %d operations, route table has %d", operations, len(openAPIRoutes))
+	}
+
+	var raw any
+	if err := json.Unmarshal(body, &raw); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+	refs := 0
+	walkOpenAPIRefs(raw, func(ref string) {
+		refs++
+		name, ok := strings.CutPrefix(ref, "#/components/schemas/")
+		if !ok {
+			t.Errorf("unexpected $ref %q", ref)
+			return
+		}
+		if _, ok := spec.Components.Schemas[name]; !ok {
+			t.Errorf("$ref %q has no component schema", ref)
+		}
+	})
+	if refs == 0 {
+		t.Error("spec has no schema references")
+	}
+}
+
+func walkOpenAPIRefs(node any, visit func(ref string)) {
+	switch v := node.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if ref, ok := child.(string); ok && key == "$ref" {
+				visit(ref)
+				continue
+			}
+			walkOpenAPIRefs(child, visit)
+		}
+	case []any:
+		for _, child := range v {
+			walkOpenAPIRefs(child, visit)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	overviewTTL         = 10 * time.Second
+	overviewConcurrency = 4
+)
+
+type overviewResponse struct {
+	GeneratedAt time.Time          `json:"generatedAt"`
+	Totals      namespaceSummary   `json:"totals"`
+	Namespaces  []namespaceSummary `json:"namespaces"`
+}
+
+// namespaceSummary rolls up one namespace, or all of them in
+// overviewResponse.Totals. Usage and requests only count pods that are still
+// running or pending.
+type namespaceSummary struct {
+	Namespace   string         `json:"namespace,omitempty"`
+	Pods        int            `json:"pods"`
+	PodsByPhase map[string]int `json:"podsByPhase"`
+	NotReady    int            `json:"notReady"`
+	Apps        int            `json:"apps"`
+	Resources   resourceUsage  `json:"resources"`
+	Errors      []string       `json:"errors,omitempty"`
+
+	cpuUsage, memUsage resource.Quantity
+	requests, limits   resourceTotals
+	metricsPods        int
+}
+
+type overviewCache struct {
+	mu      sync.Mutex
+	resp    *overviewResponse
+	fetched time.Time
+	group   singleflight.Group
+}
+
+// handleOverview serves the cluster-wide rollup for the global dashboard. It
+// is built from the per-namespace caches and shared across users for
+// overviewTTL, since every user sees the same allowed namespaces.
+func (h *KubeHandler) handleOverview(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	h.auditRead(r, "overview", "", "", nil)
+	writeJSON(w, r, h.overviewCached())
+}
+
+func (h *KubeHandler) overviewCached() *overviewResponse {
+	h.overview.mu.Lock()
+	if h.overview.resp != nil && time.Since(h.overview.fetched) < overviewTTL {
+		resp := h.overview.resp
+		h.overview.mu.Unlock()
+		return resp
+	}
+	h.overview.mu.Unlock()
+
+	v, _, _ := h.overview.group.Do("overview", func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		resp := h.buildOverview(ctx)
+		h.overview.mu.Lock()
+		h.overview.resp = resp
+		h.overview.fetched = time.Now()
+		h.overview.mu.Unlock()
+		return resp, nil
+	})
+	return v.(*overviewResponse)
+}
+
+func (h *KubeHandler) buildOverview(ctx context.Context) *overviewResponse {
+	namespaces := h.cfg.Kubernetes.AllowedNamespaces
+	summaries := make([]namespaceSummary, len(namespaces))
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(overviewConcurrency)
+	for i, ns := range namespaces {
+		group.Go(func() error {
+			summaries[i] = h.summarizeNamespace(groupCtx, ns)
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	totals := namespaceSummary{PodsByPhase: map[string]int{}}
+	for _, summary := range summaries {
+		totals.Pods += summary.Pods
+		totals.NotReady += summary.NotReady
+		totals.Apps += summary.Apps
+		for phase, count := range summary.PodsByPhase {
+			totals.PodsByPhase[phase] += count
+		}
+		totals.cpuUsage.Add(summary.cpuUsage)
+		totals.memUsage.Add(summary.memUsage)
+		totals.requests.cpu.Add(summary.requests.cpu)
+		totals.requests.mem.Add(summary.requests.mem)
+		totals.limits.cpu.Add(summary.limits.cpu)
+		totals.limits.mem.Add(summary.limits.mem)
+		totals.metricsPods += summary.metricsPods
+		for _, msg := range summary.Errors {
+			totals.Errors = append(totals.Errors, summary.Namespace+": "+msg)
+		}
+	}
+	totals.fillResources()
+
+	return &overviewResponse{
+		GeneratedAt: time.Now().UTC(),
+		Totals:      totals,
+		Namespaces:  summaries,
+	}
+}
+
+func (h *KubeHandler) summarizeNamespace(ctx context.Context, namespace string) namespaceSummary {
+	summary := namespaceSummary{Namespace: namespace, PodsByPhase: map[string]int{}}
+
+	// A pod list error is reported by evaluateFilters below, which reads the
+	// same cached list.
+	pods, _ := h.listPodsCached(ctx, namespace)
+	metrics, _ := h.listPodMetricsCached(ctx, namespace)
+	for i := range pods {
+		pod := &pods[i]
+		if !h.allowPod(pod) {
+			continue
+		}
+		summary.Pods++
+		summary.PodsByPhase[string(pod.Status.Phase)]++
+		if pod.Status.Phase != corev1.PodRunning && pod.Status.Phase != corev1.PodPending {
+			continue
+		}
+		if !podReady(pod) {
+			summary.NotReady++
+		}
+		for _, container := range pod.Spec.Containers {
+			summary.requests.add(container.Resources.Requests)
+			summary.limits.add(container.Resources.Limits)
+		}
+		if cpu, mem, ok := metrics.usageForPod(pod.Name); ok {
+			summary.cpuUsage.Add(cpu)
+			summary.memUsage.Add(mem)
+			summary.metricsPods++
+		}
+	}
+
+	apps := h.evaluateFilters(ctx, namespace)
+	for _, app := range apps.Apps {
+		if app.Included {
+			summary.Apps++
+		}
+	}
+	summary.Errors = append(summary.Errors, apps.Errors...)
+	summary.fillResources()
+	return summary
+}
+
+// fillResources renders the summed quantities. Utilization compares usage to
+// the requests and limits of all counted pods, so it is only set once every
+// counted pod reported metrics.
+func (s *namespaceSummary) fillResources() {
+	s.Resources = resourceUsage{
+		CPURequest: formatCPU(s.requests.cpu),
+		MemRequest: formatMemory(s.requests.mem),
+		CPULimit:   formatCPU(s.limits.cpu),
+		MemLimit:   formatMemory(s.limits.mem),
+		CPUUsage:   formatCPU(s.cpuUsage),
+		MemUsage:   formatMemory(s.memUsage),
+	}
+	active := s.Pods - s.PodsByPhase[string(corev1.PodSucceeded)] - s.PodsByPhase[string(corev1.PodFailed)]
+	if s.metricsPods > 0 && s.metricsPods == active {
+		s.Resources.applyUsage(s.cpuUsage, s.memUsage, 1, s.requests, s.limits)
+	}
+}
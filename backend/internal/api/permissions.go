@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/halceonio/kubelens/backend/internal/auth"
+)
+
+// permissionsResponse tells the UI which actions to offer in a namespace. The
+// same checks guard the endpoints themselves, so hiding a control here never
+// replaces the server-side check.
+type permissionsResponse struct {
+	Namespace     string `json:"namespace"`
+	ReadLogs      bool   `json:"readLogs"`
+	RevealSecrets bool   `json:"revealSecrets"`
+	Write         bool   `json:"write"`
+	Admin         bool   `json:"admin"`
+}
+
+func (h *KubeHandler) handleNamespacePermissions(w http.ResponseWriter, r *http.Request, namespace string) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	user, _ := auth.UserFromContext(r.Context())
+	writeJSON(w, r, h.permissionsFor(user, namespace))
+}
+
+func (h *KubeHandler) permissionsFor(user *auth.User, namespace string) permissionsResponse {
+	resp := permissionsResponse{
+		Namespace:     namespace,
+		ReadLogs:      h.logHub != nil && h.canReadLogs(user, namespace),
+		RevealSecrets: h.canRevealSecrets(user),
+		Write:         !h.cfg.Server.ReadOnly,
+	}
+	if user != nil {
+		resp.Admin = user.Admin
+	}
+	return resp
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+type podRestartsResponse struct {
+	Name              string                     `json:"name"`
+	Namespace         string                     `json:"namespace"`
+	Containers        []containerRestartResponse `json:"containers"`
+	Events            []restartEventResponse     `json:"events"`
+	EventsUnavailable bool                       `json:"eventsUnavailable,omitempty"`
+}
+
+type containerRestartResponse struct {
+	Name            string               `json:"name"`
+	Init            bool                 `json:"init,omitempty"`
+	RestartCount    int32                `json:"restartCount"`
+	State           string               `json:"state"`
+	StartedAt       string               `json:"startedAt,omitempty"`
+	WaitingReason   string               `json:"waitingReason,omitempty"`
+	Current         *terminationResponse `json:"current,omitempty"`
+	LastTermination *terminationResponse `json:"lastTermination,omitempty"`
+}
+
+type terminationResponse struct {
+	Reason     string `json:"reason,omitempty"`
+	Message    string `json:"message,omitempty"`
+	ExitCode   int32  `json:"exitCode"`
+	Signal     int32  `json:"signal,omitempty"`
+	StartedAt  string `json:"startedAt,omitempty"`
+	FinishedAt string `json:"finishedAt,omitempty"`
+	OOMKilled  bool   `json:"oomKilled,omitempty"`
+}
+
+type restartEventResponse struct {
+	Type      string `json:"type"`
+	Reason    string `json:"reason"`
+	Message   string `json:"message"`
+	Container string `json:"container,omitempty"`
+	Count     int32  `json:"count"`
+	FirstSeen string `json:"firstSeen,omitempty"`
+	LastSeen  string `json:"lastSeen,omitempty"`
+}
+
+func (h *KubeHandler) handlePodRestarts(w http.ResponseWriter, r *http.Request, namespace, name string) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	h.auditRead(r, "pod_restarts", namespace, name, nil)
+	ctx := r.Context()
+	pod, err := h.client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if !h.allowPod(pod) {
+		writeError(w, http.StatusForbidden, "pod not allowed")
+		return
+	}
+
+	resp := podRestartsResponse{
+		Name:       pod.Name,
+		Namespace:  pod.Namespace,
+		Containers: make([]containerRestartResponse, 0, len(pod.Status.InitContainerStatuses)+len(pod.Status.ContainerStatuses)),
+		Events:     []restartEventResponse{},
+	}
+	for _, status := range pod.Status.InitContainerStatuses {
+		item := mapContainerRestart(status)
+		item.Init = true
+		resp.Containers = append(resp.Containers, item)
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		resp.Containers = append(resp.Containers, mapContainerRestart(status))
+	}
+
+	events, err := h.listPodWarningEvents(ctx, pod)
+	if err != nil {
+		// Events only enrich the view; keep the container history when RBAC or the API refuses them.
+		resp.EventsUnavailable = true
+	} else {
+		resp.Events = events
+	}
+	writeJSON(w, r, resp)
+}
+
+func mapContainerRestart(status corev1.ContainerStatus) containerRestartResponse {
+	item := containerRestartResponse{
+		Name:         status.Name,
+		RestartCount: status.RestartCount,
+	}
+	switch {
+	case status.State.Running != nil:
+		item.State = "running"
+		item.StartedAt = formatRestartTime(status.State.Running.StartedAt)
+	case status.State.Waiting != nil:
+		item.State = "waiting"
+		item.WaitingReason = status.State.Waiting.Reason
+	case status.State.Terminated != nil:
+		item.State = "terminated"
+		item.Current = mapTermination(status.State.Terminated)
+	default:
+		item.State = "unknown"
+	}
+	if term := status.LastTerminationState.Terminated; term != nil {
+		item.LastTermination = mapTermination(term)
+	}
+	return item
+}
+
+func mapTermination(term *corev1.ContainerStateTerminated) *terminationResponse {
+	return &terminationResponse{
+		Reason:     term.Reason,
+		Message:    term.Message,
+		ExitCode:   term.ExitCode,
+		Signal:     term.Signal,
+		StartedAt:  formatRestartTime(term.StartedAt),
+		FinishedAt: formatRestartTime(term.FinishedAt),
+		OOMKilled:  term.Reason == oomKilledReason,
+	}
+}
+
+func formatRestartTime(t metav1.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// listPodWarningEvents returns the pod's Warning events (BackOff, Unhealthy,
+// OOMKilling, ...), newest first, since the pod status only keeps the last termination.
+func (h *KubeHandler) listPodWarningEvents(ctx context.Context, pod *corev1.Pod) ([]restartEventResponse, error) {
+	selector := fields.Set{
+		"involvedObject.kind": "Pod",
+		"involvedObject.name": pod.Name,
+		"involvedObject.uid":  string(pod.UID),
+	}.AsSelector().String()
+	var list *corev1.EventList
+	err := retryK8s(ctx, h.cache, func(ctx context.Context) error {
+		var err error
+		list, err = h.client.CoreV1().Events(pod.Namespace).List(ctx, metav1.ListOptions{FieldSelector: selector})
+		return err
+	})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return []restartEventResponse{}, nil
+		}
+		return nil, err
+	}
+
+	type seenEvent struct {
+		item     restartEventResponse
+		lastSeen time.Time
+	}
+	seen := make([]seenEvent, 0, len(list.Items))
+	for _, event := range list.Items {
+		if event.Type != corev1.EventTypeWarning {
+			continue
+		}
+		last := eventLastSeen(event)
+		seen = append(seen, seenEvent{
+			item: restartEventResponse{
+				Type:      event.Type,
+				Reason:    event.Reason,
+				Message:   event.Message,
+				Container: eventContainer(event.InvolvedObject.FieldPath),
+				Count:     event.Count,
+				FirstSeen: formatRestartTime(event.FirstTimestamp),
+				LastSeen:  formatRestartTime(metav1.Time{Time: last}),
+			},
+			lastSeen: last,
+		})
+	}
+	sort.SliceStable(seen, func(i, j int) bool {
+		return seen[i].lastSeen.After(seen[j].lastSeen)
+	})
+	items := make([]restartEventResponse, 0, len(seen))
+	for _, event := range seen {
+		items = append(items, event.item)
+	}
+	return items, nil
+}
+
+func eventLastSeen(event corev1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	if event.Series != nil && !event.Series.LastObservedTime.IsZero() {
+		return event.Series.LastObservedTime.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.FirstTimestamp.Time
+}
+
+// eventContainer extracts the container name from field paths such as
+// "spec.containers{app}" or "spec.initContainers{migrate}".
+func eventContainer(fieldPath string) string {
+	start := strings.IndexByte(fieldPath, '{')
+	end := strings.LastIndexByte(fieldPath, '}')
+	if start < 0 || end <= start {
+		return ""
+	}
+	return fieldPath[start+1 : end]
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type namespaceQuotaResponse struct {
+	Namespace   string                  `json:"namespace"`
+	Quotas      []resourceQuotaResponse `json:"quotas"`
+	LimitRanges []limitRangeResponse    `json:"limitRanges"`
+}
+
+type resourceQuotaResponse struct {
+	Name string            `json:"name"`
+	Hard map[string]string `json:"hard"`
+	Used map[string]string `json:"used"`
+}
+
+type limitRangeResponse struct {
+	Name   string                   `json:"name"`
+	Limits []limitRangeItemResponse `json:"limits"`
+}
+
+type limitRangeItemResponse struct {
+	Type           string            `json:"type"`
+	Default        map[string]string `json:"default,omitempty"`
+	DefaultRequest map[string]string `json:"defaultRequest,omitempty"`
+	Min            map[string]string `json:"min,omitempty"`
+	Max            map[string]string `json:"max,omitempty"`
+}
+
+func (h *KubeHandler) handleNamespaceQuota(w http.ResponseWriter, r *http.Request, namespace string) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	h.auditRead(r, "namespace_quota", namespace, "", nil)
+	ctx := r.Context()
+
+	quotas, err := h.listResourceQuotasCached(ctx, namespace)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	limitRanges, err := h.listLimitRangesCached(ctx, namespace)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	resp := namespaceQuotaResponse{
+		Namespace:   namespace,
+		Quotas:      make([]resourceQuotaResponse, 0, len(quotas)),
+		LimitRanges: make([]limitRangeResponse, 0, len(limitRanges)),
+	}
+	for _, quota := range quotas {
+		resp.Quotas = append(resp.Quotas, resourceQuotaResponse{
+			Name: quota.Name,
+			Hard: formatResourceList(quota.Status.Hard),
+			Used: formatResourceList(quota.Status.Used),
+		})
+	}
+	for _, lr := range limitRanges {
+		items := make([]limitRangeItemResponse, 0, len(lr.Spec.Limits))
+		for _, limit := range lr.Spec.Limits {
+			items = append(items, limitRangeItemResponse{
+				Type:           string(limit.Type),
+				Default:        formatResourceList(limit.Default),
+				DefaultRequest: formatResourceList(limit.DefaultRequest),
+				Min:            formatResourceList(limit.Min),
+				Max:            formatResourceList(limit.Max),
+			})
+		}
+		resp.LimitRanges = append(resp.LimitRanges, limitRangeResponse{Name: lr.Name, Limits: items})
+	}
+	sort.Slice(resp.Quotas, func(i, j int) bool { return resp.Quotas[i].Name < resp.Quotas[j].Name })
+	sort.Slice(resp.LimitRanges, func(i, j int) bool { return resp.LimitRanges[i].Name < resp.LimitRanges[j].Name })
+
+	writeJSON(w, r, resp)
+}
+
+func (h *KubeHandler) listResourceQuotasCached(ctx context.Context, namespace string) ([]corev1.ResourceQuota, error) {
+	if h.cache != nil {
+		return h.cache.doQuotas(namespace, func() ([]corev1.ResourceQuota, error) {
+			if items, ok := h.cache.getQuotas(namespace); ok {
+				return items, nil
+			}
+			items, err := listResourceQuotas(ctx, h.client, namespace, h.cache)
+			if err != nil {
+				return nil, err
+			}
+			h.cache.setQuotas(namespace, items)
+			return items, nil
+		})
+	}
+	return listResourceQuotas(ctx, h.client, namespace, h.cache)
+}
+
+func (h *KubeHandler) listLimitRangesCached(ctx context.Context, namespace string) ([]corev1.LimitRange, error) {
+	if h.cache != nil {
+		return h.cache.doLimitRanges(namespace, func() ([]corev1.LimitRange, error) {
+			if items, ok := h.cache.getLimitRanges(namespace); ok {
+				return items, nil
+			}
+			items, err := listLimitRanges(ctx, h.client, namespace, h.cache)
+			if err != nil {
+				return nil, err
+			}
+			h.cache.setLimitRanges(namespace, items)
+			return items, nil
+		})
+	}
+	return listLimitRanges(ctx, h.client, namespace, h.cache)
+}
+
+func listResourceQuotas(ctx context.Context, client kubernetes.Interface, namespace string, cache *resourceCache) ([]corev1.ResourceQuota, error) {
+	var list *corev1.ResourceQuotaList
+	err := retryK8s(ctx, cache, func(ctx context.Context) error {
+		var err error
+		list, err = client.CoreV1().ResourceQuotas(namespace).List(ctx, metav1.ListOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		return []corev1.ResourceQuota{}, nil
+	}
+	return list.Items, nil
+}
+
+func listLimitRanges(ctx context.Context, client kubernetes.Interface, namespace string, cache *resourceCache) ([]corev1.LimitRange, error) {
+	var list *corev1.LimitRangeList
+	err := retryK8s(ctx, cache, func(ctx context.Context) error {
+		var err error
+		list, err = client.CoreV1().LimitRanges(namespace).List(ctx, metav1.ListOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		return []corev1.LimitRange{}, nil
+	}
+	return list.Items, nil
+}
+
+func formatResourceList(list corev1.ResourceList) map[string]string {
+	out := make(map[string]string, len(list))
+	for name, qty := range list {
+		out[string(name)] = qty.String()
+	}
+	return out
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// rawClient fetches untyped API paths (CRDs, metrics.k8s.io) so it can be faked alongside client-go's fake clientset.
+type rawClient interface {
+	getRaw(ctx context.Context, path string) ([]byte, error)
+}
+
+type restRawClient struct {
+	rest rest.Interface
+}
+
+func newRawClient(client kubernetes.Interface) rawClient {
+	if client == nil {
+		return &restRawClient{}
+	}
+	discovery := client.Discovery()
+	if discovery == nil {
+		return &restRawClient{}
+	}
+	return &restRawClient{rest: discovery.RESTClient()}
+}
+
+func (c *restRawClient) getRaw(ctx context.Context, path string) ([]byte, error) {
+	if c == nil || c.rest == nil {
+		return nil, errors.New("k8s client unavailable")
+	}
+	return c.rest.Get().AbsPath(path).Do(ctx).Raw()
+}
@@ -18,6 +18,10 @@ import (
 type cacheEntry[T any] struct {
 	items   []T
 	fetched time.Time
+	// bytes is the approximate JSON size of items, filled in by the cache
+	// sizer the first time it sees the entry; sized says it has been.
+	bytes int64
+	sized bool
 }
 
 type resourceCache struct {
@@ -26,6 +30,8 @@ type resourceCache struct {
 	appTTL          time.Duration
 	crdTTL          time.Duration
 	metricsTTL      time.Duration
+	configMapTTL    time.Duration
+	secretTTL       time.Duration
 	retryCount      int
 	retryBase       time.Duration
 	stats           *ResourceStats
@@ -34,6 +40,8 @@ type resourceCache struct {
 	statefuls       map[string]cacheEntry[appsv1.StatefulSet]
 	cnpg            map[string]cacheEntry[cnpgCluster]
 	dragonfly       map[string]cacheEntry[dragonflyResource]
+	cnpgByName      map[string]cacheEntry[cnpgCluster]
+	dragonByName    map[string]cacheEntry[dragonflyResource]
 	metaPods        map[string]cacheEntry[metav1.PartialObjectMetadata]
 	metaDeps        map[string]cacheEntry[metav1.PartialObjectMetadata]
 	metaSts         map[string]cacheEntry[metav1.PartialObjectMetadata]
@@ -41,11 +49,17 @@ type resourceCache struct {
 	metaDragon      map[string]cacheEntry[metav1.PartialObjectMetadata]
 	metaCustom      map[string]cacheEntry[metav1.PartialObjectMetadata]
 	podMetrics      map[string]cacheEntry[podMetricItem]
+	quotas          map[string]cacheEntry[corev1.ResourceQuota]
+	limitRanges     map[string]cacheEntry[corev1.LimitRange]
+	configMaps      map[string]cacheEntry[corev1.ConfigMap]
+	secrets         map[string]cacheEntry[corev1.Secret]
 	podGroup        singleflight.Group
 	depGroup        singleflight.Group
 	stsGroup        singleflight.Group
 	cnpgGroup       singleflight.Group
 	dfGroup         singleflight.Group
+	cnpgGetGroup    singleflight.Group
+	dfGetGroup      singleflight.Group
 	metaPodGroup    singleflight.Group
 	metaDepGroup    singleflight.Group
 	metaStsGroup    singleflight.Group
@@ -53,29 +67,41 @@ type resourceCache struct {
 	metaDfGroup     singleflight.Group
 	metaCustomGroup singleflight.Group
 	podMetricsGroup singleflight.Group
+	quotaGroup      singleflight.Group
+	limitGroup      singleflight.Group
+	configMapGroup  singleflight.Group
+	secretGroup     singleflight.Group
 }
 
-func newResourceCache(podTTL, appTTL, crdTTL, metricsTTL time.Duration, retryCount int, retryBase time.Duration, stats *ResourceStats) *resourceCache {
+func newResourceCache(podTTL, appTTL, crdTTL, metricsTTL, configMapTTL, secretTTL time.Duration, retryCount int, retryBase time.Duration, stats *ResourceStats) *resourceCache {
 	return &resourceCache{
-		podTTL:      podTTL,
-		appTTL:      appTTL,
-		crdTTL:      crdTTL,
-		metricsTTL:  metricsTTL,
-		retryCount:  retryCount,
-		retryBase:   retryBase,
-		stats:       stats,
-		pods:        map[string]cacheEntry[corev1.Pod]{},
-		deployments: map[string]cacheEntry[appsv1.Deployment]{},
-		statefuls:   map[string]cacheEntry[appsv1.StatefulSet]{},
-		cnpg:        map[string]cacheEntry[cnpgCluster]{},
-		dragonfly:   map[string]cacheEntry[dragonflyResource]{},
-		metaPods:    map[string]cacheEntry[metav1.PartialObjectMetadata]{},
-		metaDeps:    map[string]cacheEntry[metav1.PartialObjectMetadata]{},
-		metaSts:     map[string]cacheEntry[metav1.PartialObjectMetadata]{},
-		metaCnpg:    map[string]cacheEntry[metav1.PartialObjectMetadata]{},
-		metaDragon:  map[string]cacheEntry[metav1.PartialObjectMetadata]{},
-		metaCustom:  map[string]cacheEntry[metav1.PartialObjectMetadata]{},
-		podMetrics:  map[string]cacheEntry[podMetricItem]{},
+		podTTL:       podTTL,
+		appTTL:       appTTL,
+		crdTTL:       crdTTL,
+		metricsTTL:   metricsTTL,
+		configMapTTL: configMapTTL,
+		secretTTL:    secretTTL,
+		retryCount:   retryCount,
+		retryBase:    retryBase,
+		stats:        stats,
+		pods:         map[string]cacheEntry[corev1.Pod]{},
+		deployments:  map[string]cacheEntry[appsv1.Deployment]{},
+		statefuls:    map[string]cacheEntry[appsv1.StatefulSet]{},
+		cnpg:         map[string]cacheEntry[cnpgCluster]{},
+		dragonfly:    map[string]cacheEntry[dragonflyResource]{},
+		cnpgByName:   map[string]cacheEntry[cnpgCluster]{},
+		dragonByName: map[string]cacheEntry[dragonflyResource]{},
+		metaPods:     map[string]cacheEntry[metav1.PartialObjectMetadata]{},
+		metaDeps:     map[string]cacheEntry[metav1.PartialObjectMetadata]{},
+		metaSts:      map[string]cacheEntry[metav1.PartialObjectMetadata]{},
+		metaCnpg:     map[string]cacheEntry[metav1.PartialObjectMetadata]{},
+		metaDragon:   map[string]cacheEntry[metav1.PartialObjectMetadata]{},
+		metaCustom:   map[string]cacheEntry[metav1.PartialObjectMetadata]{},
+		podMetrics:   map[string]cacheEntry[podMetricItem]{},
+		quotas:       map[string]cacheEntry[corev1.ResourceQuota]{},
+		limitRanges:  map[string]cacheEntry[corev1.LimitRange]{},
+		configMaps:   map[string]cacheEntry[corev1.ConfigMap]{},
+		secrets:      map[string]cacheEntry[corev1.Secret]{},
 	}
 }
 
@@ -119,6 +145,22 @@ func (c *resourceCache) setDragonfly(namespace string, items []dragonflyResource
 	setCache(c, c.dragonfly, namespace, items)
 }
 
+func (c *resourceCache) getCnpgByName(key string) ([]cnpgCluster, bool) {
+	return getCache(c, c.cnpgByName, key, c.crdTTL)
+}
+
+func (c *resourceCache) setCnpgByName(key string, items []cnpgCluster) {
+	setCache(c, c.cnpgByName, key, items)
+}
+
+func (c *resourceCache) getDragonflyByName(key string) ([]dragonflyResource, bool) {
+	return getCache(c, c.dragonByName, key, c.crdTTL)
+}
+
+func (c *resourceCache) setDragonflyByName(key string, items []dragonflyResource) {
+	setCache(c, c.dragonByName, key, items)
+}
+
 func (c *resourceCache) getMetaPods(namespace string) ([]metav1.PartialObjectMetadata, bool) {
 	return getCache(c, c.metaPods, namespace, c.podTTL)
 }
@@ -175,6 +217,22 @@ func (c *resourceCache) setPodMetrics(namespace string, items []podMetricItem) {
 	setCache(c, c.podMetrics, namespace, items)
 }
 
+func (c *resourceCache) getQuotas(namespace string) ([]corev1.ResourceQuota, bool) {
+	return getCache(c, c.quotas, namespace, c.appTTL)
+}
+
+func (c *resourceCache) setQuotas(namespace string, items []corev1.ResourceQuota) {
+	setCache(c, c.quotas, namespace, items)
+}
+
+func (c *resourceCache) getLimitRanges(namespace string) ([]corev1.LimitRange, bool) {
+	return getCache(c, c.limitRanges, namespace, c.appTTL)
+}
+
+func (c *resourceCache) setLimitRanges(namespace string, items []corev1.LimitRange) {
+	setCache(c, c.limitRanges, namespace, items)
+}
+
 func (c *resourceCache) getPodMetricsEntry(namespace string) (cacheEntry[podMetricItem], bool) {
 	c.mu.RLock()
 	entry, ok := c.podMetrics[namespace]
@@ -182,6 +240,101 @@ func (c *resourceCache) getPodMetricsEntry(namespace string) (cacheEntry[podMetr
 	return entry, ok
 }
 
+func (c *resourceCache) getConfigMap(key string) ([]corev1.ConfigMap, bool) {
+	if c.configMapTTL <= 0 {
+		return nil, false
+	}
+	return getCache(c, c.configMaps, key, c.configMapTTL)
+}
+
+func (c *resourceCache) setConfigMap(key string, items []corev1.ConfigMap) {
+	if c.configMapTTL <= 0 {
+		return
+	}
+	setCache(c, c.configMaps, key, items)
+}
+
+// Secrets are only cached for callers allowed to reveal them, and a
+// non-positive secretTTL disables the secret cache entirely. Unlike other
+// kinds, the cache keeps its own copies and evicts them as soon as they
+// expire, so Secret data is not held past the TTL.
+func (c *resourceCache) getSecret(key string) ([]corev1.Secret, bool) {
+	if c.secretTTL <= 0 {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.secrets[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Since(entry.fetched) > c.secretTTL {
+		c.evictSecretLocked(key, entry)
+		return nil, false
+	}
+	return copySecrets(entry.items), true
+}
+
+func (c *resourceCache) setSecret(key string, items []corev1.Secret) {
+	if c.secretTTL <= 0 {
+		return
+	}
+	setCache(c, c.secrets, key, copySecrets(items))
+}
+
+// sweepSecrets evicts every expired Secret entry, including ones that are
+// never read again.
+func (c *resourceCache) sweepSecrets() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.secrets {
+		if time.Since(entry.fetched) > c.secretTTL {
+			c.evictSecretLocked(key, entry)
+		}
+	}
+}
+
+// evictSecretLocked deletes an entry and zeroes the cache's copy of its data.
+// Callers only ever received copies, so nothing else shares these bytes.
+func (c *resourceCache) evictSecretLocked(key string, entry cacheEntry[corev1.Secret]) {
+	delete(c.secrets, key)
+	for i := range entry.items {
+		for _, value := range entry.items[i].Data {
+			clear(value)
+		}
+		entry.items[i].Data = nil
+		entry.items[i].StringData = nil
+	}
+}
+
+func copySecrets(items []corev1.Secret) []corev1.Secret {
+	copies := make([]corev1.Secret, len(items))
+	for i := range items {
+		items[i].DeepCopyInto(&copies[i])
+	}
+	return copies
+}
+
+// startSecretSweeper evicts expired Secret entries on the secret TTL cadence
+// until the handler stops.
+func (h *KubeHandler) startSecretSweeper() {
+	if h.cache == nil || h.cache.secretTTL <= 0 {
+		return
+	}
+	ticker := time.NewTicker(max(h.cache.secretTTL, time.Second))
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				h.cache.sweepSecrets()
+			case <-h.statsStop:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
 func getCache[T any](cache *resourceCache, store map[string]cacheEntry[T], namespace string, ttl time.Duration) ([]T, bool) {
 	cache.mu.RLock()
 	entry, ok := store[namespace]
@@ -256,6 +409,50 @@ func (c *resourceCache) doDragonfly(namespace string, fn func() ([]dragonflyReso
 	return items, nil
 }
 
+func (c *resourceCache) doCnpgGet(key string, fn func() (*cnpgCluster, error)) (*cnpgCluster, error) {
+	v, err, _ := c.cnpgGetGroup.Do(key, func() (any, error) {
+		return fn()
+	})
+	if err != nil {
+		return nil, err
+	}
+	cluster, _ := v.(*cnpgCluster)
+	return cluster, nil
+}
+
+func (c *resourceCache) doConfigMapGet(key string, fn func() (*corev1.ConfigMap, error)) (*corev1.ConfigMap, error) {
+	v, err, _ := c.configMapGroup.Do(key, func() (any, error) {
+		return fn()
+	})
+	if err != nil {
+		return nil, err
+	}
+	cfg, _ := v.(*corev1.ConfigMap)
+	return cfg, nil
+}
+
+func (c *resourceCache) doSecretGet(key string, fn func() (*corev1.Secret, error)) (*corev1.Secret, error) {
+	v, err, _ := c.secretGroup.Do(key, func() (any, error) {
+		return fn()
+	})
+	if err != nil {
+		return nil, err
+	}
+	secret, _ := v.(*corev1.Secret)
+	return secret, nil
+}
+
+func (c *resourceCache) doDragonflyGet(key string, fn func() (*dragonflyResource, error)) (*dragonflyResource, error) {
+	v, err, _ := c.dfGetGroup.Do(key, func() (any, error) {
+		return fn()
+	})
+	if err != nil {
+		return nil, err
+	}
+	dragonfly, _ := v.(*dragonflyResource)
+	return dragonfly, nil
+}
+
 func (c *resourceCache) doMetaPods(namespace string, fn func() ([]metav1.PartialObjectMetadata, error)) ([]metav1.PartialObjectMetadata, error) {
 	v, err, _ := c.metaPodGroup.Do(namespace, func() (any, error) {
 		return fn()
@@ -333,7 +530,29 @@ func (c *resourceCache) doPodMetrics(namespace string, fn func() ([]podMetricIte
 	return items, nil
 }
 
-func listPods(ctx context.Context, client *kubernetes.Clientset, namespace string, cache *resourceCache) ([]corev1.Pod, error) {
+func (c *resourceCache) doQuotas(namespace string, fn func() ([]corev1.ResourceQuota, error)) ([]corev1.ResourceQuota, error) {
+	v, err, _ := c.quotaGroup.Do(namespace, func() (any, error) {
+		return fn()
+	})
+	if err != nil {
+		return nil, err
+	}
+	items, _ := v.([]corev1.ResourceQuota)
+	return items, nil
+}
+
+func (c *resourceCache) doLimitRanges(namespace string, fn func() ([]corev1.LimitRange, error)) ([]corev1.LimitRange, error) {
+	v, err, _ := c.limitGroup.Do(namespace, func() (any, error) {
+		return fn()
+	})
+	if err != nil {
+		return nil, err
+	}
+	items, _ := v.([]corev1.LimitRange)
+	return items, nil
+}
+
+func listPods(ctx context.Context, client kubernetes.Interface, namespace string, cache *resourceCache) ([]corev1.Pod, error) {
 	var pods *corev1.PodList
 	if cache != nil && cache.stats != nil {
 		cache.stats.incPodsAPICall()
@@ -355,7 +574,7 @@ func listPods(ctx context.Context, client *kubernetes.Clientset, namespace strin
 	return pods.Items, nil
 }
 
-func listDeployments(ctx context.Context, client *kubernetes.Clientset, namespace string, cache *resourceCache) ([]appsv1.Deployment, error) {
+func listDeployments(ctx context.Context, client kubernetes.Interface, namespace string, cache *resourceCache) ([]appsv1.Deployment, error) {
 	var deployments *appsv1.DeploymentList
 	if cache != nil && cache.stats != nil {
 		cache.stats.incDepAPICall()
@@ -377,7 +596,7 @@ func listDeployments(ctx context.Context, client *kubernetes.Clientset, namespac
 	return deployments.Items, nil
 }
 
-func listStatefulSets(ctx context.Context, client *kubernetes.Clientset, namespace string, cache *resourceCache) ([]appsv1.StatefulSet, error) {
+func listStatefulSets(ctx context.Context, client kubernetes.Interface, namespace string, cache *resourceCache) ([]appsv1.StatefulSet, error) {
 	var sets *appsv1.StatefulSetList
 	if cache != nil && cache.stats != nil {
 		cache.stats.incStsAPICall()
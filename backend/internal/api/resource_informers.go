@@ -22,17 +22,21 @@ type namespaceInformers struct {
 	depInformer appinformers.DeploymentInformer
 	stsInformer appinformers.StatefulSetInformer
 	stop        chan struct{}
+	synced      chan struct{}
 	cacheSynced atomic.Bool
+	waitExpired atomic.Bool
 }
 
 type resourceInformers struct {
 	mu         sync.RWMutex
 	namespaces map[string]*namespaceInformers
+	syncWait   time.Duration
 }
 
-func newResourceInformers(client *kubernetes.Clientset, namespaces []string, resync time.Duration) *resourceInformers {
+func newResourceInformers(client kubernetes.Interface, namespaces []string, resync, syncWait time.Duration) *resourceInformers {
 	ri := &resourceInformers{
 		namespaces: make(map[string]*namespaceInformers, len(namespaces)),
+		syncWait:   syncWait,
 	}
 	for _, ns := range namespaces {
 		factory := informers.NewSharedInformerFactoryWithOptions(client, resync, informers.WithNamespace(ns))
@@ -43,6 +47,7 @@ func newResourceInformers(client *kubernetes.Clientset, namespaces []string, res
 			depInformer: factory.Apps().V1().Deployments(),
 			stsInformer: factory.Apps().V1().StatefulSets(),
 			stop:        make(chan struct{}),
+			synced:      make(chan struct{}),
 		}
 		ri.namespaces[ns] = nsInf
 	}
@@ -63,6 +68,7 @@ func (r *resourceInformers) Start() {
 			)
 			if synced {
 				inf.cacheSynced.Store(true)
+				close(inf.synced)
 			}
 		}(nsInf)
 	}
@@ -82,7 +88,7 @@ func (r *resourceInformers) Stop() {
 
 func (r *resourceInformers) listPods(namespace string) ([]corev1.Pod, bool) {
 	nsInf := r.getNamespace(namespace)
-	if nsInf == nil || !nsInf.cacheSynced.Load() {
+	if !r.waitForSync(nsInf) {
 		return nil, false
 	}
 	items, err := nsInf.podInformer.Lister().List(labels.Everything())
@@ -94,7 +100,7 @@ func (r *resourceInformers) listPods(namespace string) ([]corev1.Pod, bool) {
 
 func (r *resourceInformers) listPodsBySelector(namespace string, selector labels.Selector) ([]corev1.Pod, bool) {
 	nsInf := r.getNamespace(namespace)
-	if nsInf == nil || !nsInf.cacheSynced.Load() {
+	if !r.waitForSync(nsInf) {
 		return nil, false
 	}
 	items, err := nsInf.podInformer.Lister().List(selector)
@@ -106,7 +112,7 @@ func (r *resourceInformers) listPodsBySelector(namespace string, selector labels
 
 func (r *resourceInformers) listDeployments(namespace string) ([]appsv1.Deployment, bool) {
 	nsInf := r.getNamespace(namespace)
-	if nsInf == nil || !nsInf.cacheSynced.Load() {
+	if !r.waitForSync(nsInf) {
 		return nil, false
 	}
 	items, err := nsInf.depInformer.Lister().List(labels.Everything())
@@ -118,7 +124,7 @@ func (r *resourceInformers) listDeployments(namespace string) ([]appsv1.Deployme
 
 func (r *resourceInformers) listStatefulSets(namespace string) ([]appsv1.StatefulSet, bool) {
 	nsInf := r.getNamespace(namespace)
-	if nsInf == nil || !nsInf.cacheSynced.Load() {
+	if !r.waitForSync(nsInf) {
 		return nil, false
 	}
 	items, err := nsInf.stsInformer.Lister().List(labels.Everything())
@@ -128,6 +134,58 @@ func (r *resourceInformers) listStatefulSets(namespace string) ([]appsv1.Statefu
 	return derefStatefulSets(items), true
 }
 
+func (r *resourceInformers) watchPods(namespace string, notify func()) func() {
+	nsInf := r.getNamespace(namespace)
+	if nsInf == nil {
+		return nil
+	}
+	informer := nsInf.podInformer.Informer()
+	registration, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			notify()
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			oldPod, ok1 := oldObj.(*corev1.Pod)
+			newPod, ok2 := newObj.(*corev1.Pod)
+			if !ok1 || !ok2 || oldPod.Status.Phase != newPod.Status.Phase {
+				notify()
+			}
+		},
+		DeleteFunc: func(obj any) {
+			notify()
+		},
+	})
+	if err != nil {
+		return nil
+	}
+	return func() {
+		_ = informer.RemoveEventHandler(registration)
+	}
+}
+
+func (r *resourceInformers) waitForSync(nsInf *namespaceInformers) bool {
+	if nsInf == nil {
+		return false
+	}
+	if nsInf.cacheSynced.Load() {
+		return true
+	}
+	if r.syncWait <= 0 || nsInf.waitExpired.Load() {
+		return false
+	}
+	timer := time.NewTimer(r.syncWait)
+	defer timer.Stop()
+	select {
+	case <-nsInf.synced:
+		return true
+	case <-timer.C:
+		nsInf.waitExpired.Store(true)
+		return false
+	case <-nsInf.stop:
+		return false
+	}
+}
+
 func (r *resourceInformers) getNamespace(namespace string) *namespaceInformers {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"sort"
 	"sync"
 	"sync/atomic"
 
@@ -31,9 +32,24 @@ type ResourceStats struct {
 	dragonCacheMiss int64
 	dragonAPICall   int64
 	dragonAPIErr    int64
+	metricsHit      int64
+	metricsMiss     int64
+	metricsAPICall  int64
+	metricsAPIErr   int64
 	throttleRetries int64
+	auditDropped    int64
 	mu              sync.Mutex
 	last            ResourceStatsSnapshot
+	trackedMu       sync.Mutex
+	trackedPods     map[string]int
+	trackedApps     map[string]map[string]int
+	cacheSizes      []cacheSizeStat
+}
+
+type trackedCount struct {
+	namespace string
+	kind      string
+	count     int
 }
 
 type ResourceStatsSnapshot struct {
@@ -60,7 +76,12 @@ type ResourceStatsSnapshot struct {
 	dragonCacheMiss int64
 	dragonAPICall   int64
 	dragonAPIErr    int64
+	metricsHit      int64
+	metricsMiss     int64
+	metricsAPICall  int64
+	metricsAPIErr   int64
 	throttleRetries int64
+	auditDropped    int64
 }
 
 func newResourceStats() *ResourceStats {
@@ -101,8 +122,78 @@ func (s *ResourceStats) incDragonCacheMiss() { atomic.AddInt64(&s.dragonCacheMis
 func (s *ResourceStats) incDragonAPICall()   { atomic.AddInt64(&s.dragonAPICall, 1) }
 func (s *ResourceStats) incDragonAPIErr()    { atomic.AddInt64(&s.dragonAPIErr, 1) }
 
+func (s *ResourceStats) incMetricsCacheHit()  { atomic.AddInt64(&s.metricsHit, 1) }
+func (s *ResourceStats) incMetricsCacheMiss() { atomic.AddInt64(&s.metricsMiss, 1) }
+func (s *ResourceStats) incMetricsAPICall()   { atomic.AddInt64(&s.metricsAPICall, 1) }
+func (s *ResourceStats) incMetricsAPIErr()    { atomic.AddInt64(&s.metricsAPIErr, 1) }
+
 func (s *ResourceStats) incThrottleRetry() { atomic.AddInt64(&s.throttleRetries, 1) }
 
+func (s *ResourceStats) addAuditDropped(n int64) { atomic.AddInt64(&s.auditDropped, n) }
+
+func (s *ResourceStats) setTrackedPods(namespace string, count int) {
+	if s == nil {
+		return
+	}
+	s.trackedMu.Lock()
+	defer s.trackedMu.Unlock()
+	if s.trackedPods == nil {
+		s.trackedPods = make(map[string]int)
+	}
+	s.trackedPods[namespace] = count
+}
+
+func (s *ResourceStats) setTrackedApps(namespace string, counts map[string]int) {
+	if s == nil {
+		return
+	}
+	s.trackedMu.Lock()
+	defer s.trackedMu.Unlock()
+	if s.trackedApps == nil {
+		s.trackedApps = make(map[string]map[string]int)
+	}
+	s.trackedApps[namespace] = counts
+}
+
+func (s *ResourceStats) setCacheSizes(sizes []cacheSizeStat) {
+	s.trackedMu.Lock()
+	s.cacheSizes = sizes
+	s.trackedMu.Unlock()
+}
+
+func (s *ResourceStats) cacheSizeSnapshot() []cacheSizeStat {
+	s.trackedMu.Lock()
+	defer s.trackedMu.Unlock()
+	return s.cacheSizes
+}
+
+func (s *ResourceStats) trackedSnapshot() ([]trackedCount, []trackedCount) {
+	s.trackedMu.Lock()
+	pods := make([]trackedCount, 0, len(s.trackedPods))
+	for ns, count := range s.trackedPods {
+		pods = append(pods, trackedCount{namespace: ns, count: count})
+	}
+	apps := []trackedCount{}
+	for ns, kinds := range s.trackedApps {
+		for kind, count := range kinds {
+			apps = append(apps, trackedCount{namespace: ns, kind: kind, count: count})
+		}
+	}
+	s.trackedMu.Unlock()
+	sortTracked(pods)
+	sortTracked(apps)
+	return pods, apps
+}
+
+func sortTracked(items []trackedCount) {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].namespace != items[j].namespace {
+			return items[i].namespace < items[j].namespace
+		}
+		return items[i].kind < items[j].kind
+	})
+}
+
 func (s *ResourceStats) snapshot() ResourceStatsSnapshot {
 	return ResourceStatsSnapshot{
 		podsCacheHit:    atomic.LoadInt64(&s.podsCacheHit),
@@ -128,7 +219,12 @@ func (s *ResourceStats) snapshot() ResourceStatsSnapshot {
 		dragonCacheMiss: atomic.LoadInt64(&s.dragonCacheMiss),
 		dragonAPICall:   atomic.LoadInt64(&s.dragonAPICall),
 		dragonAPIErr:    atomic.LoadInt64(&s.dragonAPIErr),
+		metricsHit:      atomic.LoadInt64(&s.metricsHit),
+		metricsMiss:     atomic.LoadInt64(&s.metricsMiss),
+		metricsAPICall:  atomic.LoadInt64(&s.metricsAPICall),
+		metricsAPIErr:   atomic.LoadInt64(&s.metricsAPIErr),
 		throttleRetries: atomic.LoadInt64(&s.throttleRetries),
+		auditDropped:    atomic.LoadInt64(&s.auditDropped),
 	}
 }
 
@@ -138,6 +234,7 @@ func (s ResourceStatsSnapshot) total() int64 {
 		s.stsCacheHit + s.stsCacheMiss + s.stsInformerHit + s.stsAPICall + s.stsAPIErr +
 		s.cnpgCacheHit + s.cnpgCacheMiss + s.cnpgAPICall + s.cnpgAPIErr +
 		s.dragonCacheHit + s.dragonCacheMiss + s.dragonAPICall + s.dragonAPIErr +
+		s.metricsHit + s.metricsMiss + s.metricsAPICall + s.metricsAPIErr +
 		s.throttleRetries
 }
 
@@ -174,6 +271,10 @@ func (s *ResourceStats) logSnapshot() {
 		"dragon_miss", delta.dragonCacheMiss,
 		"dragon_api", delta.dragonAPICall,
 		"dragon_err", delta.dragonAPIErr,
+		"metrics_hit", delta.metricsHit,
+		"metrics_miss", delta.metricsMiss,
+		"metrics_api", delta.metricsAPICall,
+		"metrics_err", delta.metricsAPIErr,
 		"throttle_retries", delta.throttleRetries,
 	)
 }
@@ -203,6 +304,10 @@ func (s ResourceStatsSnapshot) diff(prev ResourceStatsSnapshot) ResourceStatsSna
 		dragonCacheMiss: s.dragonCacheMiss - prev.dragonCacheMiss,
 		dragonAPICall:   s.dragonAPICall - prev.dragonAPICall,
 		dragonAPIErr:    s.dragonAPIErr - prev.dragonAPIErr,
+		metricsHit:      s.metricsHit - prev.metricsHit,
+		metricsMiss:     s.metricsMiss - prev.metricsMiss,
+		metricsAPICall:  s.metricsAPICall - prev.metricsAPICall,
+		metricsAPIErr:   s.metricsAPIErr - prev.metricsAPIErr,
 		throttleRetries: s.throttleRetries - prev.throttleRetries,
 	}
 }
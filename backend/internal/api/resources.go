@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,10 +19,13 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/client-go/kubernetes"
+
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/halceonio/kubelens/backend/internal/auth"
 	"github.com/halceonio/kubelens/backend/internal/config"
+	"github.com/halceonio/kubelens/backend/internal/tracing"
 )
 
 type namespaceResponse struct {
@@ -28,14 +33,31 @@ type namespaceResponse struct {
 }
 
 type resourceUsage struct {
-	CPUUsage          string `json:"cpuUsage"`
-	CPURequest        string `json:"cpuRequest"`
-	CPULimit          string `json:"cpuLimit"`
-	MemUsage          string `json:"memUsage"`
-	MemRequest        string `json:"memRequest"`
-	MemLimit          string `json:"memLimit"`
-	MetricsAgeSeconds int    `json:"metricsAgeSeconds,omitempty"`
-	MetricsStale      bool   `json:"metricsStale,omitempty"`
+	CPUUsage          string       `json:"cpuUsage"`
+	CPURequest        string       `json:"cpuRequest"`
+	CPULimit          string       `json:"cpuLimit"`
+	MemUsage          string       `json:"memUsage"`
+	MemRequest        string       `json:"memRequest"`
+	MemLimit          string       `json:"memLimit"`
+	MetricsAgeSeconds int          `json:"metricsAgeSeconds,omitempty"`
+	MetricsStale      bool         `json:"metricsStale,omitempty"`
+	CPUUtilPercent    *utilPercent `json:"cpuUtilPercent,omitempty"`
+	MemUtilPercent    *utilPercent `json:"memUtilPercent,omitempty"`
+	// Extended holds every other requested or limited resource, keyed by
+	// resource name such as nvidia.com/gpu or hugepages-2Mi.
+	Extended map[string]extendedResource `json:"extended,omitempty"`
+}
+
+type extendedResource struct {
+	Request string `json:"request,omitempty"`
+	Limit   string `json:"limit,omitempty"`
+}
+
+// utilPercent is usage as a percentage of the request and of the limit. A side
+// is null when nothing was requested or limited.
+type utilPercent struct {
+	Request *float64 `json:"request"`
+	Limit   *float64 `json:"limit"`
 }
 
 type podMetricItem struct {
@@ -61,18 +83,19 @@ func (m *metricsSnapshot) usageForPod(podName string) (resource.Quantity, resour
 	return metric.CPU, metric.Mem, true
 }
 
-func (m *metricsSnapshot) usageForPods(podNames []string) (resource.Quantity, resource.Quantity, bool) {
+// usageForPods sums usage over podNames and reports how many had metrics.
+func (m *metricsSnapshot) usageForPods(podNames []string) (resource.Quantity, resource.Quantity, int) {
 	if m == nil || len(m.items) == 0 || len(podNames) == 0 {
-		return resource.Quantity{}, resource.Quantity{}, false
+		return resource.Quantity{}, resource.Quantity{}, 0
 	}
 	totalCPU := resource.Quantity{}
 	totalMem := resource.Quantity{}
-	found := false
+	found := 0
 	for _, name := range podNames {
 		if metric, ok := m.items[name]; ok {
 			totalCPU.Add(metric.CPU)
 			totalMem.Add(metric.Mem)
-			found = true
+			found++
 		}
 	}
 	return totalCPU, totalMem, found
@@ -86,49 +109,46 @@ func applyMetricsMeta(usage *resourceUsage, snapshot *metricsSnapshot) {
 	usage.MetricsStale = snapshot.stale
 }
 
-func formatCPUUsage(q resource.Quantity) string {
-	if q.IsZero() {
-		return ""
+// applyUsage sets usage from metrics summed over pods pods, each sized by
+// requests and limits, and derives utilization from the quantities directly so
+// milli-CPU and binary memory units never need converting by hand.
+func (u *resourceUsage) applyUsage(cpu, mem resource.Quantity, pods int, requests, limits resourceTotals) {
+	u.CPUUsage = formatCPU(cpu)
+	u.MemUsage = formatMemory(mem)
+	u.CPUUtilPercent = &utilPercent{Request: percentOf(cpu, requests.cpu, pods), Limit: percentOf(cpu, limits.cpu, pods)}
+	u.MemUtilPercent = &utilPercent{Request: percentOf(mem, requests.mem, pods), Limit: percentOf(mem, limits.mem, pods)}
+}
+
+// percentOf returns usage / (per-pod total * pods) as a percentage rounded to
+// one decimal, or nil when the total is zero.
+func percentOf(usage, total resource.Quantity, pods int) *float64 {
+	if total.IsZero() || pods <= 0 {
+		return nil
 	}
+	pct := usage.AsApproximateFloat64() / (total.AsApproximateFloat64() * float64(pods)) * 100
+	pct = math.Round(pct*10) / 10
+	return &pct
+}
+
+// formatCPU renders CPU in millicores regardless of how the quantity was
+// written or summed, so "1", "1000m", and 0.5+0.5 all read as "1000m".
+func formatCPU(q resource.Quantity) string {
 	milli := q.MilliValue()
-	if milli == 0 {
+	if milli <= 0 {
 		return ""
 	}
-	if milli < 1000 {
-		return fmt.Sprintf("%dm", milli)
-	}
-	if milli%1000 == 0 {
-		return fmt.Sprintf("%dc", milli/1000)
-	}
-	return fmt.Sprintf("%.2fc", float64(milli)/1000.0)
+	return strconv.FormatInt(milli, 10) + "m"
 }
 
-func formatMemUsage(q resource.Quantity) string {
-	if q.IsZero() {
-		return ""
-	}
+// formatMemory renders memory in Mi with at most two decimals, so "1.5Gi",
+// "1536Mi", and "1610612736" all read as "1536Mi".
+func formatMemory(q resource.Quantity) string {
 	bytes := q.Value()
-	if bytes == 0 {
+	if bytes <= 0 {
 		return ""
 	}
-	const (
-		ki = 1024
-		mi = 1024 * ki
-		gi = 1024 * mi
-		ti = 1024 * gi
-	)
-	switch {
-	case bytes >= ti:
-		return fmt.Sprintf("%.2fTi", float64(bytes)/ti)
-	case bytes >= gi:
-		return fmt.Sprintf("%.2fGi", float64(bytes)/gi)
-	case bytes >= mi:
-		return fmt.Sprintf("%.2fMi", float64(bytes)/mi)
-	case bytes >= ki:
-		return fmt.Sprintf("%.2fKi", float64(bytes)/ki)
-	default:
-		return fmt.Sprintf("%dB", bytes)
-	}
+	mib := math.Round(float64(bytes)/(1024*1024)*100) / 100
+	return strconv.FormatFloat(mib, 'f', -1, 64) + "Mi"
 }
 
 func formatQuantityOrEmpty(q resource.Quantity) string {
@@ -143,6 +163,11 @@ type containerResponse struct {
 	Image        string `json:"image"`
 	Ready        bool   `json:"ready"`
 	RestartCount int32  `json:"restartCount"`
+	SpecImage    string `json:"specImage,omitempty"`
+	ImageDigest  string `json:"imageDigest,omitempty"`
+	PullState    string `json:"pullState,omitempty"`
+	OOMKilled    bool   `json:"oomKilled,omitempty"`
+	ExitCode     *int32 `json:"exitCode,omitempty"`
 }
 
 type volumeMountResponse struct {
@@ -161,12 +186,16 @@ type podResponse struct {
 	Annotations  map[string]string     `json:"annotations"`
 	Env          map[string]string     `json:"env"`
 	EnvSecrets   []string              `json:"envSecrets"`
+	EnvTruncated bool                  `json:"envTruncated,omitempty"`
+	EnvOmitted   bool                  `json:"envOmitted,omitempty"`
 	Containers   []containerResponse   `json:"containers"`
 	Volumes      []volumeMountResponse `json:"volumes"`
 	Secrets      []string              `json:"secrets"`
 	ConfigMaps   []string              `json:"configMaps"`
 	Resources    resourceUsage         `json:"resources"`
 	OwnerApp     string                `json:"ownerApp,omitempty"`
+	QOSClass     string                `json:"qosClass,omitempty"`
+	EvictionRisk string                `json:"evictionRisk,omitempty"`
 	Light        bool                  `json:"light,omitempty"`
 	MetadataOnly bool                  `json:"metadataOnly,omitempty"`
 }
@@ -178,20 +207,33 @@ type appResponse struct {
 	Replicas      int32                 `json:"replicas"`
 	ReadyReplicas int32                 `json:"readyReplicas"`
 	PodNames      []string              `json:"podNames"`
+	Pods          []podResponse         `json:"pods,omitempty"`
 	Labels        map[string]string     `json:"labels"`
 	Annotations   map[string]string     `json:"annotations"`
 	Env           map[string]string     `json:"env"`
 	EnvSecrets    []string              `json:"envSecrets"`
+	EnvTruncated  bool                  `json:"envTruncated,omitempty"`
+	EnvOmitted    bool                  `json:"envOmitted,omitempty"`
 	Resources     resourceUsage         `json:"resources"`
 	Volumes       []volumeMountResponse `json:"volumes"`
 	Secrets       []string              `json:"secrets"`
 	ConfigMaps    []string              `json:"configMaps"`
 	Containers    []containerResponse   `json:"containers,omitempty"`
 	Image         string                `json:"image,omitempty"`
+	HasOOMKills   bool                  `json:"hasOOMKills,omitempty"`
+	ImageDrift    bool                  `json:"imageDrift,omitempty"`
+	DriftDetails  []imageDriftResponse  `json:"imageDriftDetails,omitempty"`
 	Light         bool                  `json:"light,omitempty"`
 	MetadataOnly  bool                  `json:"metadataOnly,omitempty"`
 }
 
+type imageDriftResponse struct {
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	Expected  string `json:"expected"`
+	Running   string `json:"running"`
+}
+
 const (
 	cnpgClusterListPathFmt = "/apis/postgresql.cnpg.io/v1/namespaces/%s/clusters"
 	cnpgClusterGetPathFmt  = "/apis/postgresql.cnpg.io/v1/namespaces/%s/clusters/%s"
@@ -246,15 +288,14 @@ type dragonflyStatus struct {
 }
 
 func (h *KubeHandler) handleNamespaces(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	if !allowMethods(w, r, http.MethodGet) {
 		return
 	}
 	resp := make([]namespaceResponse, 0, len(h.cfg.Kubernetes.AllowedNamespaces))
 	for _, ns := range h.cfg.Kubernetes.AllowedNamespaces {
 		resp = append(resp, namespaceResponse{Name: ns})
 	}
-	writeJSON(w, resp)
+	writeJSON(w, r, resp)
 }
 
 func (h *KubeHandler) handlePods(w http.ResponseWriter, r *http.Request, namespace string, parts []string) {
@@ -270,11 +311,20 @@ func (h *KubeHandler) handlePods(w http.ResponseWriter, r *http.Request, namespa
 	sub := parts[1]
 	switch sub {
 	case "logs":
+		if !h.requireLogs(w) {
+			return
+		}
 		h.streamPodLogs(w, r, namespace, name)
 	case "details":
 		h.handlePodDetails(w, r, namespace, name)
 	case "metrics":
+		if len(parts) > 2 && parts[2] == "history" {
+			h.handlePodMetricsHistory(w, r, namespace, name)
+			return
+		}
 		h.handlePodMetrics(w, r, namespace, name)
+	case "restarts":
+		h.handlePodRestarts(w, r, namespace, name)
 	default:
 		http.NotFound(w, r)
 	}
@@ -293,6 +343,17 @@ func (h *KubeHandler) handleApps(w http.ResponseWriter, r *http.Request, namespa
 	sub := parts[1]
 	switch sub {
 	case "logs":
+		if !h.requireLogs(w) {
+			return
+		}
+		if len(parts) > 2 {
+			if len(parts) == 3 && parts[2] == "search" {
+				h.searchAppLogs(w, r, namespace, name)
+				return
+			}
+			http.NotFound(w, r)
+			return
+		}
 		h.streamAppLogs(w, r, namespace, name)
 	default:
 		http.NotFound(w, r)
@@ -300,11 +361,10 @@ func (h *KubeHandler) handleApps(w http.ResponseWriter, r *http.Request, namespa
 }
 
 func (h *KubeHandler) handlePodsList(w http.ResponseWriter, r *http.Request, namespace string) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	if !allowMethods(w, r, http.MethodGet) {
 		return
 	}
-	h.audit(r, "pods_list", namespace, "", nil)
+	h.auditRead(r, "pods_list", namespace, "", nil)
 	includeMetrics := wantsMetrics(r)
 	light := wantsLight(r)
 	if includeMetrics {
@@ -320,47 +380,83 @@ func (h *KubeHandler) handlePodsList(w http.ResponseWriter, r *http.Request, nam
 			metrics = metricsSnap
 		}
 	}
+	var stream *ndjsonWriter
+	var resp []podResponse
+	var streamErr error
+	emit := func(item podResponse) {
+		if stream == nil {
+			resp = append(resp, item)
+			return
+		}
+		if streamErr != nil {
+			return
+		}
+		h.maskPodResponse(r, &item)
+		streamErr = stream.write(item)
+	}
 	if metadataOnly {
 		items, err := h.listPodsMetadataCached(r.Context(), namespace)
 		if err != nil {
 			writeError(w, http.StatusBadGateway, err.Error())
 			return
 		}
-		resp := make([]podResponse, 0, len(items))
+		allowed := make([]metav1.PartialObjectMetadata, 0, len(items))
 		for _, pod := range items {
-			if !h.allowPod(&corev1.Pod{ObjectMeta: pod.ObjectMeta}) {
-				continue
+			if h.allowPod(&corev1.Pod{ObjectMeta: pod.ObjectMeta}) {
+				allowed = append(allowed, pod)
 			}
-			resp = append(resp, h.mapPodMetadata(pod))
 		}
-		writeJSON(w, resp)
-		return
-	}
-	pods, err := h.listPodsCached(r.Context(), namespace)
-	if err != nil {
-		writeError(w, http.StatusBadGateway, err.Error())
-		return
-	}
-	resp := make([]podResponse, 0, len(pods))
-	for _, pod := range pods {
-		if !h.allowPod(&pod) {
-			continue
+		h.stats.setTrackedPods(namespace, len(allowed))
+		allowed = truncateList(w, allowed, h.cfg.Kubernetes.MaxListItems)
+		if wantsNDJSON(r) {
+			stream = newNDJSONWriter(w)
+		} else {
+			resp = make([]podResponse, 0, len(allowed))
+		}
+		for _, pod := range allowed {
+			emit(h.mapPodMetadata(pod))
+		}
+	} else {
+		pods, err := h.listPodsCached(r.Context(), namespace)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		allowed := make([]corev1.Pod, 0, len(pods))
+		for _, pod := range pods {
+			if h.allowPod(&pod) {
+				allowed = append(allowed, pod)
+			}
 		}
-		if light {
-			resp = append(resp, h.mapPodLite(&pod))
+		h.stats.setTrackedPods(namespace, len(allowed))
+		allowed = truncateList(w, allowed, h.cfg.Kubernetes.MaxListItems)
+		if wantsNDJSON(r) {
+			stream = newNDJSONWriter(w)
 		} else {
-			resp = append(resp, h.mapPod(&pod, false, nil, false, metrics))
+			resp = make([]podResponse, 0, len(allowed))
+		}
+		for _, pod := range allowed {
+			if light {
+				emit(h.mapPodLite(&pod))
+			} else {
+				emit(h.mapPod(r.Context(), &pod, false, nil, false, envOmit, metrics))
+			}
 		}
 	}
-	writeJSON(w, resp)
+	if stream != nil {
+		stream.flush()
+		return
+	}
+	h.maskPodResponses(r, resp)
+	writeJSON(w, r, resp)
 }
 
 func (h *KubeHandler) handlePodGet(w http.ResponseWriter, r *http.Request, namespace, name string) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	if !allowMethods(w, r, http.MethodGet) {
 		return
 	}
-	h.audit(r, "pod_get", namespace, name, nil)
+	h.auditRead(r, "pod_get", namespace, name, nil)
+	h.auditSecretReveal(r, namespace, name)
 	pod, err := h.client.CoreV1().Pods(namespace).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
 		writeError(w, http.StatusNotFound, err.Error())
@@ -380,15 +476,17 @@ func (h *KubeHandler) handlePodGet(w http.ResponseWriter, r *http.Request, names
 			metrics = metricsSnap
 		}
 	}
-	writeJSON(w, h.mapPod(pod, false, user, wantsRevealSecrets(r), metrics))
+	resp := h.mapPod(r.Context(), pod, false, user, wantsRevealSecrets(r), envResolve, metrics)
+	h.maskPodResponse(r, &resp)
+	writeJSON(w, r, resp)
 }
 
 func (h *KubeHandler) handlePodDetails(w http.ResponseWriter, r *http.Request, namespace, name string) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	if !allowMethods(w, r, http.MethodGet) {
 		return
 	}
-	h.audit(r, "pod_details", namespace, name, nil)
+	h.auditRead(r, "pod_details", namespace, name, nil)
+	h.auditSecretReveal(r, namespace, name)
 	pod, err := h.client.CoreV1().Pods(namespace).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
 		writeError(w, http.StatusNotFound, err.Error())
@@ -409,26 +507,27 @@ func (h *KubeHandler) handlePodDetails(w http.ResponseWriter, r *http.Request, n
 			metrics = metricsSnap
 		}
 	}
-	writeJSON(w, h.mapPod(pod, true, user, wantsRevealSecrets(r), metrics))
+	resp := h.mapPod(r.Context(), pod, true, user, wantsRevealSecrets(r), envResolve, metrics)
+	h.maskPodResponse(r, &resp)
+	writeJSON(w, r, resp)
 }
 
 func (h *KubeHandler) handleAppsList(w http.ResponseWriter, r *http.Request, namespace string) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	if !allowMethods(w, r, http.MethodGet) {
 		return
 	}
-	h.audit(r, "apps_list", namespace, "", nil)
+	h.auditRead(r, "apps_list", namespace, "", nil)
 	ctx := r.Context()
 	resp := []appResponse{}
 	includeMetrics := wantsMetrics(r)
+	// Like metrics, expand=pods needs the full items and pod snapshot, so
+	// either overrides light.
+	expandPods := wantsExpand(r, "pods")
 	light := wantsLight(r)
-	if includeMetrics {
+	if includeMetrics || expandPods {
 		light = false
 	}
 	metadataOnly := light && h.cfg.Kubernetes.APICache.MetadataOnly && h.metaClient != nil
-	if includeMetrics {
-		metadataOnly = false
-	}
 
 	var podSnapshot []corev1.Pod
 	if !light {
@@ -443,12 +542,73 @@ func (h *KubeHandler) handleAppsList(w http.ResponseWriter, r *http.Request, nam
 		}
 	}
 
-	if metadataOnly {
-		deployments, err := h.listDeploymentsMetadataCached(ctx, namespace)
+	fetchers := []func(context.Context, string, bool, bool, []corev1.Pod, *metricsSnapshot) ([]appResponse, error){
+		h.listDeploymentApps,
+		h.listStatefulSetApps,
+		h.listCnpgApps,
+		h.listDragonflyApps,
+	}
+	results := make([][]appResponse, len(fetchers))
+	group, groupCtx := errgroup.WithContext(ctx)
+	for i, fetch := range fetchers {
+		group.Go(func() error {
+			items, err := fetch(groupCtx, namespace, metadataOnly, light, podSnapshot, metrics)
+			if err != nil {
+				return err
+			}
+			results[i] = items
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	for _, items := range results {
+		resp = append(resp, items...)
+	}
+
+	for _, crd := range h.enabledCustomResources() {
+		items, err := h.listCustomResourcesMetadataCached(ctx, namespace, crd)
 		if err != nil {
 			writeError(w, http.StatusBadGateway, err.Error())
 			return
 		}
+		for _, item := range items {
+			if !h.allowApp(item.Name, item.Labels) {
+				continue
+			}
+			resp = append(resp, h.mapCustomResourceMetadata(crd, item))
+		}
+	}
+
+	h.trackApps(namespace, resp)
+	resp = truncateList(w, resp, h.cfg.Kubernetes.MaxListItems)
+	if expandPods && podSnapshot != nil {
+		h.attachPodSummaries(resp, podSnapshot)
+	}
+
+	h.maskAppResponses(r, resp)
+	if wantsNDJSON(r) {
+		stream := newNDJSONWriter(w)
+		for _, app := range resp {
+			if err := stream.write(app); err != nil {
+				return
+			}
+		}
+		stream.flush()
+		return
+	}
+	writeJSON(w, r, resp)
+}
+
+func (h *KubeHandler) listDeploymentApps(ctx context.Context, namespace string, metadataOnly, light bool, podSnapshot []corev1.Pod, metrics *metricsSnapshot) ([]appResponse, error) {
+	resp := []appResponse{}
+	if metadataOnly {
+		deployments, err := h.listDeploymentsMetadataCached(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
 		for _, dep := range deployments {
 			if !h.allowApp(dep.Name, dep.Labels) {
 				continue
@@ -458,8 +618,7 @@ func (h *KubeHandler) handleAppsList(w http.ResponseWriter, r *http.Request, nam
 	} else {
 		deployments, err := h.listDeploymentsCached(ctx, namespace)
 		if err != nil {
-			writeError(w, http.StatusBadGateway, err.Error())
-			return
+			return nil, err
 		}
 		for _, dep := range deployments {
 			if !h.allowApp(dep.Name, dep.Labels) {
@@ -468,16 +627,19 @@ func (h *KubeHandler) handleAppsList(w http.ResponseWriter, r *http.Request, nam
 			if light {
 				resp = append(resp, h.mapDeploymentLite(&dep))
 			} else {
-				resp = append(resp, h.mapDeployment(ctx, &dep, nil, false, podSnapshot, metrics))
+				resp = append(resp, h.mapDeployment(ctx, &dep, nil, false, envOmit, podSnapshot, metrics))
 			}
 		}
 	}
+	return resp, nil
+}
 
+func (h *KubeHandler) listStatefulSetApps(ctx context.Context, namespace string, metadataOnly, light bool, podSnapshot []corev1.Pod, metrics *metricsSnapshot) ([]appResponse, error) {
+	resp := []appResponse{}
 	if metadataOnly {
 		statefulSets, err := h.listStatefulSetsMetadataCached(ctx, namespace)
 		if err != nil {
-			writeError(w, http.StatusBadGateway, err.Error())
-			return
+			return nil, err
 		}
 		for _, sts := range statefulSets {
 			if hasOwnerKind(sts.OwnerReferences, dragonflyOwnerKind) {
@@ -491,8 +653,7 @@ func (h *KubeHandler) handleAppsList(w http.ResponseWriter, r *http.Request, nam
 	} else {
 		statefulSets, err := h.listStatefulSetsCached(ctx, namespace)
 		if err != nil {
-			writeError(w, http.StatusBadGateway, err.Error())
-			return
+			return nil, err
 		}
 		for _, sts := range statefulSets {
 			if hasOwnerKind(sts.OwnerReferences, dragonflyOwnerKind) {
@@ -504,16 +665,19 @@ func (h *KubeHandler) handleAppsList(w http.ResponseWriter, r *http.Request, nam
 			if light {
 				resp = append(resp, h.mapStatefulSetLite(&sts))
 			} else {
-				resp = append(resp, h.mapStatefulSet(ctx, &sts, nil, false, podSnapshot, metrics))
+				resp = append(resp, h.mapStatefulSet(ctx, &sts, nil, false, envOmit, podSnapshot, metrics))
 			}
 		}
 	}
+	return resp, nil
+}
 
+func (h *KubeHandler) listCnpgApps(ctx context.Context, namespace string, metadataOnly, light bool, podSnapshot []corev1.Pod, metrics *metricsSnapshot) ([]appResponse, error) {
+	resp := []appResponse{}
 	if metadataOnly {
 		clusters, err := h.listCnpgMetadataCached(ctx, namespace)
 		if err != nil {
-			writeError(w, http.StatusBadGateway, err.Error())
-			return
+			return nil, err
 		}
 		for _, cluster := range clusters {
 			if !h.allowApp(cluster.Name, cluster.Labels) {
@@ -524,8 +688,7 @@ func (h *KubeHandler) handleAppsList(w http.ResponseWriter, r *http.Request, nam
 	} else {
 		cnpgClusters, err := h.listCnpgClustersCached(ctx, namespace)
 		if err != nil {
-			writeError(w, http.StatusBadGateway, err.Error())
-			return
+			return nil, err
 		}
 		for _, cluster := range cnpgClusters {
 			if !h.allowApp(cluster.Metadata.Name, cluster.Metadata.Labels) {
@@ -538,12 +701,15 @@ func (h *KubeHandler) handleAppsList(w http.ResponseWriter, r *http.Request, nam
 			}
 		}
 	}
+	return resp, nil
+}
 
+func (h *KubeHandler) listDragonflyApps(ctx context.Context, namespace string, metadataOnly, light bool, podSnapshot []corev1.Pod, metrics *metricsSnapshot) ([]appResponse, error) {
+	resp := []appResponse{}
 	if metadataOnly {
 		dragonflies, err := h.listDragonflyMetadataCached(ctx, namespace)
 		if err != nil {
-			writeError(w, http.StatusBadGateway, err.Error())
-			return
+			return nil, err
 		}
 		for _, dragonfly := range dragonflies {
 			if !h.allowApp(dragonfly.Name, dragonfly.Labels) {
@@ -554,8 +720,7 @@ func (h *KubeHandler) handleAppsList(w http.ResponseWriter, r *http.Request, nam
 	} else {
 		dragonflies, err := h.listDragonfliesCached(ctx, namespace)
 		if err != nil {
-			writeError(w, http.StatusBadGateway, err.Error())
-			return
+			return nil, err
 		}
 		for _, dragonfly := range dragonflies {
 			if !h.allowApp(dragonfly.Metadata.Name, dragonfly.Metadata.Labels) {
@@ -564,34 +729,43 @@ func (h *KubeHandler) handleAppsList(w http.ResponseWriter, r *http.Request, nam
 			if light {
 				resp = append(resp, h.mapDragonflyLite(&dragonfly))
 			} else {
-				resp = append(resp, h.mapDragonfly(ctx, &dragonfly, nil, false, podSnapshot, metrics))
+				resp = append(resp, h.mapDragonfly(ctx, &dragonfly, nil, false, envOmit, podSnapshot, metrics))
 			}
 		}
 	}
+	return resp, nil
+}
 
-	for _, crd := range h.enabledCustomResources() {
-		items, err := h.listCustomResourcesMetadataCached(ctx, namespace, crd)
-		if err != nil {
-			writeError(w, http.StatusBadGateway, err.Error())
-			return
-		}
-		for _, item := range items {
-			if !h.allowApp(item.Name, item.Labels) {
-				continue
+func (h *KubeHandler) trackApps(namespace string, apps []appResponse) {
+	counts := make(map[string]int)
+	for _, app := range apps {
+		counts[app.Type]++
+	}
+	h.stats.setTrackedApps(namespace, counts)
+}
+
+func (h *KubeHandler) attachPodSummaries(apps []appResponse, podSnapshot []corev1.Pod) {
+	byName := make(map[string]*corev1.Pod, len(podSnapshot))
+	for i := range podSnapshot {
+		byName[podSnapshot[i].Name] = &podSnapshot[i]
+	}
+	for i := range apps {
+		pods := make([]podResponse, 0, len(apps[i].PodNames))
+		for _, name := range apps[i].PodNames {
+			if pod, ok := byName[name]; ok {
+				pods = append(pods, h.mapPodLite(pod))
 			}
-			resp = append(resp, h.mapCustomResourceMetadata(crd, item))
 		}
+		apps[i].Pods = pods
 	}
-
-	writeJSON(w, resp)
 }
 
 func (h *KubeHandler) handleAppGet(w http.ResponseWriter, r *http.Request, namespace, name string) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	if !allowMethods(w, r, http.MethodGet) {
 		return
 	}
-	h.audit(r, "app_get", namespace, name, nil)
+	h.auditRead(r, "app_get", namespace, name, nil)
+	h.auditSecretReveal(r, namespace, name)
 	ctx := r.Context()
 	var user *auth.User
 	if u, ok := auth.UserFromContext(r.Context()); ok {
@@ -614,7 +788,7 @@ func (h *KubeHandler) handleAppGet(w http.ResponseWriter, r *http.Request, names
 			writeError(w, http.StatusForbidden, "app not allowed")
 			return
 		}
-		writeJSON(w, h.mapDeployment(ctx, dep, user, reveal, podSnapshot, metrics))
+		h.writeAppResponse(w, r, h.mapDeployment(ctx, dep, user, reveal, envResolve, podSnapshot, metrics))
 		return
 	}
 	sts, err := h.client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
@@ -627,16 +801,16 @@ func (h *KubeHandler) handleAppGet(w http.ResponseWriter, r *http.Request, names
 			writeError(w, http.StatusForbidden, "app not allowed")
 			return
 		}
-		writeJSON(w, h.mapStatefulSet(ctx, sts, user, reveal, podSnapshot, metrics))
+		h.writeAppResponse(w, r, h.mapStatefulSet(ctx, sts, user, reveal, envResolve, podSnapshot, metrics))
 		return
 	}
-	cluster, err := h.getCnpgCluster(ctx, namespace, name)
+	cluster, err := h.getCnpgClusterCached(ctx, namespace, name)
 	if err == nil {
 		if !h.allowApp(cluster.Metadata.Name, cluster.Metadata.Labels) {
 			writeError(w, http.StatusForbidden, "app not allowed")
 			return
 		}
-		writeJSON(w, h.mapCnpgCluster(ctx, cluster, podSnapshot, metrics))
+		h.writeAppResponse(w, r, h.mapCnpgCluster(ctx, cluster, podSnapshot, metrics))
 		return
 	}
 	if err != nil && !apierrors.IsNotFound(err) {
@@ -644,13 +818,13 @@ func (h *KubeHandler) handleAppGet(w http.ResponseWriter, r *http.Request, names
 		return
 	}
 
-	dragonfly, err := h.getDragonfly(ctx, namespace, name)
+	dragonfly, err := h.getDragonflyCached(ctx, namespace, name)
 	if err == nil {
 		if !h.allowApp(dragonfly.Metadata.Name, dragonfly.Metadata.Labels) {
 			writeError(w, http.StatusForbidden, "app not allowed")
 			return
 		}
-		writeJSON(w, h.mapDragonfly(ctx, dragonfly, user, reveal, podSnapshot, metrics))
+		h.writeAppResponse(w, r, h.mapDragonfly(ctx, dragonfly, user, reveal, envResolve, podSnapshot, metrics))
 		return
 	}
 	if err != nil && !apierrors.IsNotFound(err) {
@@ -665,7 +839,7 @@ func (h *KubeHandler) handleAppGet(w http.ResponseWriter, r *http.Request, names
 				writeError(w, http.StatusForbidden, "app not allowed")
 				return
 			}
-			writeJSON(w, h.mapCustomResourceMetadata(crd, *meta))
+			h.writeAppResponse(w, r, h.mapCustomResourceMetadata(crd, *meta))
 			return
 		}
 		if err != nil && !apierrors.IsNotFound(err) {
@@ -678,8 +852,7 @@ func (h *KubeHandler) handleAppGet(w http.ResponseWriter, r *http.Request, names
 }
 
 func (h *KubeHandler) handlePodMetrics(w http.ResponseWriter, r *http.Request, namespace, name string) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	if !allowMethods(w, r, http.MethodGet) {
 		return
 	}
 	ctx := r.Context()
@@ -693,50 +866,92 @@ func (h *KubeHandler) handlePodMetrics(w http.ResponseWriter, r *http.Request, n
 		return
 	}
 
-	usage, err := h.fetchPodMetrics(ctx, namespace, name)
+	cpu, mem, err := h.fetchPodMetrics(ctx, namespace, name)
 	if err != nil {
 		writeError(w, http.StatusNotFound, err.Error())
 		return
 	}
 	requests, limits := sumResourceRequests(pod.Spec.Containers)
-	usage.CPURequest = requests.cpu.String()
-	usage.MemRequest = requests.mem.String()
-	usage.CPULimit = limits.cpu.String()
-	usage.MemLimit = limits.mem.String()
-	writeJSON(w, usage)
+	usage := resourceUsage{
+		CPURequest: formatCPU(requests.cpu),
+		MemRequest: formatMemory(requests.mem),
+		CPULimit:   formatCPU(limits.cpu),
+		MemLimit:   formatMemory(limits.mem),
+		Extended:   extendedResources(requests, limits),
+	}
+	usage.applyUsage(cpu, mem, 1, requests, limits)
+	writeJSON(w, r, usage)
 }
 
 func (h *KubeHandler) allowPod(pod *corev1.Pod) bool {
-	if !h.podInclude.MatchString(pod.Name) {
-		return false
+	return h.podFilterRule(pod.Name, pod.Labels) == ""
+}
+
+func (h *KubeHandler) allowApp(name string, labels map[string]string) bool {
+	return h.appFilterRule(name, labels) == ""
+}
+
+// podFilterRule names the filter rule that hides a pod, or "" when it is shown.
+func (h *KubeHandler) podFilterRule(name string, labels map[string]string) string {
+	h.filterMu.RLock()
+	defer h.filterMu.RUnlock()
+	if !h.podInclude.MatchString(name) {
+		return "pod_filters.include_regex"
 	}
-	if matchesExcluded(pod.Labels, h.podExclude) {
-		return false
+	if filter, ok := firstMissing(labels, h.podRequired); ok {
+		return "pod_filters.include_labels: " + filter.String()
 	}
-	return true
+	if filter, ok := firstExcluded(labels, h.podExclude); ok {
+		return "pod_filters.exclude_labels: " + filter.String()
+	}
+	return ""
 }
 
-func (h *KubeHandler) allowApp(name string, labels map[string]string) bool {
+// appFilterRule names the filter rule that hides an app, or "" when it is shown.
+func (h *KubeHandler) appFilterRule(name string, labels map[string]string) string {
+	h.filterMu.RLock()
+	defer h.filterMu.RUnlock()
 	if !h.appInclude.MatchString(name) {
-		return false
+		return "app_filters.include_regex"
 	}
-	if matchesExcluded(labels, h.appExclude) {
-		return false
+	if filter, ok := firstMissing(labels, h.appRequired); ok {
+		return "app_filters.include_labels: " + filter.String()
 	}
-	return true
+	if filter, ok := firstExcluded(labels, h.appExclude); ok {
+		return "app_filters.exclude_labels: " + filter.String()
+	}
+	if filter, ok := firstMissing(labels, h.appGroup); ok {
+		return "app_groups.labels.selector: " + filter.String()
+	}
+	return ""
 }
 
-func (h *KubeHandler) mapPod(pod *corev1.Pod, includeDetails bool, user *auth.User, revealSecrets bool, metrics *metricsSnapshot) podResponse {
+func (h *KubeHandler) mapPod(ctx context.Context, pod *corev1.Pod, includeDetails bool, user *auth.User, revealSecrets bool, mode envMode, metrics *metricsSnapshot) podResponse {
 	restarts := int32(0)
 	containers := make([]containerResponse, 0, len(pod.Status.ContainerStatuses))
+	specImages := make(map[string]string, len(pod.Spec.Containers))
+	for _, container := range pod.Spec.Containers {
+		specImages[container.Name] = container.Image
+	}
 	for _, status := range pod.Status.ContainerStatuses {
 		restarts += status.RestartCount
-		containers = append(containers, containerResponse{
+		container := containerResponse{
 			Name:         status.Name,
 			Image:        status.Image,
 			Ready:        status.Ready,
 			RestartCount: status.RestartCount,
-		})
+			SpecImage:    specImages[status.Name],
+			ImageDigest:  imageDigest(status.ImageID),
+			PullState:    imagePullState(status),
+		}
+		if includeDetails {
+			if term := lastTermination(status); term != nil {
+				exitCode := term.ExitCode
+				container.ExitCode = &exitCode
+				container.OOMKilled = term.Reason == oomKilledReason
+			}
+		}
+		containers = append(containers, container)
 	}
 
 	volumes := extractVolumeMounts(pod.Spec.Containers)
@@ -744,41 +959,78 @@ func (h *KubeHandler) mapPod(pod *corev1.Pod, includeDetails bool, user *auth.Us
 	requests, limits := sumResourceRequests(pod.Spec.Containers)
 
 	usage := resourceUsage{
-		CPURequest: formatQuantityOrEmpty(requests.cpu),
-		MemRequest: formatQuantityOrEmpty(requests.mem),
-		CPULimit:   formatQuantityOrEmpty(limits.cpu),
-		MemLimit:   formatQuantityOrEmpty(limits.mem),
+		CPURequest: formatCPU(requests.cpu),
+		MemRequest: formatMemory(requests.mem),
+		CPULimit:   formatCPU(limits.cpu),
+		MemLimit:   formatMemory(limits.mem),
+		Extended:   extendedResources(requests, limits),
 	}
+	var memUsage *resource.Quantity
 	if metrics != nil {
 		if cpu, mem, ok := metrics.usageForPod(pod.Name); ok {
-			usage.CPUUsage = formatCPUUsage(cpu)
-			usage.MemUsage = formatMemUsage(mem)
+			usage.applyUsage(cpu, mem, 1, requests, limits)
+			memUsage = &mem
 		}
 		applyMetricsMeta(&usage, metrics)
 	}
 
-	env := map[string]string{}
-	envSecrets := []string{}
-	if len(pod.Spec.Containers) > 0 {
-		env, envSecrets = extractEnv(pod.Namespace, pod.Spec.Containers[0].Env, pod.Spec.Containers[0].EnvFrom, user, revealSecrets, h.client)
+	env, envSecrets, envTruncated := h.containerEnv(ctx, pod.Namespace, firstEnv(pod.Spec.Containers), firstEnvFrom(pod.Spec.Containers), user, revealSecrets, mode)
+
+	resp := podResponse{
+		Name:         pod.Name,
+		Namespace:    pod.Namespace,
+		Status:       podStatus(pod),
+		Restarts:     restarts,
+		Age:          formatAge(pod.CreationTimestamp.Time),
+		Labels:       pod.Labels,
+		Annotations:  h.filterAnnotations(pod.Annotations),
+		Env:          env,
+		EnvSecrets:   envSecrets,
+		EnvTruncated: envTruncated,
+		EnvOmitted:   mode == envOmit,
+		Containers:   containers,
+		Volumes:      volumes,
+		Secrets:      secrets,
+		ConfigMaps:   configMaps,
+		Resources:    usage,
+		OwnerApp:     ownerRefName(pod.OwnerReferences),
+		QOSClass:     string(pod.Status.QOSClass),
+	}
+	if includeDetails {
+		resp.EvictionRisk = evictionRisk(pod.Status.QOSClass, memUsage, requests.mem)
 	}
+	return resp
+}
 
-	return podResponse{
-		Name:        pod.Name,
-		Namespace:   pod.Namespace,
-		Status:      string(pod.Status.Phase),
-		Restarts:    restarts,
-		Age:         formatAge(pod.CreationTimestamp.Time),
-		Labels:      pod.Labels,
-		Annotations: pod.Annotations,
-		Env:         env,
-		EnvSecrets:  envSecrets,
-		Containers:  containers,
-		Volumes:     volumes,
-		Secrets:     secrets,
-		ConfigMaps:  configMaps,
-		Resources:   usage,
-		OwnerApp:    ownerRefName(pod.OwnerReferences),
+// Memory use above this share of the request marks a Burstable pod as a
+// medium eviction risk, before it crosses the request itself.
+const evictionRiskMemWarnRatio = 0.8
+
+// evictionRisk approximates the kubelet's ranking under node memory pressure,
+// which evicts pods using more memory than they requested first. BestEffort
+// pods request nothing, so they are always high; Guaranteed pods cannot
+// exceed their requests. Burstable pods are ranked by live memory usage
+// against the request, and count as medium when there are no metrics. CPU is
+// throttled rather than evicted, so it is ignored.
+func evictionRisk(qos corev1.PodQOSClass, memUsage *resource.Quantity, memRequest resource.Quantity) string {
+	switch qos {
+	case corev1.PodQOSGuaranteed:
+		return "low"
+	case corev1.PodQOSBestEffort:
+		return "high"
+	case corev1.PodQOSBurstable:
+		if memUsage == nil {
+			return "medium"
+		}
+		if memRequest.IsZero() || memUsage.Cmp(memRequest) > 0 {
+			return "high"
+		}
+		if memUsage.AsApproximateFloat64() > memRequest.AsApproximateFloat64()*evictionRiskMemWarnRatio {
+			return "medium"
+		}
+		return "low"
+	default:
+		return ""
 	}
 }
 
@@ -791,11 +1043,11 @@ func (h *KubeHandler) mapPodLite(pod *corev1.Pod) podResponse {
 	return podResponse{
 		Name:        pod.Name,
 		Namespace:   pod.Namespace,
-		Status:      string(pod.Status.Phase),
+		Status:      podStatus(pod),
 		Restarts:    restarts,
 		Age:         formatAge(pod.CreationTimestamp.Time),
 		Labels:      pod.Labels,
-		Annotations: pod.Annotations,
+		Annotations: h.filterAnnotations(pod.Annotations),
 		Env:         map[string]string{},
 		EnvSecrets:  []string{},
 		Containers:  []containerResponse{},
@@ -804,19 +1056,24 @@ func (h *KubeHandler) mapPodLite(pod *corev1.Pod) podResponse {
 		ConfigMaps:  []string{},
 		Resources:   resourceUsage{},
 		OwnerApp:    ownerRefName(pod.OwnerReferences),
+		QOSClass:    string(pod.Status.QOSClass),
 		Light:       true,
 	}
 }
 
 func (h *KubeHandler) mapPodMetadata(meta metav1.PartialObjectMetadata) podResponse {
+	status := "Unknown"
+	if meta.DeletionTimestamp != nil {
+		status = podStatusTerminating
+	}
 	return podResponse{
 		Name:         meta.Name,
 		Namespace:    meta.Namespace,
-		Status:       "Unknown",
+		Status:       status,
 		Restarts:     0,
 		Age:          formatAge(meta.CreationTimestamp.Time),
 		Labels:       meta.Labels,
-		Annotations:  meta.Annotations,
+		Annotations:  h.filterAnnotations(meta.Annotations),
 		Env:          map[string]string{},
 		EnvSecrets:   []string{},
 		Containers:   []containerResponse{},
@@ -830,7 +1087,7 @@ func (h *KubeHandler) mapPodMetadata(meta metav1.PartialObjectMetadata) podRespo
 	}
 }
 
-func (h *KubeHandler) mapDeployment(ctx context.Context, dep *appsv1.Deployment, user *auth.User, revealSecrets bool, podSnapshot []corev1.Pod, metrics *metricsSnapshot) appResponse {
+func (h *KubeHandler) mapDeployment(ctx context.Context, dep *appsv1.Deployment, user *auth.User, revealSecrets bool, mode envMode, podSnapshot []corev1.Pod, metrics *metricsSnapshot) appResponse {
 	pods := h.podNamesForSelector(ctx, dep.Namespace, dep.Spec.Selector, podSnapshot)
 	requests, limits := sumResourceRequests(dep.Spec.Template.Spec.Containers)
 	volumes := extractVolumeMounts(dep.Spec.Template.Spec.Containers)
@@ -840,22 +1097,24 @@ func (h *KubeHandler) mapDeployment(ctx context.Context, dep *appsv1.Deployment,
 	if len(dep.Spec.Template.Spec.Containers) > 0 {
 		image = dep.Spec.Template.Spec.Containers[0].Image
 	}
-	env, envSecrets := extractEnv(dep.Namespace, firstEnv(dep.Spec.Template.Spec.Containers), firstEnvFrom(dep.Spec.Template.Spec.Containers), user, revealSecrets, h.client)
+	env, envSecrets, envTruncated := h.containerEnv(ctx, dep.Namespace, firstEnv(dep.Spec.Template.Spec.Containers), firstEnvFrom(dep.Spec.Template.Spec.Containers), user, revealSecrets, mode)
 
 	usage := resourceUsage{
-		CPURequest: formatQuantityOrEmpty(requests.cpu),
-		MemRequest: formatQuantityOrEmpty(requests.mem),
-		CPULimit:   formatQuantityOrEmpty(limits.cpu),
-		MemLimit:   formatQuantityOrEmpty(limits.mem),
+		CPURequest: formatCPU(requests.cpu),
+		MemRequest: formatMemory(requests.mem),
+		CPULimit:   formatCPU(limits.cpu),
+		MemLimit:   formatMemory(limits.mem),
+		Extended:   extendedResources(requests, limits),
 	}
 	if metrics != nil {
-		if cpu, mem, ok := metrics.usageForPods(pods); ok {
-			usage.CPUUsage = formatCPUUsage(cpu)
-			usage.MemUsage = formatMemUsage(mem)
+		if cpu, mem, found := metrics.usageForPods(pods); found > 0 {
+			usage.applyUsage(cpu, mem, found, requests, limits)
 		}
 		applyMetricsMeta(&usage, metrics)
 	}
 
+	drift := imageDrift(dep.Spec.Template.Spec.Containers, podSnapshot, pods)
+
 	return appResponse{
 		Name:          dep.Name,
 		Namespace:     dep.Namespace,
@@ -864,15 +1123,20 @@ func (h *KubeHandler) mapDeployment(ctx context.Context, dep *appsv1.Deployment,
 		ReadyReplicas: dep.Status.ReadyReplicas,
 		PodNames:      pods,
 		Labels:        dep.Labels,
-		Annotations:   dep.Annotations,
+		Annotations:   h.filterAnnotations(dep.Annotations),
 		Env:           env,
 		EnvSecrets:    envSecrets,
+		EnvTruncated:  envTruncated,
+		EnvOmitted:    mode == envOmit,
 		Resources:     usage,
 		Volumes:       volumes,
 		Secrets:       secrets,
 		ConfigMaps:    configMaps,
 		Containers:    containers,
 		Image:         image,
+		HasOOMKills:   hasOOMKills(podSnapshot, pods),
+		ImageDrift:    len(drift) > 0,
+		DriftDetails:  drift,
 	}
 }
 
@@ -889,7 +1153,7 @@ func (h *KubeHandler) mapDeploymentLite(dep *appsv1.Deployment) appResponse {
 		ReadyReplicas: dep.Status.ReadyReplicas,
 		PodNames:      []string{},
 		Labels:        dep.Labels,
-		Annotations:   dep.Annotations,
+		Annotations:   h.filterAnnotations(dep.Annotations),
 		Env:           map[string]string{},
 		EnvSecrets:    []string{},
 		Resources:     resourceUsage{},
@@ -911,7 +1175,7 @@ func (h *KubeHandler) mapDeploymentMetadata(meta metav1.PartialObjectMetadata) a
 		ReadyReplicas: 0,
 		PodNames:      []string{},
 		Labels:        meta.Labels,
-		Annotations:   meta.Annotations,
+		Annotations:   h.filterAnnotations(meta.Annotations),
 		Env:           map[string]string{},
 		EnvSecrets:    []string{},
 		Resources:     resourceUsage{},
@@ -925,7 +1189,7 @@ func (h *KubeHandler) mapDeploymentMetadata(meta metav1.PartialObjectMetadata) a
 	}
 }
 
-func (h *KubeHandler) mapStatefulSet(ctx context.Context, sts *appsv1.StatefulSet, user *auth.User, revealSecrets bool, podSnapshot []corev1.Pod, metrics *metricsSnapshot) appResponse {
+func (h *KubeHandler) mapStatefulSet(ctx context.Context, sts *appsv1.StatefulSet, user *auth.User, revealSecrets bool, mode envMode, podSnapshot []corev1.Pod, metrics *metricsSnapshot) appResponse {
 	pods := h.podNamesForSelector(ctx, sts.Namespace, sts.Spec.Selector, podSnapshot)
 	requests, limits := sumResourceRequests(sts.Spec.Template.Spec.Containers)
 	volumes := extractVolumeMounts(sts.Spec.Template.Spec.Containers)
@@ -935,22 +1199,24 @@ func (h *KubeHandler) mapStatefulSet(ctx context.Context, sts *appsv1.StatefulSe
 	if len(sts.Spec.Template.Spec.Containers) > 0 {
 		image = sts.Spec.Template.Spec.Containers[0].Image
 	}
-	env, envSecrets := extractEnv(sts.Namespace, firstEnv(sts.Spec.Template.Spec.Containers), firstEnvFrom(sts.Spec.Template.Spec.Containers), user, revealSecrets, h.client)
+	env, envSecrets, envTruncated := h.containerEnv(ctx, sts.Namespace, firstEnv(sts.Spec.Template.Spec.Containers), firstEnvFrom(sts.Spec.Template.Spec.Containers), user, revealSecrets, mode)
 
 	usage := resourceUsage{
-		CPURequest: formatQuantityOrEmpty(requests.cpu),
-		MemRequest: formatQuantityOrEmpty(requests.mem),
-		CPULimit:   formatQuantityOrEmpty(limits.cpu),
-		MemLimit:   formatQuantityOrEmpty(limits.mem),
+		CPURequest: formatCPU(requests.cpu),
+		MemRequest: formatMemory(requests.mem),
+		CPULimit:   formatCPU(limits.cpu),
+		MemLimit:   formatMemory(limits.mem),
+		Extended:   extendedResources(requests, limits),
 	}
 	if metrics != nil {
-		if cpu, mem, ok := metrics.usageForPods(pods); ok {
-			usage.CPUUsage = formatCPUUsage(cpu)
-			usage.MemUsage = formatMemUsage(mem)
+		if cpu, mem, found := metrics.usageForPods(pods); found > 0 {
+			usage.applyUsage(cpu, mem, found, requests, limits)
 		}
 		applyMetricsMeta(&usage, metrics)
 	}
 
+	drift := imageDrift(sts.Spec.Template.Spec.Containers, podSnapshot, pods)
+
 	return appResponse{
 		Name:          sts.Name,
 		Namespace:     sts.Namespace,
@@ -959,15 +1225,20 @@ func (h *KubeHandler) mapStatefulSet(ctx context.Context, sts *appsv1.StatefulSe
 		ReadyReplicas: sts.Status.ReadyReplicas,
 		PodNames:      pods,
 		Labels:        sts.Labels,
-		Annotations:   sts.Annotations,
+		Annotations:   h.filterAnnotations(sts.Annotations),
 		Env:           env,
 		EnvSecrets:    envSecrets,
+		EnvTruncated:  envTruncated,
+		EnvOmitted:    mode == envOmit,
 		Resources:     usage,
 		Volumes:       volumes,
 		Secrets:       secrets,
 		ConfigMaps:    configMaps,
 		Containers:    containers,
 		Image:         image,
+		HasOOMKills:   hasOOMKills(podSnapshot, pods),
+		ImageDrift:    len(drift) > 0,
+		DriftDetails:  drift,
 	}
 }
 
@@ -984,7 +1255,7 @@ func (h *KubeHandler) mapStatefulSetLite(sts *appsv1.StatefulSet) appResponse {
 		ReadyReplicas: sts.Status.ReadyReplicas,
 		PodNames:      []string{},
 		Labels:        sts.Labels,
-		Annotations:   sts.Annotations,
+		Annotations:   h.filterAnnotations(sts.Annotations),
 		Env:           map[string]string{},
 		EnvSecrets:    []string{},
 		Resources:     resourceUsage{},
@@ -1006,7 +1277,7 @@ func (h *KubeHandler) mapStatefulSetMetadata(meta metav1.PartialObjectMetadata)
 		ReadyReplicas: 0,
 		PodNames:      []string{},
 		Labels:        meta.Labels,
-		Annotations:   meta.Annotations,
+		Annotations:   h.filterAnnotations(meta.Annotations),
 		Env:           map[string]string{},
 		EnvSecrets:    []string{},
 		Resources:     resourceUsage{},
@@ -1029,15 +1300,15 @@ func (h *KubeHandler) mapCnpgCluster(ctx context.Context, cluster *cnpgCluster,
 	}
 
 	usage := resourceUsage{
-		CPURequest: formatQuantityOrEmpty(requests.cpu),
-		MemRequest: formatQuantityOrEmpty(requests.mem),
-		CPULimit:   formatQuantityOrEmpty(limits.cpu),
-		MemLimit:   formatQuantityOrEmpty(limits.mem),
+		CPURequest: formatCPU(requests.cpu),
+		MemRequest: formatMemory(requests.mem),
+		CPULimit:   formatCPU(limits.cpu),
+		MemLimit:   formatMemory(limits.mem),
+		Extended:   extendedResources(requests, limits),
 	}
 	if metrics != nil {
-		if cpu, mem, ok := metrics.usageForPods(pods); ok {
-			usage.CPUUsage = formatCPUUsage(cpu)
-			usage.MemUsage = formatMemUsage(mem)
+		if cpu, mem, found := metrics.usageForPods(pods); found > 0 {
+			usage.applyUsage(cpu, mem, found, requests, limits)
 		}
 		applyMetricsMeta(&usage, metrics)
 	}
@@ -1050,7 +1321,7 @@ func (h *KubeHandler) mapCnpgCluster(ctx context.Context, cluster *cnpgCluster,
 		ReadyReplicas: cluster.Status.ReadyInstances,
 		PodNames:      pods,
 		Labels:        cluster.Metadata.Labels,
-		Annotations:   cluster.Metadata.Annotations,
+		Annotations:   h.filterAnnotations(cluster.Metadata.Annotations),
 		Env:           map[string]string{},
 		EnvSecrets:    []string{},
 		Resources:     usage,
@@ -1074,7 +1345,7 @@ func (h *KubeHandler) mapCnpgClusterLite(cluster *cnpgCluster) appResponse {
 		ReadyReplicas: cluster.Status.ReadyInstances,
 		PodNames:      []string{},
 		Labels:        cluster.Metadata.Labels,
-		Annotations:   cluster.Metadata.Annotations,
+		Annotations:   h.filterAnnotations(cluster.Metadata.Annotations),
 		Env:           map[string]string{},
 		EnvSecrets:    []string{},
 		Resources:     resourceUsage{},
@@ -1095,7 +1366,7 @@ func (h *KubeHandler) mapCnpgClusterMetadata(meta metav1.PartialObjectMetadata)
 		ReadyReplicas: 0,
 		PodNames:      []string{},
 		Labels:        meta.Labels,
-		Annotations:   meta.Annotations,
+		Annotations:   h.filterAnnotations(meta.Annotations),
 		Env:           map[string]string{},
 		EnvSecrets:    []string{},
 		Resources:     resourceUsage{},
@@ -1108,22 +1379,22 @@ func (h *KubeHandler) mapCnpgClusterMetadata(meta metav1.PartialObjectMetadata)
 	}
 }
 
-func (h *KubeHandler) mapDragonfly(ctx context.Context, dragonfly *dragonflyResource, user *auth.User, revealSecrets bool, podSnapshot []corev1.Pod, metrics *metricsSnapshot) appResponse {
+func (h *KubeHandler) mapDragonfly(ctx context.Context, dragonfly *dragonflyResource, user *auth.User, revealSecrets bool, mode envMode, podSnapshot []corev1.Pod, metrics *metricsSnapshot) appResponse {
 	pods, ready := h.podNamesForLabel(ctx, dragonfly.Metadata.Namespace, fmt.Sprintf("%s=%s", dragonflyAppLabelKey, dragonfly.Metadata.Name), podSnapshot)
 	requests, limits := sumResourceRequirements(dragonfly.Spec.Resources)
 	secretRefs, configRefs := extractEnvRefs(dragonfly.Spec.Env)
-	env, envSecrets := extractEnv(dragonfly.Metadata.Namespace, dragonfly.Spec.Env, nil, user, revealSecrets, h.client)
+	env, envSecrets, envTruncated := h.containerEnv(ctx, dragonfly.Metadata.Namespace, dragonfly.Spec.Env, nil, user, revealSecrets, mode)
 
 	usage := resourceUsage{
-		CPURequest: formatQuantityOrEmpty(requests.cpu),
-		MemRequest: formatQuantityOrEmpty(requests.mem),
-		CPULimit:   formatQuantityOrEmpty(limits.cpu),
-		MemLimit:   formatQuantityOrEmpty(limits.mem),
+		CPURequest: formatCPU(requests.cpu),
+		MemRequest: formatMemory(requests.mem),
+		CPULimit:   formatCPU(limits.cpu),
+		MemLimit:   formatMemory(limits.mem),
+		Extended:   extendedResources(requests, limits),
 	}
 	if metrics != nil {
-		if cpu, mem, ok := metrics.usageForPods(pods); ok {
-			usage.CPUUsage = formatCPUUsage(cpu)
-			usage.MemUsage = formatMemUsage(mem)
+		if cpu, mem, found := metrics.usageForPods(pods); found > 0 {
+			usage.applyUsage(cpu, mem, found, requests, limits)
 		}
 		applyMetricsMeta(&usage, metrics)
 	}
@@ -1136,9 +1407,11 @@ func (h *KubeHandler) mapDragonfly(ctx context.Context, dragonfly *dragonflyReso
 		ReadyReplicas: ready,
 		PodNames:      pods,
 		Labels:        dragonfly.Metadata.Labels,
-		Annotations:   dragonfly.Metadata.Annotations,
+		Annotations:   h.filterAnnotations(dragonfly.Metadata.Annotations),
 		Env:           env,
 		EnvSecrets:    envSecrets,
+		EnvTruncated:  envTruncated,
+		EnvOmitted:    mode == envOmit,
 		Resources:     usage,
 		Volumes:       []volumeMountResponse{},
 		Secrets:       secretRefs,
@@ -1162,7 +1435,7 @@ func (h *KubeHandler) mapDragonflyLite(dragonfly *dragonflyResource) appResponse
 		ReadyReplicas: ready,
 		PodNames:      []string{},
 		Labels:        dragonfly.Metadata.Labels,
-		Annotations:   dragonfly.Metadata.Annotations,
+		Annotations:   h.filterAnnotations(dragonfly.Metadata.Annotations),
 		Env:           map[string]string{},
 		EnvSecrets:    []string{},
 		Resources:     resourceUsage{},
@@ -1183,7 +1456,7 @@ func (h *KubeHandler) mapDragonflyMetadata(meta metav1.PartialObjectMetadata) ap
 		ReadyReplicas: 0,
 		PodNames:      []string{},
 		Labels:        meta.Labels,
-		Annotations:   meta.Annotations,
+		Annotations:   h.filterAnnotations(meta.Annotations),
 		Env:           map[string]string{},
 		EnvSecrets:    []string{},
 		Resources:     resourceUsage{},
@@ -1209,7 +1482,7 @@ func (h *KubeHandler) mapCustomResourceMetadata(crd config.CustomResourceConfig,
 		ReadyReplicas: 0,
 		PodNames:      []string{},
 		Labels:        meta.Labels,
-		Annotations:   meta.Annotations,
+		Annotations:   h.filterAnnotations(meta.Annotations),
 		Env:           map[string]string{},
 		EnvSecrets:    []string{},
 		Resources:     resourceUsage{},
@@ -1259,18 +1532,8 @@ func (h *KubeHandler) listPodsForLabel(ctx context.Context, namespace, selector
 func sumResourceRequirements(req corev1.ResourceRequirements) (resourceTotals, resourceTotals) {
 	var requests resourceTotals
 	var limits resourceTotals
-	if quantity, ok := req.Requests[corev1.ResourceCPU]; ok {
-		requests.cpu.Add(quantity)
-	}
-	if quantity, ok := req.Requests[corev1.ResourceMemory]; ok {
-		requests.mem.Add(quantity)
-	}
-	if quantity, ok := req.Limits[corev1.ResourceCPU]; ok {
-		limits.cpu.Add(quantity)
-	}
-	if quantity, ok := req.Limits[corev1.ResourceMemory]; ok {
-		limits.mem.Add(quantity)
-	}
+	requests.add(req.Requests)
+	limits.add(req.Limits)
 	return requests, limits
 }
 
@@ -1291,8 +1554,110 @@ func extractEnvRefs(envs []corev1.EnvVar) ([]string, []string) {
 	return mapKeys(secrets), mapKeys(configMaps)
 }
 
+const oomKilledReason = "OOMKilled"
+
+func imageDigest(imageID string) string {
+	if idx := strings.LastIndex(imageID, "@"); idx >= 0 {
+		return imageID[idx+1:]
+	}
+	return strings.TrimPrefix(imageID, "docker://")
+}
+
+func imagePullState(status corev1.ContainerStatus) string {
+	if status.State.Waiting != nil {
+		switch reason := status.State.Waiting.Reason; reason {
+		case "ImagePullBackOff", "ErrImagePull", "ErrImageNeverPull", "InvalidImageName":
+			return reason
+		}
+	}
+	if status.ImageID != "" {
+		return "Pulled"
+	}
+	return ""
+}
+
+func lastTermination(status corev1.ContainerStatus) *corev1.ContainerStateTerminated {
+	if status.State.Terminated != nil {
+		return status.State.Terminated
+	}
+	return status.LastTerminationState.Terminated
+}
+
+func hasOOMKills(podSnapshot []corev1.Pod, podNames []string) bool {
+	if len(podSnapshot) == 0 || len(podNames) == 0 {
+		return false
+	}
+	wanted := make(map[string]struct{}, len(podNames))
+	for _, name := range podNames {
+		wanted[name] = struct{}{}
+	}
+	for _, pod := range podSnapshot {
+		if _, ok := wanted[pod.Name]; !ok {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if term := lastTermination(status); term != nil && term.Reason == oomKilledReason {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func imageDrift(template []corev1.Container, podSnapshot []corev1.Pod, podNames []string) []imageDriftResponse {
+	if len(template) == 0 || len(podSnapshot) == 0 || len(podNames) == 0 {
+		return nil
+	}
+	expected := make(map[string]string, len(template))
+	for _, container := range template {
+		expected[container.Name] = container.Image
+	}
+	wanted := make(map[string]struct{}, len(podNames))
+	for _, name := range podNames {
+		wanted[name] = struct{}{}
+	}
+	var drift []imageDriftResponse
+	for _, pod := range podSnapshot {
+		if _, ok := wanted[pod.Name]; !ok {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			image, ok := expected[container.Name]
+			if !ok || image == container.Image {
+				continue
+			}
+			drift = append(drift, imageDriftResponse{
+				Pod:       pod.Name,
+				Container: container.Name,
+				Expected:  image,
+				Running:   container.Image,
+			})
+		}
+	}
+	sort.Slice(drift, func(i, j int) bool {
+		if drift[i].Pod != drift[j].Pod {
+			return drift[i].Pod < drift[j].Pod
+		}
+		return drift[i].Container < drift[j].Container
+	})
+	return drift
+}
+
+// podStatusTerminating is reported instead of the phase once a pod has a
+// deletion timestamp; the phase stays Running until its containers exit.
+const podStatusTerminating = "Terminating"
+
+// podStatus mirrors the STATUS column of kubectl get pods for the cases the UI
+// distinguishes: Terminating, otherwise the pod phase.
+func podStatus(pod *corev1.Pod) string {
+	if pod.DeletionTimestamp != nil {
+		return podStatusTerminating
+	}
+	return string(pod.Status.Phase)
+}
+
 func podReady(pod *corev1.Pod) bool {
-	if pod.Status.Phase != corev1.PodRunning {
+	if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
 		return false
 	}
 	for _, status := range pod.Status.ContainerStatuses {
@@ -1955,8 +2320,7 @@ func (h *KubeHandler) customResourceSelector(ctx context.Context, namespace, nam
 	if h.client == nil {
 		return "", false, errors.New("k8s client unavailable")
 	}
-	path := customResourcePath(namespace, name, crd)
-	data, err := h.client.RESTClient().Get().AbsPath(path).Do(ctx).Raw()
+	data, err := h.getRawWithRetry(ctx, customResourcePath(namespace, name, crd))
 	if err != nil {
 		return "", false, err
 	}
@@ -2131,30 +2495,59 @@ func extractSecretsConfigMaps(containers []corev1.Container, volumes []corev1.Vo
 }
 
 type resourceTotals struct {
-	cpu resource.Quantity
-	mem resource.Quantity
+	cpu   resource.Quantity
+	mem   resource.Quantity
+	other corev1.ResourceList
+}
+
+// add sums list into the totals; anything besides CPU and memory, such as
+// GPUs or hugepages, lands in other.
+func (t *resourceTotals) add(list corev1.ResourceList) {
+	for name, quantity := range list {
+		switch name {
+		case corev1.ResourceCPU:
+			t.cpu.Add(quantity)
+		case corev1.ResourceMemory:
+			t.mem.Add(quantity)
+		default:
+			if t.other == nil {
+				t.other = corev1.ResourceList{}
+			}
+			total := t.other[name]
+			total.Add(quantity)
+			t.other[name] = total
+		}
+	}
 }
 
 func sumResourceRequests(containers []corev1.Container) (resourceTotals, resourceTotals) {
 	var req resourceTotals
 	var lim resourceTotals
 	for _, container := range containers {
-		if quantity, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
-			req.cpu.Add(quantity)
-		}
-		if quantity, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
-			req.mem.Add(quantity)
-		}
-		if quantity, ok := container.Resources.Limits[corev1.ResourceCPU]; ok {
-			lim.cpu.Add(quantity)
-		}
-		if quantity, ok := container.Resources.Limits[corev1.ResourceMemory]; ok {
-			lim.mem.Add(quantity)
-		}
+		req.add(container.Resources.Requests)
+		lim.add(container.Resources.Limits)
 	}
 	return req, lim
 }
 
+func extendedResources(requests, limits resourceTotals) map[string]extendedResource {
+	if len(requests.other) == 0 && len(limits.other) == 0 {
+		return nil
+	}
+	out := make(map[string]extendedResource, len(requests.other)+len(limits.other))
+	for name, quantity := range requests.other {
+		item := out[string(name)]
+		item.Request = formatQuantityOrEmpty(quantity)
+		out[string(name)] = item
+	}
+	for name, quantity := range limits.other {
+		item := out[string(name)]
+		item.Limit = formatQuantityOrEmpty(quantity)
+		out[string(name)] = item
+	}
+	return out
+}
+
 func mapKeys(input map[string]struct{}) []string {
 	keys := make([]string, 0, len(input))
 	for key := range input {
@@ -2206,10 +2599,27 @@ func derefInt32(val *int32) int32 {
 	return *val
 }
 
-func writeJSON(w http.ResponseWriter, data any) {
+// writeJSON encodes data compactly, or indented when the caller asks with
+// ?pretty=true or an X-Pretty: true header, for humans reading curl output.
+func writeJSON(w http.ResponseWriter, r *http.Request, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(data)
+	enc := json.NewEncoder(w)
+	if wantsPrettyJSON(r) {
+		enc.SetIndent("", "  ")
+	}
+	_ = enc.Encode(data)
+}
+
+func wantsPrettyJSON(r *http.Request) bool {
+	if r == nil {
+		return false
+	}
+	if pretty, err := strconv.ParseBool(r.URL.Query().Get("pretty")); err == nil && pretty {
+		return true
+	}
+	pretty, err := strconv.ParseBool(r.Header.Get("X-Pretty"))
+	return err == nil && pretty
 }
 
 func firstEnv(containers []corev1.Container) []corev1.EnvVar {
@@ -2241,15 +2651,36 @@ func wantsMetrics(r *http.Request) bool {
 	return val == "true" || val == "1" || val == "yes"
 }
 
-func extractEnv(namespace string, envs []corev1.EnvVar, envFrom []corev1.EnvFromSource, user *auth.User, revealSecrets bool, client *kubernetes.Clientset) (map[string]string, []string) {
+func wantsExpand(r *http.Request, field string) bool {
+	for _, raw := range strings.Split(r.URL.Query().Get("expand"), ",") {
+		if strings.TrimSpace(strings.ToLower(raw)) == field {
+			return true
+		}
+	}
+	return false
+}
+
+// canRevealSecrets requires membership in auth.allowed_secrets_groups and,
+// when auth.reveal_required_scope is set, that scope on the token, so secret
+// reveals can be gated behind a step-up login.
+func (h *KubeHandler) canRevealSecrets(user *auth.User) bool {
+	if user == nil || !user.AllowedSecrets {
+		return false
+	}
+	return h.cfg.Auth.RevealRequiredScope == "" || user.HasScope(h.cfg.Auth.RevealRequiredScope)
+}
+
+func (h *KubeHandler) extractEnv(ctx context.Context, namespace string, envs []corev1.EnvVar, envFrom []corev1.EnvFromSource, user *auth.User, revealSecrets bool) (map[string]string, []string) {
 	result := map[string]string{}
 	secretKeys := map[string]struct{}{}
-	canReveal := revealSecrets && user != nil && user.AllowedSecrets && client != nil
+	canReveal := revealSecrets && h.canRevealSecrets(user) && h.client != nil
 
-	if client != nil {
+	var secrets map[string]secretResult
+	if h.client != nil {
+		secrets = h.prefetchSecrets(ctx, namespace, referencedSecrets(envs, envFrom, canReveal), canReveal)
 		for _, source := range envFrom {
 			if source.ConfigMapRef != nil && source.ConfigMapRef.Name != "" {
-				data, err := fetchConfigMapData(client, namespace, source.ConfigMapRef.Name)
+				data, err := h.fetchConfigMapData(ctx, namespace, source.ConfigMapRef.Name)
 				if err != nil {
 					if source.ConfigMapRef.Optional != nil && *source.ConfigMapRef.Optional {
 						continue
@@ -2263,7 +2694,8 @@ func extractEnv(namespace string, envs []corev1.EnvVar, envFrom []corev1.EnvFrom
 				}
 			}
 			if source.SecretRef != nil && source.SecretRef.Name != "" {
-				data, err := fetchSecretData(client, namespace, source.SecretRef.Name)
+				res := secrets[source.SecretRef.Name]
+				data, err := res.data, res.err
 				if err != nil {
 					if source.SecretRef.Optional != nil && *source.SecretRef.Optional {
 						continue
@@ -2296,9 +2728,9 @@ func extractEnv(namespace string, envs []corev1.EnvVar, envFrom []corev1.EnvFrom
 		if env.ValueFrom.SecretKeyRef != nil {
 			secretKeys[env.Name] = struct{}{}
 			if canReveal {
-				value, err := fetchSecretValue(client, namespace, env.ValueFrom.SecretKeyRef.Name, env.ValueFrom.SecretKeyRef.Key)
-				if err == nil {
-					result[env.Name] = value
+				res := secrets[env.ValueFrom.SecretKeyRef.Name]
+				if value, ok := res.data[env.ValueFrom.SecretKeyRef.Key]; ok && res.err == nil {
+					result[env.Name] = string(value)
 					continue
 				}
 			}
@@ -2306,8 +2738,8 @@ func extractEnv(namespace string, envs []corev1.EnvVar, envFrom []corev1.EnvFrom
 			continue
 		}
 		if env.ValueFrom.ConfigMapKeyRef != nil {
-			if client != nil {
-				value, err := fetchConfigMapValue(client, namespace, env.ValueFrom.ConfigMapKeyRef.Name, env.ValueFrom.ConfigMapKeyRef.Key)
+			if h.client != nil {
+				value, err := h.fetchConfigMapValue(ctx, namespace, env.ValueFrom.ConfigMapKeyRef.Name, env.ValueFrom.ConfigMapKeyRef.Key)
 				if err == nil {
 					result[env.Name] = value
 					continue
@@ -2319,50 +2751,225 @@ func extractEnv(namespace string, envs []corev1.EnvVar, envFrom []corev1.EnvFrom
 	return result, mapKeys(secretKeys)
 }
 
-func fetchSecretData(client *kubernetes.Clientset, namespace, name string) (map[string][]byte, error) {
-	secret, err := client.CoreV1().Secrets(namespace).Get(context.Background(), name, metav1.GetOptions{})
-	if err != nil {
-		return nil, err
+// envMode says whether a mapper resolves env. List responses use envOmit and
+// get an empty map instead: resolving envFrom there would cost a ConfigMap or
+// Secret GET per listed resource on every refresh.
+type envMode int
+
+const (
+	envResolve envMode = iota
+	envOmit
+)
+
+// containerEnv resolves a workload's env for single-resource responses.
+func (h *KubeHandler) containerEnv(ctx context.Context, namespace string, envs []corev1.EnvVar, envFrom []corev1.EnvFromSource, user *auth.User, revealSecrets bool, mode envMode) (map[string]string, []string, bool) {
+	if mode == envOmit {
+		return map[string]string{}, []string{}, false
 	}
-	return secret.Data, nil
+	env, secretKeys := h.extractEnv(ctx, namespace, envs, envFrom, user, revealSecrets)
+	return limitEnv(env, secretKeys, envs, h.cfg.Kubernetes.MaxEnvEntries)
 }
 
-func fetchConfigMapData(client *kubernetes.Clientset, namespace, name string) (map[string]string, error) {
-	cfg, err := client.CoreV1().ConfigMaps(namespace).Get(context.Background(), name, metav1.GetOptions{})
+// limitEnv caps env at max entries so a pod pulling in a large ConfigMap via
+// envFrom does not bloat every response. Variables declared directly on the
+// container are kept first, then the rest in key order.
+func limitEnv(env map[string]string, secretKeys []string, declared []corev1.EnvVar, max int) (map[string]string, []string, bool) {
+	if max <= 0 || len(env) <= max {
+		return env, secretKeys, false
+	}
+	keys := make([]string, 0, len(env))
+	seen := make(map[string]struct{}, len(env))
+	for _, item := range declared {
+		if _, ok := env[item.Name]; !ok {
+			continue
+		}
+		if _, ok := seen[item.Name]; ok {
+			continue
+		}
+		seen[item.Name] = struct{}{}
+		keys = append(keys, item.Name)
+	}
+	rest := make([]string, 0, len(env)-len(keys))
+	for key := range env {
+		if _, ok := seen[key]; !ok {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	keys = append(keys, rest...)
+
+	limited := make(map[string]string, max)
+	for _, key := range keys[:max] {
+		limited[key] = env[key]
+	}
+	keptSecrets := make([]string, 0, len(secretKeys))
+	for _, key := range secretKeys {
+		if _, ok := limited[key]; ok {
+			keptSecrets = append(keptSecrets, key)
+		}
+	}
+	return limited, keptSecrets, true
+}
+
+type secretResult struct {
+	data map[string][]byte
+	err  error
+}
+
+// referencedSecrets lists the distinct Secrets extractEnv reads. envFrom
+// Secrets are always needed for their key names; single-key references only
+// when values are revealed, since otherwise they are masked unread.
+func referencedSecrets(envs []corev1.EnvVar, envFrom []corev1.EnvFromSource, reveal bool) []string {
+	seen := map[string]struct{}{}
+	var names []string
+	add := func(name string) {
+		if _, ok := seen[name]; ok || name == "" {
+			return
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	for _, source := range envFrom {
+		if source.SecretRef != nil {
+			add(source.SecretRef.Name)
+		}
+	}
+	if reveal {
+		for _, env := range envs {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+				add(env.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+	return names
+}
+
+// prefetchSecrets reads each named Secret once, with at most
+// secrets.reveal_concurrency requests in flight, so a pod referencing dozens
+// of Secrets neither waits on them serially nor bursts the apiserver.
+func (h *KubeHandler) prefetchSecrets(ctx context.Context, namespace string, names []string, cacheable bool) map[string]secretResult {
+	results := make([]secretResult, len(names))
+	var group errgroup.Group
+	group.SetLimit(max(h.cfg.Secrets.RevealConcurrency, 1))
+	for i, name := range names {
+		group.Go(func() error {
+			secret, err := h.getSecretCached(ctx, namespace, name, cacheable)
+			if err != nil {
+				results[i] = secretResult{err: err}
+				return nil
+			}
+			results[i] = secretResult{data: secret.Data}
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	out := make(map[string]secretResult, len(names))
+	for i, name := range names {
+		out[name] = results[i]
+	}
+	return out
+}
+
+func (h *KubeHandler) fetchConfigMapData(ctx context.Context, namespace, name string) (map[string]string, error) {
+	cfg, err := h.getConfigMapCached(ctx, namespace, name)
 	if err != nil {
 		return nil, err
 	}
 	return cfg.Data, nil
 }
 
-func fetchSecretValue(client *kubernetes.Clientset, namespace, name, key string) (string, error) {
-	secret, err := client.CoreV1().Secrets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+func (h *KubeHandler) fetchConfigMapValue(ctx context.Context, namespace, name, key string) (string, error) {
+	cfg, err := h.getConfigMapCached(ctx, namespace, name)
 	if err != nil {
 		return "", err
 	}
-	value, ok := secret.Data[key]
+	value, ok := cfg.Data[key]
 	if !ok {
-		return "", errors.New("secret key not found")
+		return "", errors.New("configmap key not found")
+	}
+	return value, nil
+}
+
+// getSecretCached only reads and fills the secret cache when cacheable is set,
+// which extractEnv does only for users allowed to reveal secret values.
+func (h *KubeHandler) getSecretCached(ctx context.Context, namespace, name string, cacheable bool) (*corev1.Secret, error) {
+	if h.cache == nil || !cacheable {
+		return h.getSecret(ctx, namespace, name)
 	}
-	return string(value), nil
+	key := namespace + "/" + name
+	return h.cache.doSecretGet(key, func() (*corev1.Secret, error) {
+		if items, ok := h.cache.getSecret(key); ok && len(items) == 1 {
+			secret := items[0]
+			return &secret, nil
+		}
+		secret, err := h.getSecret(ctx, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		h.cache.setSecret(key, []corev1.Secret{*secret})
+		return secret, nil
+	})
 }
 
-func fetchConfigMapValue(client *kubernetes.Clientset, namespace, name, key string) (string, error) {
-	cfg, err := client.CoreV1().ConfigMaps(namespace).Get(context.Background(), name, metav1.GetOptions{})
+func (h *KubeHandler) getSecret(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
+	ctx, span := tracing.Start(ctx, "secret.get", secretSpanAttrs(namespace, name)...)
+	defer span.End()
+	secret, err := h.client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
-		return "", err
+		tracing.Fail(span, err)
+		return nil, err
 	}
-	value, ok := cfg.Data[key]
-	if !ok {
-		return "", errors.New("configmap key not found")
+	// Keep only what env resolution reads, so cached entries stay small.
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secret.Name, Namespace: secret.Namespace},
+		Data:       secret.Data,
+	}, nil
+}
+
+// secretSpanAttrs identifies a secret on a span; values and keys are never
+// recorded.
+func secretSpanAttrs(namespace, name string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("k8s.namespace.name", namespace),
+		attribute.String("k8s.secret.name", name),
 	}
-	return value, nil
+}
+
+func (h *KubeHandler) getConfigMapCached(ctx context.Context, namespace, name string) (*corev1.ConfigMap, error) {
+	if h.cache == nil {
+		return h.getConfigMap(ctx, namespace, name)
+	}
+	key := namespace + "/" + name
+	return h.cache.doConfigMapGet(key, func() (*corev1.ConfigMap, error) {
+		if items, ok := h.cache.getConfigMap(key); ok && len(items) == 1 {
+			cfg := items[0]
+			return &cfg, nil
+		}
+		cfg, err := h.getConfigMap(ctx, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		h.cache.setConfigMap(key, []corev1.ConfigMap{*cfg})
+		return cfg, nil
+	})
+}
+
+func (h *KubeHandler) getConfigMap(ctx context.Context, namespace, name string) (*corev1.ConfigMap, error) {
+	cfg, err := h.client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: cfg.Name, Namespace: cfg.Namespace},
+		Data:       cfg.Data,
+	}, nil
 }
 
 func (h *KubeHandler) listPodMetricsCached(ctx context.Context, namespace string) (*metricsSnapshot, error) {
 	staleSeconds := h.cfg.Kubernetes.APICache.MetricsStaleSeconds
 	if h.cache == nil {
-		items, err := listPodMetrics(ctx, h.client, namespace, h.cache)
+		items, err := listPodMetrics(ctx, h.raw, namespace, h.cache)
 		if err != nil {
 			return nil, err
 		}
@@ -2371,11 +2978,17 @@ func (h *KubeHandler) listPodMetricsCached(ctx context.Context, namespace string
 
 	entry, hasCache := h.cache.getPodMetricsEntry(namespace)
 	if hasCache && (h.cache.metricsTTL <= 0 || time.Since(entry.fetched) <= h.cache.metricsTTL) {
+		if h.stats != nil {
+			h.stats.incMetricsCacheHit()
+		}
 		return mapMetricsSnapshot(entry.items, entry.fetched, staleSeconds), nil
 	}
+	if h.stats != nil {
+		h.stats.incMetricsCacheMiss()
+	}
 
 	_, err := h.cache.doPodMetrics(namespace, func() ([]podMetricItem, error) {
-		items, err := listPodMetrics(ctx, h.client, namespace, h.cache)
+		items, err := listPodMetrics(ctx, h.raw, namespace, h.cache)
 		if err != nil {
 			if hasCache {
 				return entry.items, nil
@@ -2419,18 +3032,29 @@ func mapMetricsSnapshot(items []podMetricItem, fetched time.Time, staleSeconds i
 	}
 }
 
-func listPodMetrics(ctx context.Context, client *kubernetes.Clientset, namespace string, cache *resourceCache) ([]podMetricItem, error) {
+func listPodMetrics(ctx context.Context, raw rawClient, namespace string, cache *resourceCache) ([]podMetricItem, error) {
 	path := fmt.Sprintf("/apis/metrics.k8s.io/v1beta1/namespaces/%s/pods", namespace)
+	var stats *ResourceStats
+	if cache != nil {
+		stats = cache.stats
+	}
+	if stats != nil {
+		stats.incMetricsAPICall()
+	}
 	var data []byte
 	err := retryK8s(ctx, cache, func(ctx context.Context) error {
 		var err error
-		data, err = client.RESTClient().Get().AbsPath(path).Do(ctx).Raw()
+		data, err = raw.getRaw(ctx, path)
 		return err
 	})
 	if err != nil {
+		// metrics.k8s.io is not registered when metrics-server is absent.
 		if apierrors.IsNotFound(err) {
 			return []podMetricItem{}, nil
 		}
+		if stats != nil {
+			stats.incMetricsAPIErr()
+		}
 		return nil, err
 	}
 
@@ -2476,11 +3100,22 @@ func listPodMetrics(ctx context.Context, client *kubernetes.Clientset, namespace
 	return items, nil
 }
 
-func (h *KubeHandler) fetchPodMetrics(ctx context.Context, namespace, name string) (resourceUsage, error) {
+func (h *KubeHandler) fetchPodMetrics(ctx context.Context, namespace, name string) (resource.Quantity, resource.Quantity, error) {
 	path := fmt.Sprintf("/apis/metrics.k8s.io/v1beta1/namespaces/%s/pods/%s", namespace, name)
-	data, err := h.client.RESTClient().Get().AbsPath(path).Do(ctx).Raw()
+	if h.stats != nil {
+		h.stats.incMetricsAPICall()
+	}
+	var data []byte
+	err := retryK8s(ctx, h.cache, func(ctx context.Context) error {
+		var err error
+		data, err = h.raw.getRaw(ctx, path)
+		return err
+	})
 	if err != nil {
-		return resourceUsage{}, err
+		if h.stats != nil && !apierrors.IsNotFound(err) {
+			h.stats.incMetricsAPIErr()
+		}
+		return resource.Quantity{}, resource.Quantity{}, err
 	}
 
 	var payload struct {
@@ -2489,7 +3124,7 @@ func (h *KubeHandler) fetchPodMetrics(ctx context.Context, namespace, name strin
 		} `json:"containers"`
 	}
 	if err := json.Unmarshal(data, &payload); err != nil {
-		return resourceUsage{}, err
+		return resource.Quantity{}, resource.Quantity{}, err
 	}
 
 	cpu := resource.Quantity{}
@@ -2507,92 +3142,145 @@ func (h *KubeHandler) fetchPodMetrics(ctx context.Context, namespace, name strin
 		}
 	}
 
-	return resourceUsage{
-		CPUUsage: formatCPUUsage(cpu),
-		MemUsage: formatMemUsage(mem),
-	}, nil
+	return cpu, mem, nil
 }
 
 func (h *KubeHandler) listCnpgClusters(ctx context.Context, namespace string) ([]cnpgCluster, error) {
-	path := fmt.Sprintf(cnpgClusterListPathFmt, namespace)
-	var data []byte
-	if h.cache != nil && h.cache.stats != nil {
-		h.cache.stats.incCnpgAPICall()
-	}
-	err := retryK8s(ctx, h.cache, func(ctx context.Context) error {
-		var err error
-		data, err = h.client.RESTClient().Get().AbsPath(path).Do(ctx).Raw()
-		return err
-	})
-	if err != nil {
-		if h.cache != nil && h.cache.stats != nil && !apierrors.IsNotFound(err) {
-			h.cache.stats.incCnpgAPIErr()
-		}
-		if apierrors.IsNotFound(err) {
-			return nil, nil
-		}
-		return nil, err
-	}
 	var list cnpgClusterList
-	if err := json.Unmarshal(data, &list); err != nil {
+	found, err := h.fetchCRDList(ctx, fmt.Sprintf(cnpgClusterListPathFmt, namespace), &list, (*ResourceStats).incCnpgAPICall, (*ResourceStats).incCnpgAPIErr)
+	if err != nil || !found {
 		return nil, err
 	}
 	return list.Items, nil
 }
 
 func (h *KubeHandler) getCnpgCluster(ctx context.Context, namespace, name string) (*cnpgCluster, error) {
-	path := fmt.Sprintf(cnpgClusterGetPathFmt, namespace, name)
-	data, err := h.client.RESTClient().Get().AbsPath(path).Do(ctx).Raw()
-	if err != nil {
-		return nil, err
-	}
 	var cluster cnpgCluster
-	if err := json.Unmarshal(data, &cluster); err != nil {
+	if err := h.fetchCRD(ctx, fmt.Sprintf(cnpgClusterGetPathFmt, namespace, name), &cluster, (*ResourceStats).incCnpgAPICall, (*ResourceStats).incCnpgAPIErr); err != nil {
 		return nil, err
 	}
 	return &cluster, nil
 }
 
 func (h *KubeHandler) listDragonflies(ctx context.Context, namespace string) ([]dragonflyResource, error) {
-	path := fmt.Sprintf(dragonflyListPathFmt, namespace)
-	var data []byte
-	if h.cache != nil && h.cache.stats != nil {
-		h.cache.stats.incDragonAPICall()
-	}
-	err := retryK8s(ctx, h.cache, func(ctx context.Context) error {
-		var err error
-		data, err = h.client.RESTClient().Get().AbsPath(path).Do(ctx).Raw()
-		return err
-	})
-	if err != nil {
-		if h.cache != nil && h.cache.stats != nil && !apierrors.IsNotFound(err) {
-			h.cache.stats.incDragonAPIErr()
-		}
-		if apierrors.IsNotFound(err) {
-			return nil, nil
-		}
-		return nil, err
-	}
 	var list dragonflyList
-	if err := json.Unmarshal(data, &list); err != nil {
+	found, err := h.fetchCRDList(ctx, fmt.Sprintf(dragonflyListPathFmt, namespace), &list, (*ResourceStats).incDragonAPICall, (*ResourceStats).incDragonAPIErr)
+	if err != nil || !found {
 		return nil, err
 	}
 	return list.Items, nil
 }
 
 func (h *KubeHandler) getDragonfly(ctx context.Context, namespace, name string) (*dragonflyResource, error) {
-	path := fmt.Sprintf(dragonflyGetPathFmt, namespace, name)
-	data, err := h.client.RESTClient().Get().AbsPath(path).Do(ctx).Raw()
-	if err != nil {
-		return nil, err
-	}
 	var dragonfly dragonflyResource
-	if err := json.Unmarshal(data, &dragonfly); err != nil {
+	if err := h.fetchCRD(ctx, fmt.Sprintf(dragonflyGetPathFmt, namespace, name), &dragonfly, (*ResourceStats).incDragonAPICall, (*ResourceStats).incDragonAPIErr); err != nil {
 		return nil, err
 	}
 	return &dragonfly, nil
 }
 
+func (h *KubeHandler) fetchCRDList(ctx context.Context, path string, out any, incCall, incErr func(*ResourceStats)) (bool, error) {
+	var stats *ResourceStats
+	if h.cache != nil {
+		stats = h.cache.stats
+	}
+	if stats != nil {
+		incCall(stats)
+	}
+	data, err := h.getRawWithRetry(ctx, path)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		if stats != nil {
+			incErr(stats)
+		}
+		return false, err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (h *KubeHandler) fetchCRD(ctx context.Context, path string, out any, incCall, incErr func(*ResourceStats)) error {
+	var stats *ResourceStats
+	if h.cache != nil {
+		stats = h.cache.stats
+	}
+	if stats != nil {
+		incCall(stats)
+	}
+	data, err := h.getRawWithRetry(ctx, path)
+	if err != nil {
+		if stats != nil && !apierrors.IsNotFound(err) {
+			incErr(stats)
+		}
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (h *KubeHandler) getCnpgClusterCached(ctx context.Context, namespace, name string) (*cnpgCluster, error) {
+	if h.cache == nil {
+		return h.getCnpgCluster(ctx, namespace, name)
+	}
+	key := namespace + "/" + name
+	return h.cache.doCnpgGet(key, func() (*cnpgCluster, error) {
+		if items, ok := h.cache.getCnpgByName(key); ok && len(items) == 1 {
+			if h.stats != nil {
+				h.stats.incCnpgCacheHit()
+			}
+			cluster := items[0]
+			return &cluster, nil
+		}
+		if h.stats != nil {
+			h.stats.incCnpgCacheMiss()
+		}
+		cluster, err := h.getCnpgCluster(ctx, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		h.cache.setCnpgByName(key, []cnpgCluster{*cluster})
+		return cluster, nil
+	})
+}
+
+func (h *KubeHandler) getDragonflyCached(ctx context.Context, namespace, name string) (*dragonflyResource, error) {
+	if h.cache == nil {
+		return h.getDragonfly(ctx, namespace, name)
+	}
+	key := namespace + "/" + name
+	return h.cache.doDragonflyGet(key, func() (*dragonflyResource, error) {
+		if items, ok := h.cache.getDragonflyByName(key); ok && len(items) == 1 {
+			if h.stats != nil {
+				h.stats.incDragonCacheHit()
+			}
+			dragonfly := items[0]
+			return &dragonfly, nil
+		}
+		if h.stats != nil {
+			h.stats.incDragonCacheMiss()
+		}
+		dragonfly, err := h.getDragonfly(ctx, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		h.cache.setDragonflyByName(key, []dragonflyResource{*dragonfly})
+		return dragonfly, nil
+	})
+}
+
+func (h *KubeHandler) getRawWithRetry(ctx context.Context, path string) ([]byte, error) {
+	var data []byte
+	err := retryK8s(ctx, h.cache, func(ctx context.Context) error {
+		var err error
+		data, err = h.raw.getRaw(ctx, path)
+		return err
+	})
+	return data, err
+}
+
 func (h *KubeHandler) listPodsForApp(ctx context.Context, namespace, name string) ([]string, error) {
 	selector, err := h.appSelector(ctx, namespace, name)
 	if err != nil {
@@ -2682,7 +3370,7 @@ func (h *KubeHandler) appSelector(ctx context.Context, namespace, name string) (
 		}
 	}
 
-	cluster, err := h.getCnpgCluster(ctx, namespace, name)
+	cluster, err := h.getCnpgClusterCached(ctx, namespace, name)
 	if err == nil {
 		return fmt.Sprintf("%s=%s", cnpgClusterLabelKey, cluster.Metadata.Name), nil
 	}
@@ -2690,7 +3378,7 @@ func (h *KubeHandler) appSelector(ctx context.Context, namespace, name string) (
 		return "", err
 	}
 
-	dragonfly, err := h.getDragonfly(ctx, namespace, name)
+	dragonfly, err := h.getDragonflyCached(ctx, namespace, name)
 	if err == nil {
 		return fmt.Sprintf("%s=%s", dragonflyAppLabelKey, dragonfly.Metadata.Name), nil
 	}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestFormatCPU(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"1", "1000m"},
+		{"1000m", "1000m"},
+		{"250m", "250m"},
+		{"0.5", "500m"},
+		{"1500000000n", "1500m"},
+		{"0", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := formatCPU(resource.MustParse(tt.in)); got != tt.want {
+				t.Fatalf("formatCPU(%s) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatMemory(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"1.5Gi", "1536Mi"},
+		{"1536Mi", "1536Mi"},
+		{"1610612736", "1536Mi"},
+		{"1M", "0.95Mi"},
+		{"512Ki", "0.5Mi"},
+		{"0", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := formatMemory(resource.MustParse(tt.in)); got != tt.want {
+				t.Fatalf("formatMemory(%s) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestApplyUsageMixedUnits sums metrics written in different units and checks
+// that usage and requests come out in the same units whatever the inputs were.
+func TestApplyUsageMixedUnits(t *testing.T) {
+	tests := []struct {
+		name     string
+		cpu      []string
+		mem      []string
+		wantCPU  string
+		wantMem  string
+		wantUtil float64
+	}{
+		{"cores and millicores", []string{"1", "500m"}, []string{"1Gi", "512Mi"}, "1500m", "1536Mi", 75},
+		{"nanocores and decimal bytes", []string{"250000000n", "0.25"}, []string{"536870912", "0.5Gi"}, "500m", "1024Mi", 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot := &metricsSnapshot{items: map[string]podMetricItem{}}
+			names := make([]string, len(tt.cpu))
+			for i := range tt.cpu {
+				names[i] = string(rune('a' + i))
+				snapshot.items[names[i]] = podMetricItem{
+					Name: names[i],
+					CPU:  resource.MustParse(tt.cpu[i]),
+					Mem:  resource.MustParse(tt.mem[i]),
+				}
+			}
+			cpu, mem, found := snapshot.usageForPods(names)
+			if found != len(names) {
+				t.Fatalf("found = %d, want %d", found, len(names))
+			}
+
+			var requests resourceTotals
+			requests.add(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1000m")})
+			var usage resourceUsage
+			usage.applyUsage(cpu, mem, len(names), requests, resourceTotals{})
+			if usage.CPUUsage != tt.wantCPU || usage.MemUsage != tt.wantMem {
+				t.Errorf("usage = %s/%s, want %s/%s", usage.CPUUsage, usage.MemUsage, tt.wantCPU, tt.wantMem)
+			}
+			if got := usage.CPUUtilPercent.Request; got == nil || *got != tt.wantUtil {
+				t.Errorf("cpu request util = %v, want %v", got, tt.wantUtil)
+			}
+			if usage.CPUUtilPercent.Limit != nil || usage.MemUtilPercent.Request != nil {
+				t.Errorf("util without a total should be nil: %+v %+v", usage.CPUUtilPercent, usage.MemUtilPercent)
+			}
+		})
+	}
+}
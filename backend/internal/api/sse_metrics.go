@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+type sseKind int
+
+const (
+	sseKindPod sseKind = iota
+	sseKindApp
+	sseKindCount
+)
+
+var sseKindNames = [sseKindCount]string{"pod", "app"}
+
+// sseCloseReason is why a log stream handler returned. Write errors are the
+// interesting case: they mean the client or a proxy stopped reading, while
+// client_gone is normal navigation.
+type sseCloseReason int
+
+const (
+	sseCloseClientGone sseCloseReason = iota
+	sseCloseWriteError
+	sseCloseTimeout
+	sseCloseSourceEnded
+	sseCloseCount
+)
+
+var sseCloseReasonNames = [sseCloseCount]string{"client_gone", "write_error", "timeout", "source_ended"}
+
+type sseMetrics struct {
+	closes [sseKindCount][sseCloseCount]atomic.Int64
+	// appEventsDropped counts events the app stream pool skipped because a
+	// subscriber's buffer was full. Slow subscribers are never closed; they
+	// only miss events.
+	appEventsDropped atomic.Int64
+}
+
+// SSECloseStats counts ended log streams by kind (pod or app) and reason.
+type SSECloseStats struct {
+	Stream string
+	Reason string
+	Count  int64
+}
+
+func (m *sseMetrics) snapshot() []SSECloseStats {
+	items := make([]SSECloseStats, 0, int(sseKindCount)*int(sseCloseCount))
+	for kind := sseKind(0); kind < sseKindCount; kind++ {
+		for reason := sseCloseReason(0); reason < sseCloseCount; reason++ {
+			items = append(items, SSECloseStats{
+				Stream: sseKindNames[kind],
+				Reason: sseCloseReasonNames[reason],
+				Count:  m.closes[kind][reason].Load(),
+			})
+		}
+	}
+	return items
+}
+
+// sseWriteRecorder remembers the first failed write so a stream handler can
+// tell a broken connection from a client that disconnected cleanly.
+type sseWriteRecorder struct {
+	http.ResponseWriter
+	err error
+}
+
+func (w *sseWriteRecorder) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
+
+// recordSSEClose counts a finished stream. A failed write wins over the
+// reason the handler set, since a stream that could not be written to ended
+// because of it.
+func (h *logStreamHub) recordSSEClose(kind sseKind, reason sseCloseReason, rec *sseWriteRecorder) {
+	if h == nil {
+		return
+	}
+	if rec != nil && rec.err != nil {
+		reason = sseCloseWriteError
+	}
+	h.sse.closes[kind][reason].Add(1)
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -27,11 +28,27 @@ type User struct {
 	Subject        string
 	Groups         []string
 	AllowedSecrets bool
+	Admin          bool
+	// Name is the auth.name_claim value for display and audit, or Subject
+	// when the token lacks that claim.
+	Name string
+	// Scopes holds the space-separated OAuth scopes from the scope claim.
+	Scopes []string
+	// AuthTime is when the user last authenticated interactively (auth_time);
+	// zero when the token does not carry the claim.
+	AuthTime time.Time
+}
+
+// HasScope reports whether the token was issued with scope.
+func (u *User) HasScope(scope string) bool {
+	return u != nil && slices.Contains(u.Scopes, scope)
 }
 
 type Claims struct {
-	Groups          []string `json:"groups"`
-	AuthorizedParty string   `json:"azp"`
+	Groups          []string         `json:"groups"`
+	AuthorizedParty string           `json:"azp"`
+	Scope           string           `json:"scope"`
+	AuthTime        *jwt.NumericDate `json:"auth_time,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -41,6 +58,8 @@ type Verifier struct {
 	audience       string
 	allowedGroups  map[string]struct{}
 	allowedSecrets map[string]struct{}
+	adminGroups    map[string]struct{}
+	nameClaim      string
 }
 
 type VerifierProvider interface {
@@ -77,11 +96,14 @@ type oidcConfig struct {
 	JWKSURI string `json:"jwks_uri"`
 }
 
-func NewVerifier(ctx context.Context, cfg config.AuthConfig) (*Verifier, error) {
+func NewVerifier(ctx context.Context, cfg config.AuthConfig, tlsCfg config.TLSConfig) (*Verifier, error) {
 	issuerURL := strings.TrimRight(cfg.KeycloakURL, "/") + "/realms/" + cfg.Realm
 	wellKnown := issuerURL + "/.well-known/openid-configuration"
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client, err := NewHTTPClient(tlsCfg, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
 	resp, err := client.Get(wellKnown)
 	if err != nil {
 		return nil, fmt.Errorf("fetch oidc config: %w", err)
@@ -102,7 +124,7 @@ func NewVerifier(ctx context.Context, cfg config.AuthConfig) (*Verifier, error)
 		issuerURL = meta.Issuer
 	}
 
-	jwks, err := keyfunc.NewDefaultCtx(ctx, []string{meta.JWKSURI})
+	jwks, err := keyfunc.NewDefaultOverrideCtx(ctx, []string{meta.JWKSURI}, keyfunc.Override{Client: client})
 	if err != nil {
 		return nil, fmt.Errorf("init jwks: %w", err)
 	}
@@ -115,6 +137,10 @@ func NewVerifier(ctx context.Context, cfg config.AuthConfig) (*Verifier, error)
 	for _, g := range cfg.AllowedSecretsGroups {
 		allowedSecrets[g] = struct{}{}
 	}
+	adminGroups := make(map[string]struct{})
+	for _, g := range cfg.AdminGroups {
+		adminGroups[g] = struct{}{}
+	}
 
 	return &Verifier{
 		jwks:           jwks,
@@ -122,6 +148,8 @@ func NewVerifier(ctx context.Context, cfg config.AuthConfig) (*Verifier, error)
 		audience:       cfg.ClientID,
 		allowedGroups:  allowed,
 		allowedSecrets: allowedSecrets,
+		adminGroups:    adminGroups,
+		nameClaim:      cfg.NameClaim,
 	}, nil
 }
 
@@ -175,10 +203,40 @@ func (v *Verifier) AuthenticateRequest(r *http.Request) (*User, error) {
 		Subject:        claims.Subject,
 		Groups:         claims.Groups,
 		AllowedSecrets: v.hasAnyGroup(claims.Groups, v.allowedSecrets),
+		Admin:          v.hasAnyGroup(claims.Groups, v.adminGroups),
+		Scopes:         strings.Fields(claims.Scope),
+	}
+	if claims.AuthTime != nil {
+		user.AuthTime = claims.AuthTime.Time
 	}
+	user.Name = v.displayName(tokenString, claims.Subject)
 	return user, nil
 }
 
+// displayName reads v.nameClaim from the already verified token. The claim
+// is configurable, so it is looked up in the raw payload instead of Claims.
+func (v *Verifier) displayName(tokenString, subject string) string {
+	if v.nameClaim == "" {
+		return subject
+	}
+	segments := strings.Split(tokenString, ".")
+	if len(segments) != 3 {
+		return subject
+	}
+	payload, err := jwt.NewParser().DecodeSegment(segments[1])
+	if err != nil {
+		return subject
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return subject
+	}
+	if name, ok := raw[v.nameClaim].(string); ok && name != "" {
+		return name
+	}
+	return subject
+}
+
 func (v *Verifier) hasAnyGroup(groups []string, allow map[string]struct{}) bool {
 	for _, g := range groups {
 		if _, ok := allow[g]; ok {
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/halceonio/kubelens/backend/internal/config"
+)
+
+// Keycloak is a single host, so the default of two idle connections per host
+// forces new TLS handshakes as soon as a burst of logins overlaps.
+const (
+	keycloakMaxIdleConns        = 64
+	keycloakMaxIdleConnsPerHost = 32
+	keycloakIdleConnTimeout     = 90 * time.Second
+)
+
+var (
+	transportMu     sync.Mutex
+	transportCAFile string
+	sharedTransport *http.Transport
+)
+
+// NewHTTPClient returns the client used for calls to Keycloak: OIDC discovery,
+// JWKS refresh, and the code exchange. When tlsCfg.CABundleFile is set its
+// certificates are trusted in addition to the system pool, for identity
+// providers behind a private CA. All clients share one pooled transport so
+// those calls reuse connections.
+func NewHTTPClient(tlsCfg config.TLSConfig, timeout time.Duration) (*http.Client, error) {
+	transport, err := keycloakTransport(tlsCfg.CABundleFile)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+// keycloakTransport returns the shared transport, replacing it when the CA
+// bundle path changes. Idle connections of the old transport are closed once
+// it is swapped out.
+func keycloakTransport(caFile string) (*http.Transport, error) {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	if sharedTransport != nil && transportCAFile == caFile {
+		return sharedTransport, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = keycloakMaxIdleConns
+	transport.MaxIdleConnsPerHost = keycloakMaxIdleConnsPerHost
+	transport.IdleConnTimeout = keycloakIdleConnTimeout
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read tls.ca_bundle_file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls.ca_bundle_file %q contains no PEM certificates", caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	if sharedTransport != nil {
+		sharedTransport.CloseIdleConnections()
+	}
+	sharedTransport = transport
+	transportCAFile = caFile
+	return transport, nil
+}
@@ -11,6 +11,21 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// Redis key prefixes shared by the session store and the log hub. They must
+// not nest, since both may live in the same Redis database.
+const (
+	DefaultSessionRedisPrefix = "kubelens:session:"
+	DefaultRedisStreamPrefix  = "kubelens:logs"
+)
+
+// App stream channel sizes. Below the minimum, a single burst from one pod
+// already overflows the buffer and lines are dropped.
+const (
+	DefaultAppSubscriberBuffer = 256
+	DefaultAppLogBuffer        = 512
+	MinAppStreamBuffer         = 16
+)
+
 type Config struct {
 	Server     ServerConfig     `yaml:"server"`
 	Auth       AuthConfig       `yaml:"auth"`
@@ -19,14 +34,50 @@ type Config struct {
 	Storage    StorageConfig    `yaml:"storage"`
 	Cache      CacheConfig      `yaml:"cache"`
 	Kubernetes KubernetesConfig `yaml:"kubernetes"`
+	TLS        TLSConfig        `yaml:"tls"`
+	Secrets    SecretsConfig    `yaml:"secrets"`
+}
+
+// SecretsConfig bounds the Secret reads made to resolve env values.
+type SecretsConfig struct {
+	RevealConcurrency int `yaml:"reveal_concurrency"`
+}
+
+// TLSConfig adjusts outbound TLS for calls the backend makes to Keycloak. The
+// Kubernetes client keeps using the kubeconfig or in-cluster CA.
+type TLSConfig struct {
+	CABundleFile string `yaml:"ca_bundle_file"`
 }
 
 type ServerConfig struct {
-	Address             string `yaml:"address"`
-	ReadTimeoutSeconds  int    `yaml:"read_timeout_seconds"`
-	WriteTimeoutSeconds int    `yaml:"write_timeout_seconds"`
-	IdleTimeoutSeconds  int    `yaml:"idle_timeout_seconds"`
-	AuditLogs           bool   `yaml:"audit_logs"`
+	Address                string     `yaml:"address"`
+	BasePath               string     `yaml:"base_path"`
+	ReadTimeoutSeconds     int        `yaml:"read_timeout_seconds"`
+	WriteTimeoutSeconds    int        `yaml:"write_timeout_seconds"`
+	IdleTimeoutSeconds     int        `yaml:"idle_timeout_seconds"`
+	ShutdownTimeoutSeconds int        `yaml:"shutdown_timeout_seconds"`
+	AuditLogs              bool       `yaml:"audit_logs"`
+	AuditReads             bool       `yaml:"audit_reads"`
+	AuditFormat            string     `yaml:"audit_format"`
+	AuditFile              string     `yaml:"audit_file"`
+	AuditSink              string     `yaml:"audit_sink"`
+	AuditBufferSize        int        `yaml:"audit_buffer_size"`
+	AuditRedisURL          string     `yaml:"audit_redis_url"`
+	AuditRedisStream       string     `yaml:"audit_redis_stream"`
+	AuditHTTPURL           string     `yaml:"audit_http_url"`
+	TrustedProxies         []string   `yaml:"trusted_proxies"`
+	EnablePprof            bool       `yaml:"enable_pprof"`
+	ReadOnly               bool       `yaml:"read_only"`
+	Otel                   OtelConfig `yaml:"otel"`
+}
+
+// OtelConfig enables OpenTelemetry tracing. Tracing is off while Endpoint is
+// empty.
+type OtelConfig struct {
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"`
 }
 
 type AuthConfig struct {
@@ -37,30 +88,65 @@ type AuthConfig struct {
 	AllowedGroups        []string `yaml:"allowed_groups"`
 	LegacyAllowsGroups   []string `yaml:"allows_groups"`
 	AllowedSecretsGroups []string `yaml:"allowed_secrets_groups"`
+	AdminGroups          []string `yaml:"admin_groups"`
+	TokenRatePerMinute   int      `yaml:"token_rate_per_minute"`
+	TokenRateBurst       int      `yaml:"token_rate_burst"`
+	RevealRequiredScope  string   `yaml:"reveal_required_scope"`
+	MaxAuthAgeSeconds    int      `yaml:"max_auth_age_seconds"`
+	NameClaim            string   `yaml:"name_claim"`
+	StatefulLogin        bool     `yaml:"stateful_login"`
+	LoginStateTTLSeconds int      `yaml:"login_state_ttl_seconds"`
 }
 
 type LogsConfig struct {
+	Enabled                *bool               `yaml:"enabled"`
+	NamespaceGroups        map[string][]string `yaml:"namespace_groups"`
 	DefaultTailLines       int                 `yaml:"default_tail_lines"`
 	MaxTailLines           int                 `yaml:"max_tail_lines"`
+	MaxReplayLines         int                 `yaml:"max_replay_lines"`
 	MaxLineLength          int                 `yaml:"max_line_length"`
+	MaxBytesPerRequest     int64               `yaml:"max_bytes_per_request"`
 	AppStreamResync        int                 `yaml:"app_stream_resync_seconds"`
 	WorkerIdleTTLSeconds   int                 `yaml:"worker_idle_ttl_seconds"`
+	MaxStreams             int                 `yaml:"max_streams"`
+	ReorderWindowMillis    int                 `yaml:"reorder_window_ms"`
+	ReorderMaxLines        int                 `yaml:"reorder_max_lines"`
+	PrefixFormat           string              `yaml:"prefix_format"`
+	MaxPodsPerAppStream    int                 `yaml:"max_pods_per_app_stream"`
+	MaxConcurrentGetLogs   int                 `yaml:"max_concurrent_getlogs"`
 	WorkerBufferLines      int                 `yaml:"worker_buffer_lines"`
 	WorkerBufferMaxBytes   int                 `yaml:"worker_buffer_max_bytes"`
 	SubscriberBufferLines  int                 `yaml:"subscriber_buffer_lines"`
+	AppSubscriberBuffer    int                 `yaml:"app_subscriber_buffer"`
+	AppLogBuffer           int                 `yaml:"app_log_buffer"`
 	UseRedisStreams        bool                `yaml:"use_redis_streams"`
 	RedisStreamPrefix      string              `yaml:"redis_stream_prefix"`
 	RedisStreamMaxLen      int                 `yaml:"redis_stream_maxlen"`
 	RedisStreamBlockMillis int                 `yaml:"redis_stream_block_millis"`
 	RedisLockTTLSeconds    int                 `yaml:"redis_lock_ttl_seconds"`
+	RedisJanitorSeconds    int                 `yaml:"redis_janitor_interval_seconds"`
+	RedisPublishBatch      int                 `yaml:"redis_publish_batch_lines"`
+	RedisPublishMillis     int                 `yaml:"redis_publish_batch_millis"`
 	RedisURLOverride       string              `yaml:"redis_url"`
 	RateLimitPerMinute     int                 `yaml:"rate_limit_per_minute"`
 	RateLimitBurst         int                 `yaml:"rate_limit_burst"`
 	RateLimitOverrides     []RateLimitOverride `yaml:"rate_limit_overrides"`
+	GlobalRatePerMinute    int                 `yaml:"global_rate_per_minute"`
+	GlobalRateBurst        int                 `yaml:"global_rate_burst"`
+	DisplayTimezone        string              `yaml:"display_timezone"`
+	CaptureEnabled         bool                `yaml:"capture_enabled"`
+	CaptureDir             string              `yaml:"capture_dir"`
+	CaptureMaxBytes        int                 `yaml:"capture_max_bytes"`
+	CaptureGroups          []string            `yaml:"capture_groups"`
+	SearchMaxConcurrency   int                 `yaml:"search_max_concurrency"`
+	SearchMaxBytes         int                 `yaml:"search_max_bytes"`
+	SearchMaxResults       int                 `yaml:"search_max_results"`
+	MaxStreamDuration      int                 `yaml:"max_stream_duration_seconds"`
 }
 
 type SessionConfig struct {
-	MaxBytes int `yaml:"max_bytes"`
+	MaxBytes    int    `yaml:"max_bytes"`
+	RedisPrefix string `yaml:"redis_prefix"`
 }
 
 type StorageConfig struct {
@@ -73,16 +159,22 @@ type CacheConfig struct {
 }
 
 type KubernetesConfig struct {
-	ClusterName       string                 `yaml:"cluster_name"`
-	TerminatedLogTTL  int                    `yaml:"terminated_log_ttl"`
-	API               KubernetesAPI          `yaml:"api"`
-	APICache          KubernetesCache        `yaml:"api_cache"`
-	AllowedNamespaces []string               `yaml:"allowed_namespaces"`
-	AppGroups         AppGroupsConfig        `yaml:"app_groups"`
-	PodFilters        ResourceFilters        `yaml:"pod_filters"`
-	AppFilters        ResourceFilters        `yaml:"app_filters"`
-	LabelPrefix       string                 `yaml:"label_prefix"`
-	CustomResources   []CustomResourceConfig `yaml:"custom_resources"`
+	ClusterName        string                 `yaml:"cluster_name"`
+	TerminatedLogTTL   int                    `yaml:"terminated_log_ttl"`
+	API                KubernetesAPI          `yaml:"api"`
+	APICache           KubernetesCache        `yaml:"api_cache"`
+	AllowedNamespaces  []string               `yaml:"allowed_namespaces"`
+	ValidateNamespaces bool                   `yaml:"validate_namespaces"`
+	DefaultNamespace   string                 `yaml:"default_namespace"`
+	MaxListItems       int                    `yaml:"max_list_items"`
+	MaxEnvEntries      int                    `yaml:"max_env_entries"`
+	AppGroups          AppGroupsConfig        `yaml:"app_groups"`
+	PodFilters         ResourceFilters        `yaml:"pod_filters"`
+	AppFilters         ResourceFilters        `yaml:"app_filters"`
+	AnnotationFilters  AnnotationFilters      `yaml:"annotation_filters"`
+	MaskedMetadataKeys []string               `yaml:"masked_metadata_keys"`
+	LabelPrefix        string                 `yaml:"label_prefix"`
+	CustomResources    []CustomResourceConfig `yaml:"custom_resources"`
 }
 
 type CustomResourceConfig struct {
@@ -102,19 +194,24 @@ type KubernetesAPI struct {
 }
 
 type KubernetesCache struct {
-	EnableInformers       *bool `yaml:"enable_informers"`
-	InformerResyncSeconds int   `yaml:"informer_resync_seconds"`
-	PodListTTLSeconds     int   `yaml:"pod_list_ttl_seconds"`
-	AppListTTLSeconds     int   `yaml:"app_list_ttl_seconds"`
-	CRDListTTLSeconds     int   `yaml:"crd_list_ttl_seconds"`
-	MetricsListTTLSeconds int   `yaml:"metrics_list_ttl_seconds"`
-	MetricsRefreshSeconds int   `yaml:"metrics_refresh_seconds"`
-	MetricsRefreshJitter  int   `yaml:"metrics_refresh_jitter_seconds"`
-	MetricsStaleSeconds   int   `yaml:"metrics_stale_seconds"`
-	WarmOnStartup         bool  `yaml:"warm_on_startup"`
-	RetryAttempts         int   `yaml:"retry_attempts"`
-	RetryBaseDelayMillis  int   `yaml:"retry_base_delay_ms"`
-	MetadataOnly          bool  `yaml:"metadata_only"`
+	EnableInformers        *bool `yaml:"enable_informers"`
+	InformerResyncSeconds  int   `yaml:"informer_resync_seconds"`
+	InformerSyncWaitMillis int   `yaml:"informer_sync_wait_ms"`
+	PodListTTLSeconds      int   `yaml:"pod_list_ttl_seconds"`
+	AppListTTLSeconds      int   `yaml:"app_list_ttl_seconds"`
+	CRDListTTLSeconds      int   `yaml:"crd_list_ttl_seconds"`
+	MetricsListTTLSeconds  int   `yaml:"metrics_list_ttl_seconds"`
+	ConfigMapTTLSeconds    int   `yaml:"configmap_ttl_seconds"`
+	SecretTTLSeconds       int   `yaml:"secret_ttl_seconds"`
+	MetricsRefreshSeconds  int   `yaml:"metrics_refresh_seconds"`
+	MetricsRefreshJitter   int   `yaml:"metrics_refresh_jitter_seconds"`
+	MetricsStaleSeconds    int   `yaml:"metrics_stale_seconds"`
+	MetricsHistorySamples  int   `yaml:"metrics_history_samples"`
+	MetricsHistoryMaxPods  int   `yaml:"metrics_history_max_pods"`
+	WarmOnStartup          bool  `yaml:"warm_on_startup"`
+	RetryAttempts          int   `yaml:"retry_attempts"`
+	RetryBaseDelayMillis   int   `yaml:"retry_base_delay_ms"`
+	MetadataOnly           bool  `yaml:"metadata_only"`
 }
 
 type RateLimitOverride struct {
@@ -135,8 +232,14 @@ type AppGroupLabels struct {
 	Version     string `yaml:"version"`
 }
 
+type AnnotationFilters struct {
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+}
+
 type ResourceFilters struct {
 	IncludeRegex  string   `yaml:"include_regex"`
+	IncludeLabels []string `yaml:"include_labels"`
 	ExcludeLabels []string `yaml:"exclude_labels"`
 }
 
@@ -211,6 +314,37 @@ func applyDefaults(cfg *Config) {
 	if cfg.Server.IdleTimeoutSeconds == 0 {
 		cfg.Server.IdleTimeoutSeconds = 60
 	}
+	if cfg.Server.ShutdownTimeoutSeconds == 0 {
+		cfg.Server.ShutdownTimeoutSeconds = 10
+	}
+	cfg.Server.BasePath = normalizeBasePath(cfg.Server.BasePath)
+
+	if cfg.Server.Otel.ServiceName == "" {
+		cfg.Server.Otel.ServiceName = "kubelens-backend"
+	}
+	if cfg.Server.Otel.SampleRatio == 0 {
+		cfg.Server.Otel.SampleRatio = 1
+	}
+	if cfg.Server.TrustedProxies == nil {
+		cfg.Server.TrustedProxies = []string{"127.0.0.1/32", "::1/128"}
+	}
+
+	if cfg.Auth.TokenRatePerMinute == 0 {
+		cfg.Auth.TokenRatePerMinute = 30
+	}
+	if cfg.Auth.TokenRateBurst == 0 {
+		cfg.Auth.TokenRateBurst = 10
+	}
+	if cfg.Secrets.RevealConcurrency <= 0 {
+		cfg.Secrets.RevealConcurrency = 4
+	}
+
+	if cfg.Auth.NameClaim == "" {
+		cfg.Auth.NameClaim = "preferred_username"
+	}
+	if cfg.Auth.LoginStateTTLSeconds <= 0 {
+		cfg.Auth.LoginStateTTLSeconds = 600
+	}
 
 	if len(cfg.Auth.AllowedGroups) == 0 && len(cfg.Auth.LegacyAllowsGroups) > 0 {
 		cfg.Auth.AllowedGroups = cfg.Auth.LegacyAllowsGroups
@@ -222,15 +356,54 @@ func applyDefaults(cfg *Config) {
 	if cfg.Logs.MaxTailLines == 0 {
 		cfg.Logs.MaxTailLines = 10000
 	}
+	if cfg.Logs.MaxReplayLines == 0 {
+		cfg.Logs.MaxReplayLines = cfg.Logs.MaxTailLines
+	}
 	if cfg.Logs.MaxLineLength == 0 {
 		cfg.Logs.MaxLineLength = 10000
 	}
+	if cfg.Logs.CaptureMaxBytes == 0 {
+		cfg.Logs.CaptureMaxBytes = 100 * 1024 * 1024
+	}
 	if cfg.Logs.AppStreamResync == 0 {
 		cfg.Logs.AppStreamResync = 10
 	}
+	if cfg.Logs.ReorderWindowMillis == 0 {
+		cfg.Logs.ReorderWindowMillis = 500
+	}
+	if cfg.Logs.ReorderMaxLines == 0 {
+		cfg.Logs.ReorderMaxLines = 5000
+	}
+	if cfg.Logs.AppSubscriberBuffer == 0 {
+		cfg.Logs.AppSubscriberBuffer = DefaultAppSubscriberBuffer
+	}
+	if cfg.Logs.AppLogBuffer == 0 {
+		cfg.Logs.AppLogBuffer = DefaultAppLogBuffer
+	}
+	if cfg.Logs.PrefixFormat == "" {
+		cfg.Logs.PrefixFormat = "[{pod}/{container}] "
+	}
+	if cfg.Logs.SearchMaxConcurrency == 0 {
+		cfg.Logs.SearchMaxConcurrency = 4
+	}
+	if cfg.Logs.SearchMaxBytes == 0 {
+		cfg.Logs.SearchMaxBytes = 64 * 1024 * 1024
+	}
+	if cfg.Logs.SearchMaxResults == 0 {
+		cfg.Logs.SearchMaxResults = 1000
+	}
 	if cfg.Session.MaxBytes == 0 {
 		cfg.Session.MaxBytes = 256 * 1024
 	}
+	if cfg.Session.RedisPrefix == "" {
+		cfg.Session.RedisPrefix = DefaultSessionRedisPrefix
+	}
+	if cfg.Kubernetes.MaxListItems == 0 {
+		cfg.Kubernetes.MaxListItems = 10000
+	}
+	if cfg.Kubernetes.MaxEnvEntries == 0 {
+		cfg.Kubernetes.MaxEnvEntries = 200
+	}
 	if cfg.Kubernetes.TerminatedLogTTL == 0 {
 		cfg.Kubernetes.TerminatedLogTTL = int((time.Minute * 60).Seconds())
 	}
@@ -243,8 +416,11 @@ func applyDefaults(cfg *Config) {
 	if cfg.Kubernetes.APICache.CRDListTTLSeconds == 0 {
 		cfg.Kubernetes.APICache.CRDListTTLSeconds = 10
 	}
-	if cfg.Kubernetes.APICache.MetricsListTTLSeconds == 0 {
-		cfg.Kubernetes.APICache.MetricsListTTLSeconds = 5
+	if cfg.Kubernetes.APICache.ConfigMapTTLSeconds == 0 {
+		cfg.Kubernetes.APICache.ConfigMapTTLSeconds = 10
+	}
+	if cfg.Kubernetes.APICache.SecretTTLSeconds == 0 {
+		cfg.Kubernetes.APICache.SecretTTLSeconds = 2
 	}
 	if cfg.Kubernetes.APICache.MetricsRefreshSeconds == 0 {
 		cfg.Kubernetes.APICache.MetricsRefreshSeconds = 15
@@ -252,18 +428,38 @@ func applyDefaults(cfg *Config) {
 	if cfg.Kubernetes.APICache.MetricsRefreshJitter == 0 {
 		cfg.Kubernetes.APICache.MetricsRefreshJitter = 5
 	}
+	if cfg.Kubernetes.APICache.MetricsListTTLSeconds == 0 {
+		// Outlive one refresh cycle so cached metrics are not evicted between background refreshes.
+		cfg.Kubernetes.APICache.MetricsListTTLSeconds = cfg.Kubernetes.APICache.MetricsRefreshSeconds + cfg.Kubernetes.APICache.MetricsRefreshJitter
+	}
 	if cfg.Kubernetes.APICache.MetricsStaleSeconds == 0 {
 		cfg.Kubernetes.APICache.MetricsStaleSeconds = 30
 	}
+	if cfg.Kubernetes.APICache.MetricsHistorySamples == 0 {
+		cfg.Kubernetes.APICache.MetricsHistorySamples = 60
+	}
+	if cfg.Kubernetes.APICache.MetricsHistoryMaxPods == 0 {
+		cfg.Kubernetes.APICache.MetricsHistoryMaxPods = 2000
+	}
 	if cfg.Kubernetes.APICache.InformerResyncSeconds == 0 {
 		cfg.Kubernetes.APICache.InformerResyncSeconds = 30
 	}
+	if cfg.Kubernetes.APICache.InformerSyncWaitMillis == 0 {
+		cfg.Kubernetes.APICache.InformerSyncWaitMillis = 2000
+	}
 	if cfg.Kubernetes.APICache.RetryAttempts == 0 {
 		cfg.Kubernetes.APICache.RetryAttempts = 3
 	}
 	if cfg.Kubernetes.APICache.RetryBaseDelayMillis == 0 {
 		cfg.Kubernetes.APICache.RetryBaseDelayMillis = 200
 	}
+	if cfg.Kubernetes.AnnotationFilters.Deny == nil {
+		cfg.Kubernetes.AnnotationFilters.Deny = []string{"kubectl.kubernetes.io/last-applied-configuration"}
+	}
+	if cfg.Logs.Enabled == nil {
+		enabled := true
+		cfg.Logs.Enabled = &enabled
+	}
 	// default to informers enabled unless explicitly disabled
 	if cfg.Kubernetes.APICache.EnableInformers == nil {
 		enabled := true
@@ -313,5 +509,21 @@ func validate(cfg *Config) error {
 	if len(cfg.Kubernetes.AllowedNamespaces) == 0 {
 		return errors.New("kubernetes.allowed_namespaces is required")
 	}
+	if errs := labelFilterErrors(cfg); len(errs) > 0 {
+		return errors.New(errs[0])
+	}
+	if err := redisPrefixError(cfg); err != "" {
+		return errors.New(err)
+	}
 	return nil
 }
+
+// normalizeBasePath turns "kubelens/", "/kubelens/", and "/kubelens" into
+// "/kubelens", and "/" into "", so routes can be joined as BasePath+"/api/...".
+func normalizeBasePath(path string) string {
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if path == "" {
+		return ""
+	}
+	return "/" + path
+}
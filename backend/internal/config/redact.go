@@ -0,0 +1,42 @@
+package config
+
+import "net/url"
+
+const redactedValue = "[redacted]"
+
+// Redacted returns a copy of c that is safe to log: the client secret is
+// replaced and passwords are masked in every connection URL. The copy shares
+// slices and maps with c, so treat it as read-only.
+func (c *Config) Redacted() *Config {
+	if c == nil {
+		return nil
+	}
+	out := *c
+	if out.Auth.ClientSecret != "" {
+		out.Auth.ClientSecret = redactedValue
+	}
+	out.Storage.DatabaseURL = redactURL(out.Storage.DatabaseURL)
+	out.Cache.RedisURL = redactURL(out.Cache.RedisURL)
+	out.Logs.RedisURLOverride = redactURL(out.Logs.RedisURLOverride)
+	out.Server.AuditRedisURL = redactURL(out.Server.AuditRedisURL)
+	out.Server.AuditHTTPURL = redactURL(out.Server.AuditHTTPURL)
+	out.Server.Otel.Endpoint = redactURL(out.Server.Otel.Endpoint)
+	return &out
+}
+
+// redactURL masks the password in raw, and any query string since tokens are
+// often passed there. Values that are not URLs, such as key=value DSNs, are
+// hidden entirely.
+func redactURL(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" {
+		return redactedValue
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = redactedValue
+	}
+	return u.Redacted()
+}
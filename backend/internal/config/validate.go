@@ -2,7 +2,14 @@ package config
 
 import (
 	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 )
 
 type ValidationResult struct {
@@ -35,6 +42,29 @@ func Validate(cfg *Config) ValidationResult {
 		warns = append(warns, "kubernetes.allowed_namespaces is empty (no namespaces will be accessible)")
 	}
 
+	if ns := cfg.Kubernetes.DefaultNamespace; ns != "" && !containsString(cfg.Kubernetes.AllowedNamespaces, ns) {
+		warns = append(warns, fmt.Sprintf("kubernetes.default_namespace %q is not in kubernetes.allowed_namespaces", ns))
+	}
+
+	if cfg.Kubernetes.MaxListItems < 0 {
+		errs = append(errs, "kubernetes.max_list_items must be >= 0")
+	}
+	if cfg.Kubernetes.MaxEnvEntries < 0 {
+		errs = append(errs, "kubernetes.max_env_entries must be >= 0")
+	}
+
+	if raw := cfg.Kubernetes.PodFilters.IncludeRegex; raw != "" {
+		if _, err := regexp.Compile(raw); err != nil {
+			errs = append(errs, fmt.Sprintf("kubernetes.pod_filters.include_regex is not a valid regex (it would match everything): %v", err))
+		}
+	}
+	if raw := cfg.Kubernetes.AppFilters.IncludeRegex; raw != "" {
+		if _, err := regexp.Compile(raw); err != nil {
+			errs = append(errs, fmt.Sprintf("kubernetes.app_filters.include_regex is not a valid regex (it would match everything): %v", err))
+		}
+	}
+	errs = append(errs, labelFilterErrors(cfg)...)
+
 	if cfg.Kubernetes.AppGroups.Enabled {
 		if cfg.Kubernetes.AppGroups.Labels.Selector == "" {
 			warns = append(warns, "kubernetes.app_groups.labels.selector is empty while app_groups.enabled is true")
@@ -44,9 +74,72 @@ func Validate(cfg *Config) ValidationResult {
 	if cfg.Logs.MaxTailLines <= 0 {
 		warns = append(warns, "logs.max_tail_lines should be > 0")
 	}
+	if cfg.Logs.MaxReplayLines < 0 {
+		errs = append(errs, "logs.max_replay_lines must be >= 0")
+	}
 	if cfg.Logs.MaxLineLength <= 0 {
 		warns = append(warns, "logs.max_line_length should be > 0")
 	}
+	if cfg.Logs.MaxBytesPerRequest < 0 {
+		errs = append(errs, "logs.max_bytes_per_request must be >= 0")
+	}
+
+	if cfg.Logs.MaxStreams < 0 {
+		errs = append(errs, "logs.max_streams must be >= 0")
+	}
+	if cfg.Logs.MaxConcurrentGetLogs < 0 {
+		errs = append(errs, "logs.max_concurrent_getlogs must be >= 0")
+	}
+	if cfg.Logs.MaxPodsPerAppStream < 0 {
+		errs = append(errs, "logs.max_pods_per_app_stream must be >= 0")
+	}
+	if cfg.Logs.ReorderWindowMillis < 0 {
+		errs = append(errs, "logs.reorder_window_ms must be >= 0")
+	} else if cfg.Logs.ReorderWindowMillis > 10000 {
+		warns = append(warns, "logs.reorder_window_ms above 10000 delays ordered app streams noticeably")
+	}
+	if cfg.Logs.ReorderMaxLines < 0 {
+		errs = append(errs, "logs.reorder_max_lines must be >= 0")
+	}
+	if cfg.Logs.AppSubscriberBuffer < MinAppStreamBuffer {
+		errs = append(errs, fmt.Sprintf("logs.app_subscriber_buffer must be >= %d", MinAppStreamBuffer))
+	}
+	if cfg.Logs.AppLogBuffer < MinAppStreamBuffer {
+		errs = append(errs, fmt.Sprintf("logs.app_log_buffer must be >= %d", MinAppStreamBuffer))
+	}
+	if format := cfg.Logs.PrefixFormat; format != "" && !strings.Contains(format, "{pod}") && !strings.Contains(format, "{container}") {
+		warns = append(warns, "logs.prefix_format contains neither {pod} nor {container}")
+	}
+
+	if tz := strings.TrimSpace(cfg.Logs.DisplayTimezone); tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			errs = append(errs, fmt.Sprintf("logs.display_timezone %q is not a valid IANA time zone", tz))
+		}
+	}
+
+	if cfg.Logs.CaptureEnabled {
+		dir := strings.TrimSpace(cfg.Logs.CaptureDir)
+		if dir == "" {
+			errs = append(errs, "logs.capture_dir is required when logs.capture_enabled is true")
+		} else if strings.Contains(dir, "://") {
+			errs = append(errs, "logs.capture_dir must be a local directory (object storage URLs are not supported)")
+		}
+	}
+	if cfg.Logs.CaptureMaxBytes < 0 {
+		errs = append(errs, "logs.capture_max_bytes must be >= 0")
+	}
+	if cfg.Logs.MaxStreamDuration < 0 {
+		errs = append(errs, "logs.max_stream_duration_seconds must be >= 0")
+	}
+	if cfg.Logs.SearchMaxConcurrency < 0 {
+		errs = append(errs, "logs.search_max_concurrency must be >= 0")
+	}
+	if cfg.Logs.SearchMaxBytes < 0 {
+		errs = append(errs, "logs.search_max_bytes must be >= 0")
+	}
+	if cfg.Logs.SearchMaxResults < 0 {
+		errs = append(errs, "logs.search_max_results must be >= 0")
+	}
 
 	if cfg.Logs.UseRedisStreams {
 		redisURL := cfg.Logs.RedisURLOverride
@@ -56,6 +149,74 @@ func Validate(cfg *Config) ValidationResult {
 		if redisURL == "" {
 			errs = append(errs, "logs.use_redis_streams requires cache.redis_url or logs.redis_url")
 		}
+		if cfg.Logs.RedisJanitorSeconds < 0 {
+			errs = append(errs, "logs.redis_janitor_interval_seconds must be >= 0")
+		}
+		if cfg.Logs.RedisPublishBatch < 0 {
+			errs = append(errs, "logs.redis_publish_batch_lines must be >= 0")
+		}
+		if cfg.Logs.RedisPublishMillis < 0 {
+			errs = append(errs, "logs.redis_publish_batch_millis must be >= 0")
+		}
+		override := strings.TrimSpace(cfg.Logs.RedisURLOverride)
+		if override != "" && override == strings.TrimSpace(cfg.Cache.RedisURL) {
+			warns = append(warns, "logs.redis_url is identical to cache.redis_url; leave logs.redis_url empty to share the cache Redis intentionally, or point it at a separate instance")
+		}
+		if err := redisPrefixError(cfg); err != "" {
+			errs = append(errs, err)
+		}
+	}
+
+	if cfg.Server.AuditReads && !cfg.Server.AuditLogs {
+		warns = append(warns, "server.audit_reads has no effect while server.audit_logs is false")
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.Server.AuditFormat)) {
+	case "", "text", "json":
+	default:
+		errs = append(errs, fmt.Sprintf("server.audit_format %q must be text or json", cfg.Server.AuditFormat))
+	}
+
+	switch strings.ToLower(strings.TrimSpace(cfg.Server.AuditSink)) {
+	case "", "stdout":
+	case "redis":
+		if cfg.Server.AuditRedisURL == "" && cfg.Cache.RedisURL == "" {
+			errs = append(errs, "server.audit_sink redis requires server.audit_redis_url or cache.redis_url")
+		}
+	case "http":
+		if cfg.Server.AuditHTTPURL == "" {
+			errs = append(errs, "server.audit_sink http requires server.audit_http_url")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("server.audit_sink %q must be stdout, redis, or http", cfg.Server.AuditSink))
+	}
+
+	for i, proxy := range cfg.Server.TrustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		if strings.Contains(proxy, "/") {
+			if _, _, err := net.ParseCIDR(proxy); err == nil {
+				continue
+			}
+		} else if net.ParseIP(proxy) != nil {
+			continue
+		}
+		errs = append(errs, fmt.Sprintf("server.trusted_proxies[%d] %q is not a valid IP or CIDR", i, proxy))
+	}
+
+	if endpoint := strings.TrimSpace(cfg.Server.Otel.Endpoint); endpoint != "" {
+		if u, err := url.Parse(endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Sprintf("server.otel.endpoint %q must be an absolute URL", endpoint))
+		}
+	}
+	if cfg.Server.Otel.SampleRatio < 0 || cfg.Server.Otel.SampleRatio > 1 {
+		errs = append(errs, "server.otel.sample_ratio must be between 0 and 1")
+	}
+
+	if strings.ContainsAny(cfg.Server.BasePath, "?#") || strings.Contains(cfg.Server.BasePath, "//") {
+		errs = append(errs, fmt.Sprintf("server.base_path %q must be a plain URL path such as /kubelens", cfg.Server.BasePath))
+	}
+
+	if cfg.Server.ShutdownTimeoutSeconds < 0 {
+		errs = append(errs, "server.shutdown_timeout_seconds must be >= 0")
 	}
 
 	if cfg.Server.WriteTimeoutSeconds > 0 {
@@ -82,6 +243,9 @@ func Validate(cfg *Config) ValidationResult {
 	if cfg.Logs.RateLimitPerMinute > 0 && cfg.Logs.RateLimitBurst == 0 {
 		warns = append(warns, "logs.rate_limit_burst is 0; using rate_limit_per_minute as burst is recommended")
 	}
+	if cfg.Logs.GlobalRatePerMinute < 0 || cfg.Logs.GlobalRateBurst < 0 {
+		errs = append(errs, "logs.global_rate_per_minute and logs.global_rate_burst must be >= 0")
+	}
 	for i, override := range cfg.Logs.RateLimitOverrides {
 		prefix := fmt.Sprintf("logs.rate_limit_overrides[%d]", i)
 		if override.Namespace == "" {
@@ -92,8 +256,36 @@ func Validate(cfg *Config) ValidationResult {
 		}
 	}
 
+	for _, ns := range slices.Sorted(maps.Keys(cfg.Logs.NamespaceGroups)) {
+		if !containsString(cfg.Kubernetes.AllowedNamespaces, ns) {
+			warns = append(warns, fmt.Sprintf("logs.namespace_groups.%s is not in kubernetes.allowed_namespaces", ns))
+		}
+		if len(cfg.Logs.NamespaceGroups[ns]) == 0 {
+			warns = append(warns, fmt.Sprintf("logs.namespace_groups.%s lists no groups, so nobody can read its logs", ns))
+		}
+	}
+
+	if file := cfg.TLS.CABundleFile; file != "" {
+		if _, err := os.Stat(file); err != nil {
+			errs = append(errs, fmt.Sprintf("tls.ca_bundle_file: %v", err))
+		}
+	}
+
+	if scope := cfg.Auth.RevealRequiredScope; scope != "" {
+		if strings.ContainsAny(scope, " \t") {
+			errs = append(errs, "auth.reveal_required_scope must be a single scope")
+		}
+		if len(cfg.Auth.AllowedSecretsGroups) == 0 {
+			warns = append(warns, "auth.reveal_required_scope has no effect without auth.allowed_secrets_groups")
+		}
+	}
+
+	if cfg.Auth.StatefulLogin && cfg.Storage.DatabaseURL == "" && (!cfg.Cache.Enabled || cfg.Cache.RedisURL == "") {
+		warns = append(warns, "auth.stateful_login uses the in-memory session store; logins fail when the callback reaches another replica")
+	}
+
 	if cfg.Auth.ClientSecret == "" {
-		warns = append(warns, "auth.client_secret is empty (public client)")
+		warns = append(warns, "auth.client_secret is empty (public client); token exchange requires PKCE (code_verifier)")
 	}
 
 	if strings.TrimSpace(cfg.Server.Address) == "" {
@@ -103,6 +295,9 @@ func Validate(cfg *Config) ValidationResult {
 	if cfg.Kubernetes.APICache.MetricsListTTLSeconds < 0 {
 		errs = append(errs, "kubernetes.api_cache.metrics_list_ttl_seconds must be >= 0")
 	}
+	if cfg.Kubernetes.APICache.SecretTTLSeconds > 30 {
+		warns = append(warns, "kubernetes.api_cache.secret_ttl_seconds above 30 keeps revealed secret values in memory longer than needed")
+	}
 	if cfg.Kubernetes.APICache.MetricsRefreshSeconds < 0 {
 		errs = append(errs, "kubernetes.api_cache.metrics_refresh_seconds must be >= 0")
 	}
@@ -115,3 +310,71 @@ func Validate(cfg *Config) ValidationResult {
 
 	return ValidationResult{Errors: errs, Warnings: warns}
 }
+
+// labelFilterErrors reports label filters and the app group selector whose
+// "key=~regex" form does not compile. LoadFromPath rejects these as well, so
+// a filter never silently stops restricting what users can see.
+func labelFilterErrors(cfg *Config) []string {
+	var errs []string
+	for _, filters := range []struct {
+		name  string
+		items []string
+	}{
+		{"kubernetes.pod_filters.include_labels", cfg.Kubernetes.PodFilters.IncludeLabels},
+		{"kubernetes.pod_filters.exclude_labels", cfg.Kubernetes.PodFilters.ExcludeLabels},
+		{"kubernetes.app_filters.include_labels", cfg.Kubernetes.AppFilters.IncludeLabels},
+		{"kubernetes.app_filters.exclude_labels", cfg.Kubernetes.AppFilters.ExcludeLabels},
+		{"kubernetes.app_groups.labels.selector", []string{cfg.Kubernetes.AppGroups.Labels.Selector}},
+	} {
+		for _, item := range filters.items {
+			if _, expr, ok := strings.Cut(item, "=~"); ok {
+				if _, err := regexp.Compile(strings.TrimSpace(expr)); err != nil {
+					errs = append(errs, fmt.Sprintf("%s: invalid regex in %q: %v", filters.name, item, err))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+func containsString(items []string, target string) bool {
+	for _, item := range items {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
+
+// redisPrefixError reports log stream keys that could collide with session
+// keys when logs and sessions share one Redis. LoadFromPath rejects it too.
+func redisPrefixError(cfg *Config) string {
+	if !cfg.Logs.UseRedisStreams || !cfg.Cache.Enabled {
+		return ""
+	}
+	redisURL := cfg.Logs.RedisURLOverride
+	if redisURL == "" {
+		redisURL = cfg.Cache.RedisURL
+	}
+	if redisURL == "" || strings.TrimSpace(redisURL) != strings.TrimSpace(cfg.Cache.RedisURL) {
+		return ""
+	}
+	streamPrefix := cfg.Logs.RedisStreamPrefix
+	if streamPrefix == "" {
+		streamPrefix = DefaultRedisStreamPrefix
+	}
+	sessionPrefix := cfg.Session.RedisPrefix
+	if sessionPrefix == "" {
+		sessionPrefix = DefaultSessionRedisPrefix
+	}
+	if redisKeyPrefixesOverlap(streamPrefix+":", sessionPrefix) {
+		return fmt.Sprintf("logs.redis_stream_prefix %q overlaps session.redis_prefix %q in the shared Redis", streamPrefix, sessionPrefix)
+	}
+	return ""
+}
+
+// redisKeyPrefixesOverlap reports whether keys under one prefix can also
+// match the other, i.e. one prefix is a prefix of the other.
+func redisKeyPrefixesOverlap(a, b string) bool {
+	return strings.HasPrefix(a, b) || strings.HasPrefix(b, a)
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultRedisPrefixesDoNotOverlap(t *testing.T) {
+	if redisKeyPrefixesOverlap(DefaultRedisStreamPrefix+":", DefaultSessionRedisPrefix) {
+		t.Fatalf("default log stream prefix %q overlaps default session prefix %q", DefaultRedisStreamPrefix, DefaultSessionRedisPrefix)
+	}
+}
+
+func TestRedisKeyPrefixesOverlap(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{"distinct", "kubelens:logs:", "kubelens:session:", false},
+		{"equal", "kubelens:", "kubelens:", true},
+		{"a under b", "kubelens:session:logs:", "kubelens:session:", true},
+		{"b under a", "kubelens:", "kubelens:session:", true},
+		{"shared stem only", "kubelens:log:", "kubelens:logs:", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redisKeyPrefixesOverlap(tt.a, tt.b); got != tt.want {
+				t.Fatalf("redisKeyPrefixesOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedisPrefixError(t *testing.T) {
+	const shared = "redis://redis:6379/0"
+	tests := []struct {
+		name          string
+		logsURL       string
+		streamPrefix  string
+		sessionPrefix string
+		wantErr       bool
+	}{
+		{"defaults", "", "", "", false},
+		{"stream prefix under session prefix", "", "kubelens:session:logs", "", true},
+		{"session prefix under stream prefix", "", "", "kubelens:logs:session:", true},
+		{"same prefix", "", "kubelens", "kubelens:", true},
+		{"separate redis", "redis://logs:6379/0", "kubelens:session:logs", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{}
+			cfg.Cache.Enabled = true
+			cfg.Cache.RedisURL = shared
+			cfg.Logs.UseRedisStreams = true
+			cfg.Logs.RedisURLOverride = tt.logsURL
+			cfg.Logs.RedisStreamPrefix = tt.streamPrefix
+			cfg.Session.RedisPrefix = tt.sessionPrefix
+			if got := redisPrefixError(cfg); (got != "") != tt.wantErr {
+				t.Fatalf("redisPrefixError() = %q, want error %v", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromPathRejectsOverlappingRedisPrefixes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `auth:
+  keycloak_url: https://keycloak.example.com
+  realm: kubelens
+  client_id: kubelens
+  allowed_groups: [devs]
+kubernetes:
+  allowed_namespaces: [default]
+cache:
+  enabled: true
+  redis_url: redis://redis:6379/0
+session:
+  redis_prefix: "kubelens:"
+logs:
+  use_redis_streams: true
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadFromPath(path)
+	if err == nil || !strings.Contains(err.Error(), "redis_stream_prefix") {
+		t.Fatalf("LoadFromPath() error = %v, want redis_stream_prefix overlap", err)
+	}
+}
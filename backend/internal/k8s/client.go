@@ -10,6 +10,7 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/halceonio/kubelens/backend/internal/config"
+	"github.com/halceonio/kubelens/backend/internal/tracing"
 )
 
 func NewClient(cfg config.KubernetesConfig) (*kubernetes.Clientset, error) {
@@ -28,6 +29,7 @@ func NewClients(cfg config.KubernetesConfig) (*kubernetes.Clientset, metadata.In
 	if cfg.API.Burst > 0 {
 		restCfg.Burst = cfg.API.Burst
 	}
+	restCfg.Wrap(tracing.Transport)
 
 	clientset, err := kubernetes.NewForConfig(restCfg)
 	if err != nil {
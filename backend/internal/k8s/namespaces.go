@@ -0,0 +1,26 @@
+package k8s
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// MissingNamespaces returns the names that the apiserver reports as not
+// found. Other errors, such as missing RBAC for namespaces, abort the check.
+func MissingNamespaces(ctx context.Context, client kubernetes.Interface, names []string) ([]string, error) {
+	missing := []string{}
+	for _, name := range names {
+		_, err := client.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			missing = append(missing, name)
+			continue
+		}
+		if err != nil {
+			return missing, err
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,24 @@
+package server
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/halceonio/kubelens/backend/internal/config"
+)
+
+func pprofHandler(configProvider func() *config.Config) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cfg := configProvider(); cfg == nil || !cfg.Server.EnablePprof {
+			http.NotFound(w, r)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
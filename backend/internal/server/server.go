@@ -4,22 +4,29 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"reflect"
+	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/charmbracelet/log"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/metadata"
 
 	"github.com/halceonio/kubelens/backend/internal/api"
 	"github.com/halceonio/kubelens/backend/internal/auth"
 	"github.com/halceonio/kubelens/backend/internal/config"
+	"github.com/halceonio/kubelens/backend/internal/k8s"
 	"github.com/halceonio/kubelens/backend/internal/storage"
+	"github.com/halceonio/kubelens/backend/internal/tracing"
 )
 
+const namespaceCheckTimeout = 15 * time.Second
+
 type Server struct {
 	cfg          atomic.Value
 	auth         auth.VerifierProvider
-	k8sClient    *kubernetes.Clientset
+	k8sClient    kubernetes.Interface
 	metaClient   metadata.Interface
 	sessionStore storage.SessionStore
 	kubeHandler  *dynamicHandler
@@ -27,7 +34,7 @@ type Server struct {
 	httpServer   *http.Server
 }
 
-func New(cfg *config.Config, verifier auth.VerifierProvider, client *kubernetes.Clientset, meta metadata.Interface, sessions storage.SessionStore) *Server {
+func New(cfg *config.Config, verifier auth.VerifierProvider, client kubernetes.Interface, meta metadata.Interface, sessions storage.SessionStore) *Server {
 	s := &Server{
 		auth:         verifier,
 		k8sClient:    client,
@@ -35,6 +42,7 @@ func New(cfg *config.Config, verifier auth.VerifierProvider, client *kubernetes.
 		sessionStore: sessions,
 	}
 	s.cfg.Store(cfg)
+	s.checkNamespaces(cfg)
 	configProvider := func() *config.Config { return s.cfg.Load().(*config.Config) }
 
 	mux := http.NewServeMux()
@@ -47,13 +55,16 @@ func New(cfg *config.Config, verifier auth.VerifierProvider, client *kubernetes.
 	}))
 
 	sessionHandler := api.NewSessionHandler(sessions, cfg.Session.MaxBytes)
-	authHandler := api.NewAuthHandler(configProvider)
+	authHandler := api.NewAuthHandler(configProvider, sessions)
 	authConfigHandler := api.NewAuthConfigHandler(configProvider)
 	configHandler := api.NewConfigHandler(configProvider)
 	configValidateHandler := api.NewConfigValidateHandler(configProvider)
 	mux.Handle("/api/v1/session", auth.Middleware(verifier)(sessionHandler))
+	mux.Handle("/api/v1/me", auth.Middleware(verifier)(api.NewMeHandler()))
 	mux.Handle("/api/v1/auth/token", authHandler)
+	mux.Handle("/api/v1/auth/state", authHandler.StateHandler())
 	mux.Handle("/api/v1/auth/config", authConfigHandler)
+	mux.Handle("/api/v1/openapi.json", api.OpenAPIHandler())
 	mux.Handle("/api/v1/config", auth.Middleware(verifier)(configHandler))
 	mux.Handle("/api/v1/config/validate", auth.Middleware(verifier)(configValidateHandler))
 	mux.Handle("/api/v1/metrics", api.MetricsHandler(func() *api.ResourceStats {
@@ -68,14 +79,20 @@ func New(cfg *config.Config, verifier auth.VerifierProvider, client *kubernetes.
 		return s.kubeImpl.LogStats()
 	}))
 
+	mux.Handle("/debug/pprof/", pprofHandler(configProvider))
+
 	kubeImpl := api.NewKubeHandler(cfg, client, meta)
 	kubeDynamic := newDynamicHandler(auth.Middleware(verifier)(kubeImpl))
 	mux.Handle("/api/v1/namespaces", kubeDynamic)
 	mux.Handle("/api/v1/namespaces/", kubeDynamic)
+	mux.Handle("/api/v1/admin/ratelimits", kubeDynamic)
+	mux.Handle("/api/v1/admin/logstreams", kubeDynamic)
+	mux.Handle("/api/v1/debug/filters", kubeDynamic)
+	mux.Handle("/api/v1/overview", kubeDynamic)
 
 	server := &http.Server{
 		Addr:         cfg.Server.Address,
-		Handler:      mux,
+		Handler:      tracing.Middleware(withBasePath(cfg.Server.BasePath, mux)),
 		ReadTimeout:  time.Duration(cfg.Server.ReadTimeoutSeconds) * time.Second,
 		WriteTimeout: time.Duration(cfg.Server.WriteTimeoutSeconds) * time.Second,
 		IdleTimeout:  time.Duration(cfg.Server.IdleTimeoutSeconds) * time.Second,
@@ -91,19 +108,33 @@ func (s *Server) Start() error {
 	return s.httpServer.ListenAndServe()
 }
 
+// Shutdown stops log streams first so open SSE responses end, then waits for
+// the HTTP server and the log hub to drain within ctx.
 func (s *Server) Shutdown(ctx context.Context) error {
+	var hubErr error
 	if s.kubeImpl != nil {
-		s.kubeImpl.Stop()
+		hubErr = s.kubeImpl.Shutdown(ctx)
 	}
-	return s.httpServer.Shutdown(ctx)
+	return errors.Join(s.httpServer.Shutdown(ctx), hubErr)
+}
+
+// Config returns the most recently applied configuration.
+func (s *Server) Config() *config.Config {
+	return s.cfg.Load().(*config.Config)
 }
 
 func (s *Server) UpdateConfig(cfg *config.Config) {
 	if cfg == nil {
 		return
 	}
+	prev, _ := s.cfg.Load().(*config.Config)
 	s.cfg.Store(cfg)
+	s.checkNamespaces(cfg)
 	if s.k8sClient != nil && s.kubeHandler != nil {
+		if s.kubeImpl != nil && onlyFiltersChanged(prev, cfg) {
+			s.kubeImpl.UpdateFilters(cfg)
+			return
+		}
 		if s.kubeImpl != nil {
 			s.kubeImpl.Stop()
 		}
@@ -111,3 +142,66 @@ func (s *Server) UpdateConfig(cfg *config.Config) {
 		s.kubeHandler.Update(auth.Middleware(s.auth)(s.kubeImpl))
 	}
 }
+
+// withBasePath serves next under basePath, for hosting behind a reverse proxy
+// at a subpath, by stripping the prefix before routing so handlers keep
+// matching on /api/v1/... paths. Health probes stay at the root because the
+// kubelet calls the pod directly. The bare base path redirects to its
+// trailing-slash form, as a mux subtree would. The base path is read once at
+// startup.
+func withBasePath(basePath string, next http.Handler) http.Handler {
+	if basePath == "" {
+		return next
+	}
+	stripped := http.StripPrefix(basePath, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/healthz" || r.URL.Path == "/readyz":
+			next.ServeHTTP(w, r)
+		case strings.HasPrefix(r.URL.Path, basePath+"/"):
+			stripped.ServeHTTP(w, r)
+		case r.URL.Path == basePath:
+			target := basePath + "/"
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// checkNamespaces warns, in the background, about allowed namespaces that do
+// not exist. A typo there otherwise only shows up as unexplained 403s.
+func (s *Server) checkNamespaces(cfg *config.Config) {
+	if !cfg.Kubernetes.ValidateNamespaces || s.k8sClient == nil {
+		return
+	}
+	namespaces := append([]string(nil), cfg.Kubernetes.AllowedNamespaces...)
+	client := s.k8sClient
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), namespaceCheckTimeout)
+		defer cancel()
+		missing, err := k8s.MissingNamespaces(ctx, client, namespaces)
+		if err != nil {
+			log.Warn("allowed namespace check failed", "err", err)
+		}
+		for _, ns := range missing {
+			log.Warn("allowed namespace does not exist", "namespace", ns)
+		}
+	}()
+}
+
+// onlyFiltersChanged reports whether next differs from prev only in the pod,
+// app, and app group filters, which KubeHandler can swap without a rebuild.
+func onlyFiltersChanged(prev, next *config.Config) bool {
+	if prev == nil || next == nil {
+		return false
+	}
+	candidate := *next
+	candidate.Kubernetes.PodFilters = prev.Kubernetes.PodFilters
+	candidate.Kubernetes.AppFilters = prev.Kubernetes.AppFilters
+	candidate.Kubernetes.AppGroups = prev.Kubernetes.AppGroups
+	return reflect.DeepEqual(prev, &candidate)
+}
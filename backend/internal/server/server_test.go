@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/halceonio/kubelens/backend/internal/auth"
+	"github.com/halceonio/kubelens/backend/internal/config"
+	"github.com/halceonio/kubelens/backend/internal/storage"
+)
+
+const testConfig = `auth:
+  keycloak_url: https://keycloak.example.com
+  realm: kubelens
+  client_id: kubelens
+  allowed_groups: [devs]
+kubernetes:
+  allowed_namespaces: [default]
+`
+
+type staticVerifier struct{ user *auth.User }
+
+func (v staticVerifier) AuthenticateRequest(*http.Request) (*auth.User, error) {
+	return v.user, nil
+}
+
+// newTestServer starts a server from testConfig plus any extra YAML.
+func newTestServer(t *testing.T, extra string) *Server {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(testConfig+extra), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadFromPath(path)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	user := &auth.User{Subject: "tester", Name: "tester", Groups: []string{"devs"}, Admin: true}
+	s := New(cfg, staticVerifier{user: user}, fake.NewClientset(), nil, storage.NewMemorySessionStore())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.Shutdown(ctx)
+	})
+	return s
+}
+
+// TestOpenAPIRoutesAreServed keeps the hand-listed OpenAPI routes in sync
+// with the mux and KubeHandler routing: every documented operation must reach
+// a handler, rather than the mux's 404 or a 405 from the handler.
+func TestOpenAPIRoutesAreServed(t *testing.T) {
+	s := newTestServer(t, "")
+	handler := s.httpServer.Handler
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("openapi.json status = %d", rec.Code)
+	}
+	var spec struct {
+		Paths map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+	if len(spec.Paths) == 0 {
+		t.Fatal("spec has no paths")
+	}
+
+	params := strings.NewReplacer("{namespace}", "default", "{pod}", "web-0", "{app}", "web")
+	for path, item := range spec.Paths {
+		for method := range item {
+			method := strings.ToUpper(method)
+			t.Run(method+" "+path, func(t *testing.T) {
+				// Streams never end on their own; the deadline closes them.
+				ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+				defer cancel()
+				req := httptest.NewRequest(method, params.Replace(path), strings.NewReader("{}")).WithContext(ctx)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				if rec.Code == http.StatusNotFound && rec.Body.String() == "404 page not found\n" {
+					t.Fatalf("route is not served")
+				}
+				if rec.Code == http.StatusMethodNotAllowed {
+					t.Fatalf("method is not allowed: %s", rec.Body.String())
+				}
+			})
+		}
+	}
+}
+
+func TestBasePathRouting(t *testing.T) {
+	s := newTestServer(t, "server:\n  base_path: /kubelens/\n")
+	handler := s.httpServer.Handler
+	tests := []struct {
+		name     string
+		target   string
+		want     int
+		location string
+	}{
+		{"prefixed path", "/kubelens/api/v1/openapi.json", http.StatusOK, ""},
+		{"bare prefix", "/kubelens", http.StatusMovedPermanently, "/kubelens/"},
+		{"bare prefix with query", "/kubelens?ns=default", http.StatusMovedPermanently, "/kubelens/?ns=default"},
+		{"root healthz", "/healthz", http.StatusOK, ""},
+		{"unprefixed api", "/api/v1/openapi.json", http.StatusNotFound, ""},
+		{"prefix lookalike", "/kubelensx/api/v1/openapi.json", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.want {
+				t.Fatalf("GET %s = %d, want %d", tt.target, rec.Code, tt.want)
+			}
+			if got := rec.Header().Get("Location"); got != tt.location {
+				t.Fatalf("Location = %q, want %q", got, tt.location)
+			}
+		})
+	}
+}
@@ -26,7 +26,7 @@ func NewSessionStoreFromConfig(ctx context.Context, cfg *config.Config) (Session
 		if err != nil {
 			return nil, BackendRedis, fmt.Errorf("redis session store: %w", err)
 		}
-		return NewRedisSessionStore(client), BackendRedis, nil
+		return NewRedisSessionStore(client, cfg.Session.RedisPrefix), BackendRedis, nil
 	}
 
 	if cfg.Storage.DatabaseURL != "" {
@@ -20,13 +20,24 @@ type SessionStore interface {
 	Delete(ctx context.Context, userID string) error
 }
 
+// ExpiringStore is implemented by stores that can drop a record on their own
+// after ttl. Callers fall back to Put and check SessionRecord.UpdatedAt when a
+// store does not implement it.
+type ExpiringStore interface {
+	PutWithTTL(ctx context.Context, key string, data []byte, ttl time.Duration) error
+}
+
 type MemorySessionStore struct {
 	mu       sync.RWMutex
 	sessions map[string]SessionRecord
+	expires  map[string]time.Time
 }
 
 func NewMemorySessionStore() *MemorySessionStore {
-	return &MemorySessionStore{sessions: make(map[string]SessionRecord)}
+	return &MemorySessionStore{
+		sessions: make(map[string]SessionRecord),
+		expires:  make(map[string]time.Time),
+	}
 }
 
 func (m *MemorySessionStore) Get(_ context.Context, userID string) (*SessionRecord, error) {
@@ -36,6 +47,9 @@ func (m *MemorySessionStore) Get(_ context.Context, userID string) (*SessionReco
 	if !ok {
 		return nil, ErrNotFound
 	}
+	if exp, ok := m.expires[userID]; ok && time.Now().After(exp) {
+		return nil, ErrNotFound
+	}
 	copyData := make([]byte, len(rec.Data))
 	copy(copyData, rec.Data)
 	return &SessionRecord{Data: copyData, UpdatedAt: rec.UpdatedAt}, nil
@@ -47,6 +61,26 @@ func (m *MemorySessionStore) Put(_ context.Context, userID string, data []byte)
 	copyData := make([]byte, len(data))
 	copy(copyData, data)
 	m.sessions[userID] = SessionRecord{Data: copyData, UpdatedAt: time.Now().UTC()}
+	delete(m.expires, userID)
+	return nil
+}
+
+// PutWithTTL stores data like Put and hides it after ttl. Expired entries are
+// swept on each call, so abandoned keys do not accumulate.
+func (m *MemorySessionStore) PutWithTTL(_ context.Context, key string, data []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for k, exp := range m.expires {
+		if now.After(exp) {
+			delete(m.sessions, k)
+			delete(m.expires, k)
+		}
+	}
+	copyData := make([]byte, len(data))
+	copy(copyData, data)
+	m.sessions[key] = SessionRecord{Data: copyData, UpdatedAt: now.UTC()}
+	m.expires[key] = now.Add(ttl)
 	return nil
 }
 
@@ -54,5 +88,6 @@ func (m *MemorySessionStore) Delete(_ context.Context, userID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	delete(m.sessions, userID)
+	delete(m.expires, userID)
 	return nil
 }
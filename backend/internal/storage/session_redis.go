@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/redis/go-redis/v9"
+
+	"github.com/halceonio/kubelens/backend/internal/config"
 )
 
 type RedisSessionStore struct {
@@ -13,8 +15,13 @@ type RedisSessionStore struct {
 	keyPrefix string
 }
 
-func NewRedisSessionStore(client *redis.Client) *RedisSessionStore {
-	return &RedisSessionStore{client: client, keyPrefix: "kubelens:session:"}
+// NewRedisSessionStore stores sessions under keyPrefix+userID. An empty
+// prefix falls back to config.DefaultSessionRedisPrefix.
+func NewRedisSessionStore(client *redis.Client, keyPrefix string) *RedisSessionStore {
+	if keyPrefix == "" {
+		keyPrefix = config.DefaultSessionRedisPrefix
+	}
+	return &RedisSessionStore{client: client, keyPrefix: keyPrefix}
 }
 
 func (r *RedisSessionStore) Get(ctx context.Context, userID string) (*SessionRecord, error) {
@@ -51,6 +58,20 @@ func (r *RedisSessionStore) Put(ctx context.Context, userID string, data []byte)
 	return nil
 }
 
+// PutWithTTL writes the record and its expiry in one transaction.
+func (r *RedisSessionStore) PutWithTTL(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	fullKey := r.keyPrefix + key
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, fullKey, map[string]any{
+			"data":       string(data),
+			"updated_at": time.Now().UTC().Format(time.RFC3339Nano),
+		})
+		pipe.Expire(ctx, fullKey, ttl)
+		return nil
+	})
+	return err
+}
+
 func (r *RedisSessionStore) Delete(ctx context.Context, userID string) error {
 	key := r.keyPrefix + userID
 	if err := r.client.Del(ctx, key).Err(); err != nil {
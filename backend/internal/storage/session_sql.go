@@ -9,12 +9,20 @@ import (
 	"time"
 )
 
+// sqlExpiryLayout is fixed-width, unlike RFC3339Nano, so expiry times in
+// UTC compare correctly as strings.
+const sqlExpiryLayout = "2006-01-02T15:04:05.000000000Z"
+
 type SQLSessionStore struct {
-	db      *sql.DB
-	dialect string
-	getStmt string
-	putStmt string
-	delStmt string
+	db            *sql.DB
+	dialect       string
+	getStmt       string
+	putStmt       string
+	delStmt       string
+	putExpiryStmt string
+	delExpiryStmt string
+	sweepStmt     string
+	sweepExpiry   string
 }
 
 func NewSQLSessionStore(db *sql.DB, dialect string) (*SQLSessionStore, error) {
@@ -31,9 +39,13 @@ func (s *SQLSessionStore) initStatements() {
 	p2 := s.placeholder(2)
 	p3 := s.placeholder(3)
 
-	s.getStmt = fmt.Sprintf("SELECT data, updated_at FROM user_sessions WHERE user_id = %s", p1)
+	s.getStmt = fmt.Sprintf("SELECT s.data, s.updated_at, COALESCE(e.expires_at, '') FROM user_sessions s LEFT JOIN session_expiry e ON e.user_id = s.user_id WHERE s.user_id = %s", p1)
 	s.putStmt = fmt.Sprintf("INSERT INTO user_sessions (user_id, data, updated_at) VALUES (%s, %s, %s) ON CONFLICT (user_id) DO UPDATE SET data = EXCLUDED.data, updated_at = EXCLUDED.updated_at", p1, p2, p3)
 	s.delStmt = fmt.Sprintf("DELETE FROM user_sessions WHERE user_id = %s", p1)
+	s.putExpiryStmt = fmt.Sprintf("INSERT INTO session_expiry (user_id, expires_at) VALUES (%s, %s) ON CONFLICT (user_id) DO UPDATE SET expires_at = EXCLUDED.expires_at", p1, p2)
+	s.delExpiryStmt = fmt.Sprintf("DELETE FROM session_expiry WHERE user_id = %s", p1)
+	s.sweepStmt = fmt.Sprintf("DELETE FROM user_sessions WHERE user_id IN (SELECT user_id FROM session_expiry WHERE expires_at < %s)", p1)
+	s.sweepExpiry = fmt.Sprintf("DELETE FROM session_expiry WHERE expires_at < %s", p1)
 }
 
 func (s *SQLSessionStore) ensureSchema(ctx context.Context) error {
@@ -46,18 +58,31 @@ func (s *SQLSessionStore) ensureSchema(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("create sessions table: %w", err)
 	}
+	// Expiries live in their own table so existing user_sessions tables need
+	// no migration; only keys written with PutWithTTL have a row.
+	expiry := `CREATE TABLE IF NOT EXISTS session_expiry (
+		user_id TEXT PRIMARY KEY,
+		expires_at TEXT NOT NULL
+	)`
+	if _, err := s.db.ExecContext(ctx, expiry); err != nil {
+		return fmt.Errorf("create session expiry table: %w", err)
+	}
 	return nil
 }
 
 func (s *SQLSessionStore) Get(ctx context.Context, userID string) (*SessionRecord, error) {
 	var data string
 	var updated string
-	if err := s.db.QueryRowContext(ctx, s.getStmt, userID).Scan(&data, &updated); err != nil {
+	var expires string
+	if err := s.db.QueryRowContext(ctx, s.getStmt, userID).Scan(&data, &updated, &expires); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNotFound
 		}
 		return nil, err
 	}
+	if expires != "" && expires < time.Now().UTC().Format(sqlExpiryLayout) {
+		return nil, ErrNotFound
+	}
 
 	updatedAt, err := time.Parse(time.RFC3339Nano, updated)
 	if err != nil {
@@ -68,13 +93,55 @@ func (s *SQLSessionStore) Get(ctx context.Context, userID string) (*SessionRecor
 
 func (s *SQLSessionStore) Put(ctx context.Context, userID string, data []byte) error {
 	updatedAt := time.Now().UTC().Format(time.RFC3339Nano)
-	_, err := s.db.ExecContext(ctx, s.putStmt, userID, string(data), updatedAt)
-	return err
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, s.putStmt, userID, string(data), updatedAt); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, s.delExpiryStmt, userID)
+		return err
+	})
+}
+
+// PutWithTTL stores data like Put and hides it after ttl. Expired entries are
+// deleted on each call, so abandoned keys do not accumulate.
+func (s *SQLSessionStore) PutWithTTL(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	now := time.Now().UTC()
+	cutoff := now.Format(sqlExpiryLayout)
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, s.sweepStmt, cutoff); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, s.sweepExpiry, cutoff); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, s.putStmt, key, string(data), now.Format(time.RFC3339Nano)); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, s.putExpiryStmt, key, now.Add(ttl).Format(sqlExpiryLayout))
+		return err
+	})
 }
 
 func (s *SQLSessionStore) Delete(ctx context.Context, userID string) error {
-	_, err := s.db.ExecContext(ctx, s.delStmt, userID)
-	return err
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, s.delStmt, userID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, s.delExpiryStmt, userID)
+		return err
+	})
+}
+
+func (s *SQLSessionStore) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
 }
 
 func (s *SQLSessionStore) placeholder(idx int) string {
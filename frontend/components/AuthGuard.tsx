@@ -1,6 +1,6 @@
 import React, { useCallback, useEffect, useRef, useState } from 'react';
 import { AuthUser } from '../types';
-import { MOCK_CONFIG, USE_MOCKS } from '../constants';
+import { API_BASE, MOCK_CONFIG, USE_MOCKS } from '../constants';
 import { onUnauthorized, resetUnauthorizedState } from '../services/authEvents';
 
 interface AuthGuardProps {
@@ -13,6 +13,7 @@ type JwtClaims = {
   email?: string;
   preferred_username?: string;
   groups?: string[];
+  scope?: string;
   exp?: number;
 };
 
@@ -22,6 +23,8 @@ type AuthConfig = {
   clientId: string;
   allowedGroups: string[];
   allowedSecretsGroups?: string[];
+  statefulLogin?: boolean;
+  revealRequiredScope?: string;
 };
 
 type StoredAuthConfig = AuthConfig & { savedAt: number };
@@ -36,6 +39,7 @@ const AUTH_CONFIG_STORAGE_KEY = 'kubelens_auth_config';
 const AUTH_CONFIG_TTL_MS = 5 * 60 * 1000;
 const ACCESS_TOKEN_STORAGE_KEY = 'kubelens_access_token';
 const OAUTH_STATE_STORAGE_KEY = 'kubelens_oauth_state';
+const PKCE_VERIFIER_STORAGE_KEY = 'kubelens_pkce_verifier';
 const POST_LOGIN_HASH_STORAGE_KEY = 'kubelens_post_login_hash';
 const REDIRECT_TRACKER_STORAGE_KEY = 'kubelens_auth_redirect_tracker';
 const TOKEN_EXPIRY_SKEW_MS = 30 * 1000;
@@ -57,7 +61,9 @@ const loadCachedAuthConfig = (): AuthConfig | null => {
       realm: parsed.realm,
       clientId: parsed.clientId,
       allowedGroups: parsed.allowedGroups?.length ? parsed.allowedGroups : DEFAULT_ALLOWED_GROUPS,
-      allowedSecretsGroups: parsed.allowedSecretsGroups || []
+      allowedSecretsGroups: parsed.allowedSecretsGroups || [],
+      statefulLogin: Boolean(parsed.statefulLogin),
+      revealRequiredScope: parsed.revealRequiredScope || undefined
     };
   } catch {
     return null;
@@ -157,9 +163,47 @@ const clearAuthCookies = () => {
   }
 };
 
+const base64UrlEncode = (bytes: Uint8Array) => {
+  let binary = '';
+  bytes.forEach((b) => { binary += String.fromCharCode(b); });
+  return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+};
+
+// PKCE (RFC 7636) needs crypto.subtle, which browsers only expose in secure
+// contexts; without it the login falls back to a plain code exchange.
+const createPkcePair = async (): Promise<{ verifier: string; challenge: string } | null> => {
+  if (!window.crypto?.subtle) return null;
+  const verifier = base64UrlEncode(window.crypto.getRandomValues(new Uint8Array(32)));
+  const digest = await window.crypto.subtle.digest('SHA-256', new TextEncoder().encode(verifier));
+  return { verifier, challenge: base64UrlEncode(new Uint8Array(digest)) };
+};
+
+// With auth.stateful_login the server issues the state and checks it again at
+// the code exchange; otherwise a random client-side state is used.
+const createLoginState = async (cfg: AuthConfig): Promise<string> => {
+  if (cfg.statefulLogin) {
+    // The response sets an HttpOnly cookie binding the state to this browser.
+    const res = await fetch(`${API_BASE}/auth/state`, {
+      method: 'POST',
+      credentials: 'same-origin',
+      headers: { 'Accept': 'application/json' }
+    });
+    if (!res.ok) {
+      throw new Error(`Failed to start login (${res.status})`);
+    }
+    const payload = await res.json();
+    if (typeof payload?.state !== 'string' || !payload.state) {
+      throw new Error('Missing login state in response');
+    }
+    return payload.state;
+  }
+  return Math.random().toString(36).slice(2) + Math.random().toString(36).slice(2);
+};
+
 const clearLocalAuthState = () => {
   localStorage.removeItem(ACCESS_TOKEN_STORAGE_KEY);
   sessionStorage.removeItem(OAUTH_STATE_STORAGE_KEY);
+  sessionStorage.removeItem(PKCE_VERIFIER_STORAGE_KEY);
   clearAuthCookies();
 };
 
@@ -222,13 +266,21 @@ const AuthGuard: React.FC<AuthGuardProps> = ({ children, onAuth }) => {
     authConfigRef.current = authConfig;
   }, [authConfig]);
 
-  const buildAuthUrl = (cfg: AuthConfig, state: string, redirectUri: string) => {
+  const buildAuthUrl = (cfg: AuthConfig, state: string, redirectUri: string, codeChallenge?: string, reauth?: boolean) => {
     const url = new URL(`${cfg.keycloakUrl}/realms/${cfg.realm}/protocol/openid-connect/auth`);
     url.searchParams.set('response_type', 'code');
     url.searchParams.set('client_id', cfg.clientId);
     url.searchParams.set('redirect_uri', redirectUri);
     url.searchParams.set('scope', 'openid profile email groups');
     url.searchParams.set('state', state);
+    if (codeChallenge) {
+      url.searchParams.set('code_challenge', codeChallenge);
+      url.searchParams.set('code_challenge_method', 'S256');
+    }
+    if (reauth) {
+      // Skip the Keycloak SSO session so auth_time is refreshed.
+      url.searchParams.set('prompt', 'login');
+    }
     return url.toString();
   };
 
@@ -240,7 +292,11 @@ const AuthGuard: React.FC<AuthGuardProps> = ({ children, onAuth }) => {
     const claims = token ? parseJwtClaims(token) : null;
     const groups = claims?.groups ?? (USE_MOCKS ? ['k8s-logs-access', 'developers'] : []);
     const allowedSecretsGroups = cfg?.allowedSecretsGroups || [];
-    const canViewSecrets = allowedSecretsGroups.length > 0 && groups.some((group) => allowedSecretsGroups.includes(group));
+    const scopes = (claims?.scope || '').split(/\s+/).filter(Boolean);
+    const hasRevealScope = !cfg?.revealRequiredScope || scopes.includes(cfg.revealRequiredScope);
+    const canViewSecrets = allowedSecretsGroups.length > 0
+      && groups.some((group) => allowedSecretsGroups.includes(group))
+      && hasRevealScope;
 
     const authUser: AuthUser = {
       username: claims?.preferred_username || claims?.email || claims?.sub || (USE_MOCKS ? 'dev_user' : 'unknown'),
@@ -258,7 +314,7 @@ const AuthGuard: React.FC<AuthGuardProps> = ({ children, onAuth }) => {
     if (onAuth) onAuth(authUser);
   }, [onAuth]);
 
-  const startLoginRedirect = useCallback((cfg: AuthConfig, opts?: { force?: boolean; reason?: string }) => {
+  const startLoginRedirect = useCallback((cfg: AuthConfig, opts?: { force?: boolean; reason?: string; reauth?: boolean }) => {
     if (USE_MOCKS) {
       window.location.reload();
       return;
@@ -289,23 +345,40 @@ const AuthGuard: React.FC<AuthGuardProps> = ({ children, onAuth }) => {
     clearLocalAuthState();
     rememberPostLoginHash();
 
-    const newState = Math.random().toString(36).slice(2) + Math.random().toString(36).slice(2);
-    sessionStorage.setItem(OAUTH_STATE_STORAGE_KEY, newState);
-
     const redirectUri = (import.meta as any).env?.VITE_KEYCLOAK_REDIRECT_URI || `${window.location.origin}${window.location.pathname}`;
-    window.location.assign(buildAuthUrl(cfg, newState, redirectUri));
+    void (async () => {
+      let newState: string;
+      try {
+        newState = await createLoginState(cfg);
+      } catch (err) {
+        isRedirectingRef.current = false;
+        setError(err instanceof Error ? err.message : 'Failed to start login');
+        setLoading(false);
+        return;
+      }
+      sessionStorage.setItem(OAUTH_STATE_STORAGE_KEY, newState);
+
+      const pkce = await createPkcePair().catch(() => null);
+      if (pkce) {
+        sessionStorage.setItem(PKCE_VERIFIER_STORAGE_KEY, pkce.verifier);
+      }
+      window.location.assign(buildAuthUrl(cfg, newState, redirectUri, pkce?.challenge, opts?.reauth));
+    })();
   }, []);
 
   useEffect(() => {
     if (USE_MOCKS) return;
 
-    return onUnauthorized(() => {
+    return onUnauthorized((detail) => {
       const cfg = authConfigRef.current;
       if (!cfg) {
         pendingUnauthorizedRef.current = true;
         return;
       }
-      startLoginRedirect(cfg, { reason: 'unauthorized-response' });
+      startLoginRedirect(cfg, {
+        reason: detail.reauth ? 'reauth-required' : 'unauthorized-response',
+        reauth: detail.reauth
+      });
     });
   }, [startLoginRedirect]);
 
@@ -336,7 +409,7 @@ const AuthGuard: React.FC<AuthGuardProps> = ({ children, onAuth }) => {
         }
 
         try {
-          const res = await fetch('/api/v1/auth/config', { headers: { 'Accept': 'application/json' } });
+          const res = await fetch(`${API_BASE}/auth/config`, { headers: { 'Accept': 'application/json' } });
           if (res.ok) {
             const data = await res.json();
             const keycloakUrl = data?.keycloak_url;
@@ -354,7 +427,11 @@ const AuthGuard: React.FC<AuthGuardProps> = ({ children, onAuth }) => {
                 realm,
                 clientId,
                 allowedGroups: allowedGroups.length ? allowedGroups : DEFAULT_ALLOWED_GROUPS,
-                allowedSecretsGroups
+                allowedSecretsGroups,
+                statefulLogin: data?.stateful_login === true,
+                revealRequiredScope: typeof data?.reveal_required_scope === 'string' && data.reveal_required_scope
+                  ? data.reveal_required_scope
+                  : undefined
               };
               saveCachedAuthConfig(config);
               return config;
@@ -394,20 +471,28 @@ const AuthGuard: React.FC<AuthGuardProps> = ({ children, onAuth }) => {
       const code = urlParams.get('code');
       const state = urlParams.get('state');
       const storedState = sessionStorage.getItem(OAUTH_STATE_STORAGE_KEY);
+      const codeVerifier = sessionStorage.getItem(PKCE_VERIFIER_STORAGE_KEY);
 
       if (code) {
         if (!state || !storedState || state !== storedState) {
           sessionStorage.removeItem(OAUTH_STATE_STORAGE_KEY);
+          sessionStorage.removeItem(PKCE_VERIFIER_STORAGE_KEY);
           setError('Invalid login state. Please try again.');
           setLoading(false);
           return;
         }
 
         try {
-          const res = await fetch('/api/v1/auth/token', {
+          const res = await fetch(`${API_BASE}/auth/token`, {
             method: 'POST',
+            credentials: 'same-origin',
             headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify({ code, redirect_uri: redirectUri })
+            body: JSON.stringify({
+              code,
+              redirect_uri: redirectUri,
+              state,
+              ...(codeVerifier ? { code_verifier: codeVerifier } : {})
+            })
           });
           if (!res.ok) {
             const text = await res.text();
@@ -421,6 +506,7 @@ const AuthGuard: React.FC<AuthGuardProps> = ({ children, onAuth }) => {
           }
 
           sessionStorage.removeItem(OAUTH_STATE_STORAGE_KEY);
+          sessionStorage.removeItem(PKCE_VERIFIER_STORAGE_KEY);
           const postLoginHash = consumePostLoginHash();
           const targetHash = postLoginHash || window.location.hash || '';
           window.history.replaceState({}, document.title, `${window.location.pathname}${targetHash}`);
@@ -5,7 +5,7 @@ import { getPodLogs } from '../services/k8sService';
 import { emitUnauthorized } from '../services/authEvents';
 import { ApiError, isApiErrorStatus } from '../services/http';
 import { FixedSizeList } from 'react-window';
-import { DEFAULT_UI_CONFIG, USE_MOCKS } from '../constants';
+import { API_BASE, DEFAULT_UI_CONFIG, USE_MOCKS } from '../constants';
 
 interface LogViewProps {
   resource: Pod | AppResource;
@@ -414,6 +414,7 @@ const LogView: React.FC<LogViewProps> = ({ resource, onClose, isMaximized, acces
   const isApp = 'type' in resource;
   const initialPods = isApp ? (resource as AppResource).podNames : [(resource as Pod).name];
   const effectiveConfig = config ?? DEFAULT_UI_CONFIG;
+  const logsDisabled = config?.logs?.enabled === false;
   
   const [logs, setLogs] = useState<LogEntry[]>([]);
   const [filter, setFilter] = useState<string>('');
@@ -662,6 +663,13 @@ const LogView: React.FC<LogViewProps> = ({ resource, onClose, isMaximized, acces
       return;
     }
 
+    if (logsDisabled) {
+      setLogs([]);
+      setStreamStatus('paused');
+      setLoadError('Log access is disabled on this server');
+      return;
+    }
+
     if (!accessToken) {
       setStreamStatus('live');
       setLoadError(null);
@@ -705,8 +713,8 @@ const LogView: React.FC<LogViewProps> = ({ resource, onClose, isMaximized, acces
     const connectStream = async () => {
       setStreamStatus('connecting');
       const basePath = isApp
-        ? `/api/v1/namespaces/${resource.namespace}/apps/${resource.name}/logs`
-        : `/api/v1/namespaces/${resource.namespace}/pods/${resource.name}/logs`;
+        ? `${API_BASE}/namespaces/${resource.namespace}/apps/${resource.name}/logs`
+        : `${API_BASE}/namespaces/${resource.namespace}/pods/${resource.name}/logs`;
       const url = new URL(basePath, window.location.origin);
       url.searchParams.set('tail', '500');
       if (selectedContainer) {
@@ -824,7 +832,7 @@ const LogView: React.FC<LogViewProps> = ({ resource, onClose, isMaximized, acces
         streamAbortRef.current.abort();
       }
     };
-  }, [resource.name, resource.namespace, isApp, accessToken, selectedContainer, isPaused]);
+  }, [resource.name, resource.namespace, isApp, accessToken, selectedContainer, isPaused, logsDisabled]);
 
   useEffect(() => {
     const handleClickOutside = (event: MouseEvent) => {
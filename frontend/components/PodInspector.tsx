@@ -33,6 +33,23 @@ const PodInspector: React.FC<PodInspectorProps> = ({ resource, onClose, config,
     setCopiedKey(null);
   }, [resource]);
 
+  // List responses omit env to spare the apiserver; load it from the single-resource endpoint.
+  useEffect(() => {
+    if (!resource?.envOmitted || !accessToken) return;
+    let cancelled = false;
+    const load = 'type' in resource
+      ? getAppByName(resource.namespace, resource.name, accessToken)
+      : getPodByName(resource.namespace, resource.name, accessToken);
+    load
+      .then((updated) => {
+        if (!cancelled && updated) setDisplayResource(updated);
+      })
+      .catch(() => {});
+    return () => {
+      cancelled = true;
+    };
+  }, [resource, accessToken]);
+
   if (!resource) return null;
 
   // Extract prefixed metadata for specialized display
@@ -123,8 +140,9 @@ const PodInspector: React.FC<PodInspectorProps> = ({ resource, onClose, config,
 
   const renderResources = () => {
     const res = resource.resources || { cpuUsage: '0', cpuLimit: '1', memUsage: '0', memLimit: '1', cpuRequest: '0', memRequest: '0' };
-    const cpuPerc = calculatePercent(res.cpuUsage, res.cpuLimit);
-    const memPerc = calculatePercent(res.memUsage, res.memLimit);
+    const serverPercent = (value?: number | null) => (value == null ? null : Math.min(100, value));
+    const cpuPerc = serverPercent(res.cpuUtilPercent?.limit) ?? calculatePercent(res.cpuUsage, res.cpuLimit);
+    const memPerc = serverPercent(res.memUtilPercent?.limit) ?? calculatePercent(res.memUsage, res.memLimit);
 
     const ProgressBar = ({ label, current, request, limit, percent, color }: any) => (
       <div className="mb-8 last:mb-0">
@@ -185,6 +203,45 @@ const PodInspector: React.FC<PodInspectorProps> = ({ resource, onClose, config,
           <div className="h-px bg-slate-100 dark:bg-slate-700/30 my-6" />
           <ProgressBar label="Memory" current={res.memUsage} request={res.memRequest} limit={res.memLimit} percent={memPerc} color="bg-fuchsia-500 shadow-fuchsia-500/20" />
         </div>
+
+        {!isApp && (resource as Pod).qosClass && (
+          <div className="p-4 bg-white dark:bg-slate-900/50 rounded-xl border border-slate-200 dark:border-slate-700/50 shadow-sm flex justify-between items-center transition-colors duration-200">
+            <div>
+              <h4 className="text-[10px] font-bold text-slate-400 dark:text-slate-500 uppercase tracking-widest mb-1">QoS Class</h4>
+              <span className="text-xs mono text-slate-700 dark:text-slate-200">{(resource as Pod).qosClass}</span>
+            </div>
+            {(resource as Pod).evictionRisk && (
+              <span
+                className={`text-[10px] font-bold uppercase tracking-widest px-2 py-1 rounded ${
+                  (resource as Pod).evictionRisk === 'high'
+                    ? 'bg-red-500/10 text-red-500 dark:text-red-400'
+                    : (resource as Pod).evictionRisk === 'medium'
+                      ? 'bg-amber-500/10 text-amber-500 dark:text-amber-400'
+                      : 'bg-emerald-500/10 text-emerald-600 dark:text-emerald-400'
+                }`}
+                title="Likelihood of eviction under node memory pressure, from QoS class and memory usage vs. request"
+              >
+                {(resource as Pod).evictionRisk} eviction risk
+              </span>
+            )}
+          </div>
+        )}
+
+        {res.extended && Object.keys(res.extended).length > 0 && (
+          <div className="p-4 bg-white dark:bg-slate-900/50 rounded-xl border border-slate-200 dark:border-slate-700/50 shadow-sm transition-colors duration-200">
+            <h4 className="text-[10px] font-bold text-slate-400 dark:text-slate-500 uppercase tracking-widest mb-3">Extended Resources</h4>
+            <div className="space-y-2">
+              {Object.entries(res.extended).sort(([a], [b]) => a.localeCompare(b)).map(([name, value]) => (
+                <div key={name} className="flex justify-between text-xs">
+                  <span className="mono text-slate-600 dark:text-slate-300">{name}</span>
+                  <span className="text-[9px] text-slate-400 dark:text-slate-500 uppercase font-bold mono">
+                    Req: {value.request || 'None'} · Lim: {value.limit || 'None'}
+                  </span>
+                </div>
+              ))}
+            </div>
+          </div>
+        )}
       </div>
     );
   };
@@ -218,6 +275,11 @@ const PodInspector: React.FC<PodInspectorProps> = ({ resource, onClose, config,
               </div>
             )}
             {renderKeyValue(displayResource.env || {}, envSecrets, showSecrets)}
+            {displayResource?.envTruncated && (
+              <div className="text-[10px] text-amber-600 dark:text-amber-400">
+                Showing the first {Object.keys(displayResource.env || {}).length} variables; the rest were truncated by the server.
+              </div>
+            )}
           </div>
         );
       }
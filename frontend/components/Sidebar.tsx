@@ -251,7 +251,7 @@ const Sidebar: React.FC<SidebarProps> = ({
     switch (status) {
       case 'Running': case 'Ready': return 'bg-emerald-500';
       case 'Failed': case 'Error': return 'bg-red-500';
-      case 'Pending': return 'bg-amber-500';
+      case 'Pending': case 'Terminating': return 'bg-amber-500';
       default: return 'bg-slate-400 dark:bg-slate-500';
     }
   };
@@ -11,6 +11,10 @@ export const COLORS = {
 
 export const USE_MOCKS = (import.meta as any).env?.VITE_USE_MOCKS === 'true';
 
+// Vite's BASE_URL follows VITE_BASE_PATH, so a build for /kubelens/ calls
+// /kubelens/api/v1; it must match the backend's server.base_path.
+export const API_BASE = `${String((import.meta as any).env?.BASE_URL || '/').replace(/\/+$/, '')}/api/v1`;
+
 export const MOCK_CONFIG = {
   keycloakUrl: 'https://sso.enterprise.com',
   realm: 'production',
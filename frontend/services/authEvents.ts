@@ -1,6 +1,9 @@
 export type UnauthorizedEventDetail = {
   source?: string;
   status?: number;
+  // Set when the server asks for a fresh interactive login (step-up) rather
+  // than rejecting the token outright.
+  reauth?: boolean;
 };
 
 const UNAUTHORIZED_EVENT_NAME = 'kubelens:unauthorized';
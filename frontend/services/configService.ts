@@ -1,7 +1,6 @@
 import { UiConfig } from '../types';
 import { ensureOk } from './http';
-
-const API_BASE = '/api/v1';
+import { API_BASE } from '../constants';
 
 const buildHeaders = (token?: string | null) => {
   if (!token) return {};
@@ -18,7 +18,8 @@ export const ensureOk = async (res: Response, source?: string): Promise<Response
   if (res.ok) return res;
 
   if (res.status === 401) {
-    emitUnauthorized({ source, status: res.status });
+    const challenge = res.headers.get('WWW-Authenticate') || '';
+    emitUnauthorized({ source, status: res.status, reauth: challenge.includes('insufficient_user_authentication') });
   }
 
   let bodyText = '';
@@ -1,10 +1,8 @@
 
-import { Pod, LogEntry, LogLevel, AppResource, Namespace } from '../types';
-import { MOCK_PODS, MOCK_NAMESPACES, USE_MOCKS } from '../constants';
+import { Pod, LogEntry, LogLevel, AppResource, Namespace, NamespacePermissions } from '../types';
+import { MOCK_PODS, MOCK_NAMESPACES, USE_MOCKS, API_BASE } from '../constants';
 import { ensureOk } from './http';
 
-const API_BASE = '/api/v1';
-
 const buildHeaders = (token?: string | null) => {
   if (!token) return {};
   return { Authorization: `Bearer ${token}` };
@@ -77,6 +75,14 @@ export const getNamespaces = async (token?: string | null): Promise<Namespace[]>
   }
 };
 
+export const getNamespacePermissions = async (namespace: string, token?: string | null): Promise<NamespacePermissions> => {
+  if (!token) {
+    if (USE_MOCKS) return { namespace, readLogs: true, revealSecrets: true, write: false, admin: false };
+    throw new Error('Missing access token');
+  }
+  return fetchJSON<NamespacePermissions>(`${API_BASE}/namespaces/${namespace}/permissions`, token);
+};
+
 export const getPods = async (
   namespace: string,
   token?: string | null,
@@ -1,5 +1,6 @@
 import { ResourceIdentifier, SavedView, ViewFilters, LogViewPreferences } from '../types';
 import { ensureOk } from './http';
+import { API_BASE } from '../constants';
 
 export type ThemePreference = 'light' | 'dark';
 
@@ -16,7 +17,7 @@ export interface SessionPayload {
   log_view?: LogViewPreferences;
 }
 
-const SESSION_ENDPOINT = '/api/v1/session';
+const SESSION_ENDPOINT = `${API_BASE}/session`;
 
 const buildHeaders = (token: string) => ({
   'Content-Type': 'application/json',
@@ -34,18 +34,34 @@ export interface ResourceUsage {
   memLimit: string;
   metricsAgeSeconds?: number;
   metricsStale?: boolean;
+  cpuUtilPercent?: UtilPercent;
+  memUtilPercent?: UtilPercent;
+  extended?: Record<string, ExtendedResource>; // e.g. nvidia.com/gpu, hugepages-2Mi
+}
+
+export interface ExtendedResource {
+  request?: string;
+  limit?: string;
+}
+
+// Server-computed usage as a percentage of request and limit; null when unset.
+export interface UtilPercent {
+  request: number | null;
+  limit: number | null;
 }
 
 export interface Pod {
   name: string;
   namespace: string;
-  status: 'Running' | 'Pending' | 'Failed' | 'Succeeded' | 'Unknown';
+  status: 'Running' | 'Pending' | 'Failed' | 'Succeeded' | 'Terminating' | 'Unknown';
   restarts: number;
   age: string;
   labels: Record<string, string>;
   annotations: Record<string, string>;
   env: Record<string, string>;
   envSecrets?: string[];
+  envTruncated?: boolean;
+  envOmitted?: boolean;
   light?: boolean;
   metadataOnly?: boolean;
   containers: Container[];
@@ -54,6 +70,8 @@ export interface Pod {
   configMaps: string[];
   resources: ResourceUsage;
   ownerApp?: string; // Links pod to its Deployment/StatefulSet
+  qosClass?: 'Guaranteed' | 'Burstable' | 'BestEffort' | string;
+  evictionRisk?: 'low' | 'medium' | 'high'; // Detail responses only
 }
 
 export interface AppResource {
@@ -67,6 +85,8 @@ export interface AppResource {
   annotations: Record<string, string>;
   env: Record<string, string>;
   envSecrets?: string[];
+  envTruncated?: boolean;
+  envOmitted?: boolean;
   resources: ResourceUsage;
   volumes: VolumeMount[];
   secrets: string[];
@@ -116,6 +136,14 @@ export interface Namespace {
   name: string;
 }
 
+export interface NamespacePermissions {
+  namespace: string;
+  readLogs: boolean;
+  revealSecrets: boolean;
+  write: boolean;
+  admin: boolean;
+}
+
 export interface AppGroupConfig {
   enabled: boolean;
   labels: {
@@ -127,6 +155,8 @@ export interface AppGroupConfig {
 }
 
 export interface UiConfig {
+  base_path?: string;
+  read_only?: boolean;
   kubernetes: {
     cluster_name?: string;
     allowed_namespaces: string[];
@@ -134,6 +164,7 @@ export interface UiConfig {
     app_groups: AppGroupConfig;
   };
   logs: {
+    enabled?: boolean;
     default_tail_lines: number;
     max_tail_lines: number;
     max_line_length: number;
@@ -4,12 +4,14 @@ import react from '@vitejs/plugin-react';
 
 export default defineConfig(({ mode }) => {
     const env = loadEnv(mode, '.', '');
+    const base = `/${(env.VITE_BASE_PATH || '').replace(/^\/+|\/+$/g, '')}/`.replace('//', '/');
     return {
+      base,
       server: {
         port: 3000,
         host: '0.0.0.0',
         proxy: {
-          '/api': {
+          [`${base}api`]: {
             target: 'http://127.0.0.1:8080',
             changeOrigin: true,
           },
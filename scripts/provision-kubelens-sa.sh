@@ -32,6 +32,9 @@ rules:
       - pods/log
       - secrets
       - configmaps
+      - resourcequotas
+      - limitranges
+      - events
     verbs: ["get", "list", "watch"]
 ---
 apiVersion: rbac.authorization.k8s.io/v1